@@ -0,0 +1,236 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package caller passes the authenticated user between the services.
+//
+// The caller of an RPC is attached to the gRPC metadata as a short-lived JWT
+// signed with HMAC-SHA256 by a key shared by the services. Backends verify it
+// with UnaryServerInterceptor and check the user IDs in the requests against
+// the caller with Check.
+package caller
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// KeysEnv is the environment variable the keys are read from when there
+	// is no keys file.
+	KeysEnv = "IDENTITY_KEYS"
+
+	metadataKey = "x-coffeelog-identity"
+
+	// ttl is how long a token is valid. Tokens are signed for every RPC, so
+	// it only has to cover the clock skew between the services.
+	ttl = time.Minute
+
+	minKeyLength = 32
+)
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Keys are the keys tokens are signed with. The first key is used for
+// signing, all of them are tried for verifying, so that the keys can be
+// rotated one service at a time.
+type Keys [][]byte
+
+// LoadKeys reads the keys from the file at path, or from the IDENTITY_KEYS
+// environment variable if path is empty.
+//
+// Each non-empty line that does not start with "#" holds a base64-encoded
+// key of at least 32 bytes. To rotate the keys, add the new key as the second
+// line everywhere, then move it to the first line, then remove the old one.
+func LoadKeys(path string) (Keys, error) {
+	var (
+		keys Keys
+		err  error
+	)
+	if path != "" {
+		f, ferr := os.Open(path)
+		if ferr != nil {
+			return nil, errors.Wrap(ferr, "failed to open identity keys file")
+		}
+		defer f.Close()
+		keys, err = parseKeys(f)
+	} else if v := os.Getenv(KeysEnv); v != "" {
+		keys, err = parseKeys(strings.NewReader(v))
+	}
+	if err != nil {
+		return nil, err
+	} else if len(keys) == 0 {
+		return nil, errors.Errorf("no identity keys configured (use -identity-keys-file or %s)", KeysEnv)
+	}
+	return keys, nil
+}
+
+func parseKeys(r io.Reader) (Keys, error) {
+	var keys Keys
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		k, err := base64.StdEncoding.DecodeString(line)
+		if err != nil {
+			return nil, errors.Wrapf(err, "identity keys line %d: invalid key", n)
+		} else if len(k) < minKeyLength {
+			return nil, errors.Errorf("identity keys line %d: key must be at least %d bytes, got %d", n, minKeyLength, len(k))
+		}
+		keys = append(keys, k)
+	}
+	return keys, errors.Wrap(sc.Err(), "failed to read identity keys")
+}
+
+type claims struct {
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Sign returns a token asserting that the request is made by the user.
+func (k Keys) Sign(userID string) (string, error) {
+	now := time.Now()
+	b, err := json.Marshal(claims{
+		Subject:   userID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix()})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode claims")
+	}
+	signed := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(b)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign(k[0], signed)), nil
+}
+
+// Verify returns the user the token was signed for.
+func (k Keys) Verify(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return "", errors.New("malformed token")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errors.New("malformed token signature")
+	}
+	signed := parts[0] + "." + parts[1]
+	valid := false
+	for _, key := range k {
+		if hmac.Equal(sig, sign(key, signed)) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return "", errors.New("invalid token signature")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", errors.New("malformed token claims")
+	}
+	var c claims
+	if err := json.Unmarshal(b, &c); err != nil {
+		return "", errors.New("malformed token claims")
+	}
+	if time.Now().Unix() > c.ExpiresAt {
+		return "", errors.New("token expired")
+	} else if c.Subject == "" {
+		return "", errors.New("token has no subject")
+	}
+	return c.Subject, nil
+}
+
+func sign(key []byte, s string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(s))
+	return m.Sum(nil)
+}
+
+type callerKey struct{}
+
+// NewContext returns a context carrying the user making the requests.
+func NewContext(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, callerKey{}, userID)
+}
+
+// FromContext returns the user making the request, or "" if the request is
+// not made on behalf of a user.
+func FromContext(ctx context.Context) string {
+	v, _ := ctx.Value(callerKey{}).(string)
+	return v
+}
+
+// Check returns an error if the request is not made by the user.
+func Check(ctx context.Context, userID string) error {
+	id := FromContext(ctx)
+	if id == "" {
+		return status.Error(codes.Unauthenticated, "request is not made on behalf of a user")
+	} else if id != userID {
+		return status.Error(codes.PermissionDenied, "request is made on behalf of another user")
+	}
+	return nil
+}
+
+// UnaryClientInterceptor attaches a token for the user in the context, if
+// any, to the outgoing requests.
+func UnaryClientInterceptor(k Keys) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if userID := FromContext(ctx); userID != "" {
+			tok, err := k.Sign(userID)
+			if err != nil {
+				return err
+			}
+			md, ok := metadata.FromOutgoingContext(ctx)
+			if !ok {
+				md = metadata.Pairs(metadataKey, tok)
+			} else {
+				md = md.Copy() // metadata is immutable, copy.
+				md[metadataKey] = []string{tok}
+			}
+			ctx = metadata.NewOutgoingContext(ctx, md)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// UnaryServerInterceptor verifies the token of the incoming requests and
+// puts the user in the context of the handler. Requests without a token are
+// handled without a user, and ones with an invalid token are rejected.
+func UnaryServerInterceptor(k Keys) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if v := md[metadataKey]; len(v) > 0 {
+			userID, err := k.Verify(v[0])
+			if err != nil {
+				return nil, status.Error(codes.Unauthenticated, "invalid identity token: "+err.Error())
+			}
+			ctx = NewContext(ctx, userID)
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package caller
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func testKey(c byte) []byte { return []byte(strings.Repeat(string(c), minKeyLength)) }
+
+// signClaims signs the claims as given, to make tokens Sign does not.
+func signClaims(t *testing.T, key []byte, c claims) string {
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(b)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign(key, signed))
+}
+
+func TestVerify(t *testing.T) {
+	oldKey, newKey := testKey('a'), testKey('b')
+	now := time.Now().Unix()
+	signed := func(k Keys) string {
+		tok, err := k.Sign("42")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+	tests := []struct {
+		name    string
+		token   string
+		keys    Keys
+		want    string
+		wantErr string
+	}{
+		{name: "valid",
+			token: signed(Keys{oldKey}), keys: Keys{oldKey}, want: "42"},
+		{name: "wrong key",
+			token: signed(Keys{oldKey}), keys: Keys{newKey}, wantErr: "invalid token signature"},
+		{name: "old key during rotation",
+			token: signed(Keys{oldKey}), keys: Keys{newKey, oldKey}, want: "42"},
+		{name: "new key during rotation",
+			token: signed(Keys{newKey, oldKey}), keys: Keys{oldKey, newKey}, want: "42"},
+		{name: "removed key after rotation",
+			token: signed(Keys{oldKey, newKey}), keys: Keys{newKey}, wantErr: "invalid token signature"},
+		{name: "expired",
+			token: signClaims(t, oldKey, claims{Subject: "42", IssuedAt: now - 120, ExpiresAt: now - 60}),
+			keys:  Keys{oldKey}, wantErr: "token expired"},
+		{name: "no subject",
+			token: signClaims(t, oldKey, claims{IssuedAt: now, ExpiresAt: now + 60}),
+			keys:  Keys{oldKey}, wantErr: "token has no subject"},
+		{name: "tampered claims",
+			token: func() string {
+				p := strings.Split(signed(Keys{oldKey}), ".")
+				c, _ := json.Marshal(claims{Subject: "1", IssuedAt: now, ExpiresAt: now + 60})
+				return p[0] + "." + base64.RawURLEncoding.EncodeToString(c) + "." + p[2]
+			}(),
+			keys: Keys{oldKey}, wantErr: "invalid token signature"},
+		{name: "other algorithm",
+			token: base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + ".e30.",
+			keys:  Keys{oldKey}, wantErr: "malformed token"},
+		{name: "malformed",
+			token: "not a token", keys: Keys{oldKey}, wantErr: "malformed token"},
+	}
+	for _, tt := range tests {
+		got, err := tt.keys.Verify(tt.token)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("%s: got error %v, expected %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		} else if got != tt.want {
+			t.Errorf("%s: got user %q, expected %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(testKey('a'))
+	short := base64.StdEncoding.EncodeToString(testKey('a')[:minKeyLength-1])
+	tests := []struct {
+		name    string
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{name: "empty", in: ""},
+		{name: "one key", in: key + "\n", want: 1},
+		{name: "comments and blank lines", in: "# current\n" + key + "\n\n  # next\n" + key, want: 2},
+		{name: "short key", in: short, wantErr: true},
+		{name: "not base64", in: "not base64!", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseKeys(strings.NewReader(tt.in))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, expected error: %v", tt.name, err, tt.wantErr)
+		} else if len(got) != tt.want {
+			t.Errorf("%s: got %d keys, expected %d", tt.name, len(got), tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		caller string
+		user   string
+		want   codes.Code
+	}{
+		{name: "same user", caller: "42", user: "42", want: codes.OK},
+		{name: "no caller", user: "42", want: codes.Unauthenticated},
+		{name: "other user", caller: "7", user: "42", want: codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		ctx := context.Background()
+		if tt.caller != "" {
+			ctx = NewContext(ctx, tt.caller)
+		}
+		if got := grpc.Code(Check(ctx, tt.user)); got != tt.want {
+			t.Errorf("%s: got %v, expected %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+
+	"cloud.google.com/go/datastore"
+	"github.com/ahmetb/coffeelog/health"
+)
+
+// datastoreCheck checks that Datastore can be read from, by looking up an
+// entity that does not exist.
+func datastoreCheck(ds *datastore.Client) health.Check {
+	k := datastore.NameKey("HealthCheck", "ping", nil)
+	return func(ctx context.Context) error {
+		var v struct{}
+		if err := ds.Get(ctx, k, &v); err != nil && err != datastore.ErrNoSuchEntity {
+			return err
+		}
+		return nil
+	}
+}
@@ -18,13 +18,23 @@ import (
 	"context"
 	"net"
 	"os"
+	"time"
 
 	"flag"
 
 	"cloud.google.com/go/datastore"
-	"cloud.google.com/go/trace"
+	"github.com/ahmetb/coffeelog/caller"
 	pb "github.com/ahmetb/coffeelog/coffeelog"
-	"github.com/ahmetb/coffeelog/version"
+	"github.com/ahmetb/coffeelog/health"
+	healthpb "github.com/ahmetb/coffeelog/health/grpc_health_v1"
+	"github.com/ahmetb/coffeelog/interceptor"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/metrics"
+	"github.com/ahmetb/coffeelog/mtls"
+	"github.com/ahmetb/coffeelog/ratelimit"
+	"github.com/ahmetb/coffeelog/rpcerror"
+	"github.com/ahmetb/coffeelog/shutdown"
+	"github.com/ahmetb/coffeelog/trace"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
@@ -36,6 +46,25 @@ var (
 	projectID            = flag.String("google-project-id", "", "google cloud project id")
 	addr                 = flag.String("addr", ":8000", "[host]:port to listen")
 	gcsBucket            = flag.String("gcs-pics-bucket", "", "name of the public gcs bucket to store picture uploads")
+	identityKeysFile     = flag.String("identity-keys-file", "", "path to the file with the keys of the identity tokens of the callers (default: $"+caller.KeysEnv+")")
+
+	tlsCert = flag.String("tls-cert", "", "path to the tls certificate of the service, for mutual tls with the other services")
+	tlsKey  = flag.String("tls-key", "", "path to the private key of the tls certificate")
+	tlsCA   = flag.String("tls-ca", "", "path to the ca certificates to verify the other services with")
+
+	shutdownDelay     = flag.Duration("shutdown-delay", 0, "how long to fail the readiness checks for on SIGTERM before stopping")
+	shutdownTimeout   = flag.Duration("shutdown-timeout", 20*time.Second, "how long to wait for the requests in flight to finish on SIGTERM")
+	metricsAddr       = flag.String("metrics-addr", "", "[host]:port to serve prometheus metrics on at /metrics (default: disabled)")
+	logLevel          = flag.String("log-level", "info", "least severe level to log: debug, info, warn or error")
+	logFormat         = flag.String("log-format", "json", "format of the logs: json or text")
+	traceExporter     = flag.String("trace-exporter", "none", "where to send the traces: otlp, stdout or none")
+	traceEndpoint     = flag.String("trace-otlp-endpoint", "", "base url of the otlp/http receiver for -trace-exporter=otlp, such as http://otel-collector:4318")
+	traceSampleRatio  = flag.Float64("trace-sample-ratio", 1.0, "fraction of the new traces to sample")
+	traceMaxPerSecond = flag.Float64("trace-max-per-second", 10, "most new traces to sample per second, 0 for no limit")
+	probe             = flag.Bool("probe", false, "check whether the service listening on -addr is serving and exit, for the kubernetes probes")
+
+	rateLimitPost     = flag.String("rate-limit-post", "20/m", "activities a user can post, as N/s, N/m or N/h, 0 for no limit")
+	rateLimitRoasters = flag.String("rate-limit-roasters", "600/m", "roaster lists a user can request, 0 for no limit")
 
 	log *logrus.Entry
 )
@@ -43,19 +72,24 @@ var (
 func main() {
 	flag.Parse()
 	ctx := context.Background()
-	host, err := os.Hostname()
+	var err error
+	log, err = logging.New("coffeedirectory", *logLevel, *logFormat)
 	if err != nil {
-		log.Fatal(errors.Wrap(err, "cannot get hostname"))
+		logrus.Fatal(err)
 	}
-	logrus.SetLevel(logrus.DebugLevel)
-	logrus.SetFormatter(&logrus.JSONFormatter{FieldMap: logrus.FieldMap{logrus.FieldKeyLevel: "severity"}})
-	log = logrus.WithFields(logrus.Fields{
-		"service": "coffeedirectory",
-		"host":    host,
-		"v":       version.Version(),
-	})
 	grpclog.SetLogger(log.WithField("facility", "grpc"))
 
+	tr, err := mtls.Load(mtls.Config{CertFile: *tlsCert, KeyFile: *tlsKey, CAFile: *tlsCA}, log)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "failed to load tls files"))
+	}
+	if *probe {
+		if err := health.Probe(*addr, tr.DialOption()); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	if env := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); env == "" {
 		log.Fatal("GOOGLE_APPLICATION_CREDENTIALS environment variable is not set")
 	}
@@ -68,6 +102,10 @@ func main() {
 	if *gcsBucket == "" {
 		log.Fatal("gcs bucket name is not set")
 	}
+	idKeys, err := caller.LoadKeys(*identityKeysFile)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	ds, err := datastore.NewClient(ctx, *projectID)
 	if err != nil {
@@ -75,13 +113,20 @@ func main() {
 	}
 	defer ds.Close()
 
-	tc, err := trace.NewClient(ctx, *projectID)
+	tc, err := trace.NewClient(trace.Config{
+		Service:      "coffeedirectory",
+		Exporter:     *traceExporter,
+		Endpoint:     *traceEndpoint,
+		SampleRatio:  *traceSampleRatio,
+		MaxPerSecond: *traceMaxPerSecond}, log)
 	if err != nil {
 		log.Fatal(errors.Wrap(err, "failed to initialize tracing client"))
 	}
 	cc, err := grpc.Dial(*userDirectoryBackend,
-		grpc.WithInsecure(),
-		grpc.WithUnaryInterceptor(tc.GRPCClientInterceptor()))
+		tr.DialOption(),
+		grpc.WithUnaryInterceptor(interceptor.ChainUnaryClient(
+			tc.GRPCClientInterceptor(),
+			metrics.UnaryClientInterceptor())))
 	if err != nil {
 		log.Fatal(errors.Wrap(err, "failed to contact user directory"))
 	}
@@ -90,23 +135,57 @@ func main() {
 		cc.Close()
 	}()
 
-	ts, err := trace.NewLimitedSampler(1.0, 10)
-	if err != nil {
-		log.Fatal(errors.Wrap(err, "failed to initialize sampling policy"))
+	limits := make(map[string]ratelimit.Limit)
+	for method, v := range map[string]string{
+		"/ActivityDirectory/PostActivity": *rateLimitPost,
+		"/RoasterDirectory/ListRoasters":  *rateLimitRoasters,
+	} {
+		l, err := ratelimit.ParseLimit(v)
+		if err != nil {
+			log.Fatal(err)
+		}
+		limits[method] = l
 	}
-	tc.SetSamplingPolicy(ts)
 
 	lis, err := net.Listen("tcp", *addr)
 	if err != nil {
 		log.Fatal(err)
 	}
-	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(tc.GRPCServerInterceptor()))
+	grpcServer := grpc.NewServer(append(tr.ServerOptions(), grpc.UnaryInterceptor(interceptor.ChainUnaryServer(
+		tc.GRPCServerInterceptor(),
+		metrics.UnaryServerInterceptor(),
+		peerPolicy.UnaryServerInterceptor(),
+		caller.UnaryServerInterceptor(idKeys),
+		logging.UnaryServerInterceptor(log),
+		rpcerror.UnaryServerInterceptor(),
+		ratelimit.UnaryServerInterceptor(ratelimit.NewMemoryStore(ratelimit.DefaultMaxKeys), limits))))...)
 	svc := &service{ds, pb.NewUserDirectoryClient(cc)}
 	pb.RegisterRoasterDirectoryServer(grpcServer, svc)
 	pb.RegisterActivityDirectoryServer(grpcServer, svc)
+	sd := shutdown.Watch(log, *shutdownDelay, *shutdownTimeout)
+	hs := health.NewServer(log, sd.Draining, "RoasterDirectory", "ActivityDirectory")
+	hs.AddCheck("datastore", datastoreCheck(ds))
+	hs.AddCheck("userdirectory", health.PeerCheck(cc))
+	hs.Start(ctx)
+	healthpb.RegisterHealthServer(grpcServer, hs)
 	log.WithFields(logrus.Fields{"addr": *addr,
 		"service":       "coffeedirectory",
 		"userdirectory": *userDirectoryBackend,
 	}).Info("starting to listen on grpc")
-	log.Fatal(grpcServer.Serve(lis))
+	if *metricsAddr != "" {
+		go func() {
+			log.WithField("addr", *metricsAddr).Info("serving metrics")
+			log.Fatal(errors.Wrap(metrics.ListenAndServe(*metricsAddr), "failed to serve metrics"))
+		}()
+	}
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil && !sd.Draining() {
+			log.Fatal(err)
+		}
+	}()
+
+	stopCtx, cancel := sd.Wait()
+	defer cancel()
+	sd.StopGRPC(stopCtx, grpcServer)
+	sd.FlushTraces(tc.Shutdown)
 }
@@ -0,0 +1,26 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "github.com/ahmetb/coffeelog/metrics"
+
+var (
+	activitiesPosted = metrics.NewCounter("coffeelog_activities_posted_total",
+		"Number of activities posted.")
+	roastersCreated = metrics.NewCounter("coffeelog_roasters_created_total",
+		"Number of roasters created.")
+	pictureUploadBytes = metrics.NewCounter("coffeelog_picture_upload_bytes_total",
+		"Total size of the pictures uploaded, in bytes.")
+)
@@ -0,0 +1,73 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"cloud.google.com/go/datastore"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxPageSize is the most results a page can have.
+const maxPageSize = 100
+
+// queryPage runs the query from the datastore cursor in the page token and
+// calls load with the iterator for each entity. load loads the entity and
+// reports whether it belongs on the page, and add adds the last entity
+// load accepted to the page, until it has size entities. Without a size all
+// entities are added. It returns the token of the next page, which is empty
+// if there are no more results.
+func queryPage(ctx context.Context, ds *datastore.Client, q *datastore.Query, size int32, token string,
+	load func(*datastore.Iterator) (bool, error), add func()) (string, error) {
+	if token != "" {
+		cur, err := datastore.DecodeCursor(token)
+		if err != nil {
+			return "", status.Error(codes.InvalidArgument, "invalid page token")
+		}
+		q = q.Start(cur)
+	}
+	if size > maxPageSize {
+		size = maxPageSize
+	}
+
+	it := ds.Run(ctx, q)
+	var (
+		n    int32
+		next datastore.Cursor
+	)
+	for {
+		ok, err := load(it)
+		if err == iterator.Done {
+			return "", nil
+		} else if err != nil {
+			return "", err
+		} else if !ok {
+			continue
+		}
+		if size > 0 && n == size {
+			// there is one more result, so the page is not the last
+			return next.String(), nil
+		}
+		add()
+		if n++; size > 0 && n == size {
+			if next, err = it.Cursor(); err != nil {
+				return "", errors.Wrap(err, "failed to get the datastore cursor")
+			}
+		}
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "github.com/ahmetb/coffeelog/mtls"
+
+// peerPolicy lists the services that may call each method over mutual TLS.
+var peerPolicy = mtls.Policy{}.
+	Allow("RoasterDirectory", []string{"web", "gateway"},
+		"GetRoaster", "ListRoasters").
+	Allow("RoasterDirectory", []string{"web"},
+		"CreateRoaster").
+	Allow("ActivityDirectory", []string{"web", "gateway"},
+		"PostActivity", "GetActivity", "GetUserActivities").
+	Allow("ActivityDirectory", []string{"web"},
+		"UploadPicture").
+	Allow("ActivityDirectory", []string{"userdirectory"},
+		"PurgeUser").
+	Allow("grpc.health.v1.Health", []string{"web", "gateway", "userdirectory", "coffeedirectory"},
+		"Check")
@@ -0,0 +1,77 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/ahmetb/coffeelog/caller"
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/trace"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// checkViewer returns an error if the request is not made by the viewer,
+// unless it is made anonymously.
+func checkViewer(ctx context.Context, viewerID string) error {
+	if viewerID == "" {
+		return nil
+	}
+	return caller.Check(ctx, viewerID)
+}
+
+// access decides which content of the owner the viewer can see. Content the
+// viewer cannot see is reported as not found, so that its existence is not
+// revealed.
+type access struct {
+	userSvc  pb.UserDirectoryClient
+	viewerID string // empty for anonymous visitors
+	owner    *pb.User
+
+	follower *bool // looked up on first use
+}
+
+// allowed reports whether the viewer can see content with the visibility,
+// which is the visibility of the account for DEFAULT.
+func (a *access) allowed(ctx context.Context, v pb.Visibility) (bool, error) {
+	if a.viewerID != "" && a.viewerID == a.owner.GetID() {
+		return true, nil
+	}
+	if v == pb.Visibility_DEFAULT {
+		v = a.owner.GetVisibility()
+	}
+	switch v {
+	case pb.Visibility_DEFAULT, pb.Visibility_PUBLIC:
+		return true, nil
+	case pb.Visibility_FOLLOWERS:
+		if a.viewerID == "" {
+			return false, nil
+		}
+		if a.follower == nil {
+			cs := trace.FromContext(ctx).NewChild("rpc.sent/GetFollow")
+			f, err := a.userSvc.GetFollow(trace.NewContext(ctx, cs), &pb.FollowRequest{
+				UserID:     a.owner.GetID(),
+				FollowerID: a.viewerID})
+			cs.Finish()
+			if err != nil {
+				return false, errors.Wrap(err, "failed to look up follow")
+			}
+			ok := f.GetFollowing() && !f.GetPending()
+			a.follower = &ok
+		}
+		return *a.follower, nil
+	default:
+		return false, nil
+	}
+}
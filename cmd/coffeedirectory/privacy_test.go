@@ -0,0 +1,81 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// follows is a user directory with the follow of the viewer, counting the
+// lookups.
+type follows struct {
+	pb.UserDirectoryClient
+	resp  pb.FollowResponse
+	calls int
+}
+
+func (f *follows) GetFollow(ctx context.Context, in *pb.FollowRequest, opts ...grpc.CallOption) (*pb.FollowResponse, error) {
+	f.calls++
+	return &f.resp, nil
+}
+
+func TestAccessAllowed(t *testing.T) {
+	approved := pb.FollowResponse{Following: true}
+	pending := pb.FollowResponse{Following: true, Pending: true}
+	tests := []struct {
+		name       string
+		viewer     string
+		account    pb.Visibility
+		visibility pb.Visibility
+		follow     pb.FollowResponse
+		want       bool
+	}{
+		{name: "public", visibility: pb.Visibility_PUBLIC, want: true},
+		{name: "public account", account: pb.Visibility_PUBLIC, want: true},
+		{name: "account without visibility", want: true},
+		{name: "private", viewer: "2", visibility: pb.Visibility_PRIVATE, follow: approved},
+		{name: "private account", viewer: "2", account: pb.Visibility_PRIVATE},
+		{name: "private to the owner", viewer: "1", visibility: pb.Visibility_PRIVATE, want: true},
+		{name: "public overriding a private account", account: pb.Visibility_PRIVATE, visibility: pb.Visibility_PUBLIC, want: true},
+		{name: "followers to anonymous", visibility: pb.Visibility_FOLLOWERS},
+		{name: "followers to others", viewer: "2", visibility: pb.Visibility_FOLLOWERS},
+		{name: "followers to a pending follower", viewer: "2", visibility: pb.Visibility_FOLLOWERS, follow: pending},
+		{name: "followers to a follower", viewer: "2", visibility: pb.Visibility_FOLLOWERS, follow: approved, want: true},
+		{name: "followers account to a follower", viewer: "2", account: pb.Visibility_FOLLOWERS, follow: approved, want: true},
+		{name: "unknown visibility", viewer: "2", visibility: pb.Visibility(42), follow: approved},
+	}
+	for _, tt := range tests {
+		svc := &follows{resp: tt.follow}
+		a := &access{
+			userSvc:  svc,
+			viewerID: tt.viewer,
+			owner:    &pb.User{ID: "1", Visibility: tt.account}}
+		for i := 0; i < 2; i++ {
+			got, err := a.allowed(context.Background(), tt.visibility)
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.name, err)
+			} else if got != tt.want {
+				t.Errorf("%s: got %v, expected %v", tt.name, got, tt.want)
+			}
+		}
+		if svc.calls > 1 {
+			t.Errorf("%s: looked up the follow %d times, expected at most once", tt.name, svc.calls)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/datastore"
+	"cloud.google.com/go/storage"
+	"github.com/ahmetb/coffeelog/caller"
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/trace"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+// purgeBatchSize is the number of activities deleted by a PurgeUser call.
+const purgeBatchSize = 100
+
+func (c *service) PurgeUser(ctx context.Context, req *pb.PurgeUserRequest) (*pb.PurgeUserResponse, error) {
+	span := trace.FromContext(ctx).NewChild("coffeesvc/PurgeUser")
+	defer span.Finish()
+
+	if err := caller.Check(ctx, req.GetUserID()); err != nil {
+		return nil, err
+	}
+
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"op":      "PurgeUser",
+		"user.id": req.GetUserID()})
+	log.Debug("received request")
+
+	cl, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create storage client")
+	}
+	defer cl.Close()
+
+	cs := span.NewChild("datastore/query/activity/by_user")
+	var v []activity
+	_, err = c.ds.GetAll(ctx, datastore.NewQuery(kindActivity).
+		Filter("UserID =", req.GetUserID()).Limit(purgeBatchSize), &v)
+	cs.Finish()
+	if err != nil {
+		log.WithField("error", err).Error("failed to query the datastore")
+		return nil, errors.Wrap(err, "failed to query")
+	}
+
+	// pictures are deleted before the activities, so that a failure leaves
+	// the activity referring to them to be retried
+	keys := make([]*datastore.Key, len(v))
+	for i, a := range v {
+		if err := deletePicture(ctx, cl, *gcsBucket, a.PictureURL); err != nil {
+			return nil, err
+		}
+		keys[i] = a.K
+	}
+	if err := c.ds.DeleteMulti(ctx, keys); err != nil {
+		log.WithField("error", err).Error("failed to delete from datastore")
+		return nil, errors.Wrap(err, "failed to delete")
+	}
+	if len(v) == purgeBatchSize {
+		log.WithField("count", len(v)).Debug("deleted activities")
+		return &pb.PurgeUserResponse{Deleted: int32(len(v))}, nil
+	}
+
+	for _, u := range req.GetPictureURLs() {
+		if err := deletePicture(ctx, cl, *gcsBucket, u); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.anonymizeRoasters(ctx, req.GetUserID()); err != nil {
+		return nil, err
+	}
+	log.WithField("count", len(v)).Info("purged user")
+	return &pb.PurgeUserResponse{Deleted: int32(len(v)), Done: true}, nil
+}
+
+// deletePicture deletes the picture at the url if it is stored in the
+// bucket, and does nothing otherwise.
+func deletePicture(ctx context.Context, cl *storage.Client, bucket, url string) error {
+	log := logging.FromContext(ctx)
+	prefix := fmt.Sprintf("https://%s.storage.googleapis.com/", bucket)
+	if !strings.HasPrefix(url, prefix) {
+		return nil
+	}
+	span := trace.FromContext(ctx).NewChild("gcs/delete")
+	defer span.Finish()
+
+	fn := strings.TrimPrefix(url, prefix)
+	if err := cl.Bucket(bucket).Object(fn).Delete(ctx); err != nil && err != storage.ErrObjectNotExist {
+		return errors.Wrapf(err, "failed to delete picture %q", fn)
+	}
+	log.WithFields(logrus.Fields{
+		"bucket": bucket,
+		"object": fn}).Debug("deleted file")
+	return nil
+}
+
+// anonymizeRoasters removes the user from the roasters they created, which
+// are shared with the activities of others and therefore kept.
+func (c *service) anonymizeRoasters(ctx context.Context, userID string) error {
+	var v []roaster
+	if _, err := c.ds.GetAll(ctx, datastore.NewQuery(kindRoaster).Filter("CreatedBy =", userID), &v); err != nil {
+		return errors.Wrap(err, "failed to query roasters")
+	}
+	if len(v) == 0 {
+		return nil
+	}
+	keys := make([]*datastore.Key, len(v))
+	for i := range v {
+		v[i].CreatedBy = ""
+		keys[i] = v[i].K
+	}
+	_, err := c.ds.PutMulti(ctx, keys, v)
+	return errors.Wrap(err, "failed to save roasters")
+}
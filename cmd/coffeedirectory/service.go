@@ -17,17 +17,23 @@ package main
 import (
 	"fmt"
 	"path"
+	"strings"
 	"time"
 
 	"cloud.google.com/go/datastore"
 	"cloud.google.com/go/storage"
-	"cloud.google.com/go/trace"
+	"github.com/ahmetb/coffeelog/caller"
 	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/trace"
+	"github.com/ahmetb/coffeelog/validation"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -40,12 +46,14 @@ type service struct {
 	userSvc pb.UserDirectoryClient
 }
 
-// roaster as represented in Datastore.
+// roaster as represented in Datastore. CreatedBy is the ID of the user who
+// created it, which is cleared when the user is deleted, and is empty for the
+// roasters created before it was recorded.
 type roaster struct {
 	K         *datastore.Key `datastore:"__key__"`
 	Name      string         `datastore:"Name"`
 	Picture   string         `datastore:"Picture,noindex"`
-	CreatedBy string         `datastore:"CreatedBy"` // TODO use
+	CreatedBy string         `datastore:"CreatedBy"`
 }
 
 func (r *roaster) ToProto() *pb.Roaster {
@@ -71,8 +79,11 @@ type activity struct {
 	RoasterName string         `datastore:"RoasterName,noindex"`
 	Notes       string         `datastore:"Notes,noindex"`
 	PictureURL  string         `datastore:"PictureURL,noindex"`
+	Visibility  int32          `datastore:"Visibility,noindex"`
 }
 
+// ToProto returns the activity with the public profile of its owner u, as
+// the settings of the user are only shown to the user.
 func (v *activity) ToProto(u *pb.User) (*pb.Activity, error) {
 	dateTs, err := ptypes.TimestampProto(v.Date)
 	if err != nil {
@@ -82,9 +93,15 @@ func (v *activity) ToProto(u *pb.User) (*pb.Activity, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to parse date from proto")
 	}
+	owner := &pb.User{
+		ID:          u.GetID(),
+		DisplayName: u.GetDisplayName(),
+		Picture:     u.GetPicture(),
+		Bio:         u.GetBio(),
+		Username:    u.GetUsername()}
 	return &pb.Activity{
 		ID:         v.K.ID,
-		User:       u,
+		User:       owner,
 		Drink:      v.Drink,
 		Method:     v.Method,
 		Homebrew:   v.Homebrew,
@@ -98,11 +115,13 @@ func (v *activity) ToProto(u *pb.User) (*pb.Activity, error) {
 		Amount: &pb.Activity_DrinkAmount{
 			N:    v.Amount,
 			Unit: pb.Activity_DrinkAmount_CaffeineUnit(pb.Activity_DrinkAmount_CaffeineUnit_value[v.AmountUnit])},
-		Notes: v.Notes,
+		Notes:      v.Notes,
+		Visibility: pb.Visibility(v.Visibility),
 	}, nil
 }
 
 func (c *service) GetRoaster(ctx context.Context, req *pb.RoasterRequest) (*pb.RoasterResponse, error) {
+	log := logging.FromContext(ctx)
 	span := trace.FromContext(ctx).NewChild("datastore/roaster/query/by_id")
 	defer span.Finish()
 
@@ -116,8 +135,7 @@ func (c *service) GetRoaster(ctx context.Context, req *pb.RoasterRequest) (*pb.R
 		q = q.Filter("__key__ =", datastore.IDKey(kindRoaster, req.GetID(), nil))
 	}
 	if _, err := c.ds.GetAll(ctx, q.Limit(1), &v); err != nil {
-		log.WithField("error", err).Error("failed to query datastore")
-		return nil, errors.New("failed to retrieve roaster")
+		return nil, errors.Wrap(err, "failed to retrieve roaster")
 	} else if len(v) == 0 {
 		return &pb.RoasterResponse{Found: false}, nil
 	}
@@ -126,17 +144,25 @@ func (c *service) GetRoaster(ctx context.Context, req *pb.RoasterRequest) (*pb.R
 }
 
 func (c *service) CreateRoaster(ctx context.Context, req *pb.RoasterCreateRequest) (*pb.Roaster, error) {
+	log := logging.FromContext(ctx)
 	span := trace.FromContext(ctx).NewChild("coffeesvc/CreateRoaster")
 	defer span.Finish()
 
+	userID := caller.FromContext(ctx)
+	if userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "roasters can only be created by users")
+	}
+
 	cs := span.NewChild("datastore/roaster/put")
 	k, err := c.ds.Put(ctx, datastore.IncompleteKey(kindRoaster, nil), &roaster{
-		Name: req.Name})
+		Name:      req.Name,
+		CreatedBy: userID})
 	if err != nil {
 		log.WithField("error", err).Error("failed to insert to datastore")
 		return new(pb.Roaster), errors.New("failed to save the roaster")
 	}
 	cs.Finish()
+	roastersCreated.Inc()
 
 	cs = span.NewChild("datastore/roaster/get/by_id")
 	defer cs.Finish()
@@ -151,36 +177,51 @@ func (c *service) CreateRoaster(ctx context.Context, req *pb.RoasterCreateReques
 	return r.GetRoaster(), nil
 }
 
-func (c *service) ListRoasters(ctx context.Context, _ *pb.RoastersRequest) (*pb.RoastersResponse, error) {
+func (c *service) ListRoasters(ctx context.Context, req *pb.RoastersRequest) (*pb.RoastersResponse, error) {
+	log := logging.FromContext(ctx)
 	span := trace.FromContext(ctx).NewChild("datastore/roaster/list")
 	defer span.Finish()
 
 	resp := new(pb.RoastersResponse)
-	var data []roaster
-	if _, err := c.ds.GetAll(ctx, datastore.NewQuery(kindRoaster), &data); err != nil {
-		log.WithField("error", err).Error("datastore query failed")
-		return resp, errors.New("failed to retrieve roasters")
-	}
-
-	var r []*pb.Roaster
-	for _, v := range data {
-		r = append(r, v.ToProto())
+	q := strings.ToLower(req.GetQuery())
+	var v roaster
+	next, err := queryPage(ctx, c.ds, datastore.NewQuery(kindRoaster).Order("__key__"), req.GetPageSize(), req.GetPageToken(),
+		func(it *datastore.Iterator) (bool, error) {
+			v = roaster{}
+			if _, err := it.Next(&v); err != nil {
+				return false, err
+			}
+			return strings.Contains(strings.ToLower(v.Name), q), nil
+		},
+		func() { resp.Results = append(resp.Results, v.ToProto()) })
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query roasters")
 	}
-	log.WithField("count", len(r)).Debug("retrieved roasters list")
-	resp.Results = r
+	log.WithField("count", len(resp.Results)).Debug("retrieved roasters list")
+	resp.NextPageToken = next
 	return resp, nil
 }
 
 func (c *service) PostActivity(ctx context.Context, req *pb.PostActivityRequest) (*pb.PostActivityResponse, error) {
+	log := logging.FromContext(ctx)
 	span := trace.FromContext(ctx).NewChild("coffeesvc/PostActivity")
 	defer span.Finish()
 
+	if err := caller.Check(ctx, req.GetUserID()); err != nil {
+		return nil, err
+	}
+
+	errs := make(validation.Errors)
+	if validation.Activity(req, errs); len(errs) > 0 {
+		return nil, status.Error(codes.InvalidArgument, errs.Error())
+	}
+
 	// resolve the roaster
 	e := log.WithField("roaster.name", req.GetRoasterName())
 	e.Debug("resolving roaster for activity")
 	var roaster *pb.Roaster
 	if rr, err := c.GetRoaster(ctx, &pb.RoasterRequest{Query: &pb.RoasterRequest_Name{Name: req.GetRoasterName()}}); err != nil {
-		return nil, errors.New("failed to query roaster by name")
+		return nil, errors.Wrap(err, "failed to query roaster by name")
 	} else if !rr.GetFound() {
 		e.Debug("roaster not found, creating")
 		rcr, err := c.CreateRoaster(ctx, &pb.RoasterCreateRequest{Name: req.GetRoasterName()})
@@ -208,7 +249,7 @@ func (c *service) PostActivity(ctx context.Context, req *pb.PostActivityRequest)
 
 	ts, err := ptypes.Timestamp(req.GetDate())
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to parse date from proto")
+		return nil, status.Error(codes.InvalidArgument, "invalid date")
 	}
 	v := activity{
 		UserID:      req.GetUserID(),
@@ -224,6 +265,7 @@ func (c *service) PostActivity(ctx context.Context, req *pb.PostActivityRequest)
 		RoasterName: roaster.GetName(),
 		Notes:       req.GetNotes(),
 		PictureURL:  picURL,
+		Visibility:  int32(req.GetVisibility()),
 	}
 
 	cs := span.NewChild("datastore/activity/put")
@@ -235,10 +277,32 @@ func (c *service) PostActivity(ctx context.Context, req *pb.PostActivityRequest)
 
 	span.SetLabel("activity/id", fmt.Sprint(k.ID))
 	log.WithField("id", k.ID).Info("activity saved to datastore")
+	activitiesPosted.Inc()
 	return &pb.PostActivityResponse{ID: k.ID}, nil
 }
 
+func (c *service) UploadPicture(ctx context.Context, req *pb.PostActivityRequest_File) (*pb.UploadPictureResponse, error) {
+	span := trace.FromContext(ctx).NewChild("coffeesvc/UploadPicture")
+	defer span.Finish()
+
+	if caller.FromContext(ctx) == "" {
+		return nil, status.Error(codes.Unauthenticated, "pictures can only be uploaded by users")
+	}
+
+	if len(req.GetData()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "picture is empty")
+	} else if !strings.HasPrefix(req.GetContentType(), "image/") {
+		return nil, status.Error(codes.InvalidArgument, "uploaded file is not a picture")
+	}
+	url, err := uploadPicture(trace.NewContext(ctx, span), *gcsBucket, req.GetFilename(), req.GetContentType(), req.GetData())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to upload picture")
+	}
+	return &pb.UploadPictureResponse{URL: url}, nil
+}
+
 func uploadPicture(ctx context.Context, bucket, filename, contentType string, b []byte) (string, error) {
+	log := logging.FromContext(ctx)
 	span := trace.FromContext(ctx).NewChild("gcs/upload")
 	defer span.Finish()
 
@@ -259,29 +323,46 @@ func uploadPicture(ctx context.Context, bucket, filename, contentType string, b
 	log.WithFields(logrus.Fields{
 		"bucket": bucket,
 		"object": fn}).Debug("uploaded file")
-	url := fmt.Sprintf("https://%s.storage.googleapis.com/%s", bucket, fn)
-	return url, errors.Wrap(w.Close(), "failed to close object writer")
+	if err := w.Close(); err != nil {
+		return "", errors.Wrap(err, "failed to close object writer")
+	}
+	pictureUploadBytes.Add(float64(len(b)))
+	return fmt.Sprintf("https://%s.storage.googleapis.com/%s", bucket, fn), nil
 }
 
 func (c *service) GetActivity(ctx context.Context, req *pb.ActivityRequest) (*pb.Activity, error) {
+	log := logging.FromContext(ctx)
 	span := trace.FromContext(ctx).NewChild("coffeesvc/GetActivity")
 	defer span.Finish()
 
+	if err := checkViewer(ctx, req.GetViewerID()); err != nil {
+		return nil, err
+	}
+
+	errNotFound := status.Error(codes.NotFound, "activity not found")
 	var v activity
 	if err := c.ds.Get(ctx, datastore.IDKey(kindActivity, req.GetID(), nil), &v); err == datastore.ErrNoSuchEntity {
-		return nil, errors.New("activity not found")
+		return nil, errNotFound
 	} else if err != nil {
 		return nil, errors.Wrap(err, "error querying datastore for activity")
 	}
 
 	cs := span.NewChild("rpc.sent/GetUser")
-	defer cs.Finish()
 	user, err := c.userSvc.GetUser(trace.NewContext(ctx, cs), &pb.UserRequest{ID: v.UserID})
+	cs.Finish()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to retrieve activity owner")
 	}
 	if !user.GetFound() {
-		return nil, errors.Wrap(err, "activity owner does not exist")
+		return nil, errNotFound
+	}
+
+	acc := &access{userSvc: c.userSvc, viewerID: req.GetViewerID(), owner: user.GetUser()}
+	if ok, err := acc.allowed(trace.NewContext(ctx, span), pb.Visibility(v.Visibility)); err != nil {
+		return nil, err
+	} else if !ok {
+		log.WithField("id", req.GetID()).Debug("activity not visible to the viewer")
+		return nil, errNotFound
 	}
 
 	activity, err := v.ToProto(user.GetUser())
@@ -289,36 +370,60 @@ func (c *service) GetActivity(ctx context.Context, req *pb.ActivityRequest) (*pb
 }
 
 func (c *service) GetUserActivities(ctx context.Context, req *pb.UserActivitiesRequest) (*pb.UserActivitiesResponse, error) {
+	log := logging.FromContext(ctx)
 	span := trace.FromContext(ctx).NewChild("coffeesvc/GetActivities")
 	defer span.Finish()
 
+	if err := checkViewer(ctx, req.GetViewerID()); err != nil {
+		return nil, err
+	}
+
 	span.SetLabel("user/id", req.GetUserID())
 	log.WithField("user.id", req.GetUserID()).Debug("querying datastore for activities")
 
+	errNotFound := status.Error(codes.NotFound, "user not found")
 	cs := span.NewChild("rpc.sent/GetUser")
 	user, err := c.userSvc.GetUser(trace.NewContext(ctx, cs), &pb.UserRequest{ID: req.GetUserID()})
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to retrieve user profile")
 	}
 	if !user.GetFound() {
-		return nil, errors.Wrap(err, "user does not exist")
+		return nil, errNotFound
 	}
 	cs.Finish()
 
-	cs = span.NewChild("datastore/activity/list")
-	var v []activity
-	if _, err := c.ds.GetAll(ctx, datastore.NewQuery(kindActivity).Filter("UserID =", req.GetUserID()).Order("-Date"), &v); err != nil {
-		return nil, errors.Wrap(err, "failed to query datastore for user activities")
+	// the profile is visible if the activities without their own visibility
+	// are
+	acc := &access{userSvc: c.userSvc, viewerID: req.GetViewerID(), owner: user.GetUser()}
+	if ok, err := acc.allowed(trace.NewContext(ctx, span), pb.Visibility_DEFAULT); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, errNotFound
 	}
-	cs.Finish()
 
-	var res []*pb.Activity
-	for _, a := range v {
-		aa, err := a.ToProto(user.GetUser())
-		if err != nil {
-			return nil, errors.Wrap(err, "proto conversion failed on one of the activities")
-		}
-		res = append(res, aa)
+	cs = span.NewChild("datastore/activity/list")
+	defer cs.Finish()
+	resp := new(pb.UserActivitiesResponse)
+	var pa *pb.Activity
+	q := datastore.NewQuery(kindActivity).Filter("UserID =", req.GetUserID()).Order("-Date")
+	next, err := queryPage(ctx, c.ds, q, req.GetPageSize(), req.GetPageToken(),
+		func(it *datastore.Iterator) (bool, error) {
+			var a activity
+			if _, err := it.Next(&a); err != nil {
+				return false, err
+			}
+			if ok, err := acc.allowed(ctx, pb.Visibility(a.Visibility)); err != nil || !ok {
+				return false, err
+			}
+			if pa, err = a.ToProto(user.GetUser()); err != nil {
+				return false, errors.Wrap(err, "proto conversion failed on one of the activities")
+			}
+			return true, nil
+		},
+		func() { resp.Activities = append(resp.Activities, pa) })
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query datastore for user activities")
 	}
-	return &pb.UserActivitiesResponse{Activities: res}, nil
+	resp.NextPageToken = next
+	return resp, nil
 }
@@ -0,0 +1,142 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command gateway serves the backend gRPC services as HTTP/JSON APIs by
+// transcoding requests according to the google.api.http annotations in
+// coffeelog.proto.
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"strings"
+	"time"
+
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/interceptor"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/metrics"
+	"github.com/ahmetb/coffeelog/mtls"
+	"github.com/ahmetb/coffeelog/shutdown"
+	"github.com/ahmetb/coffeelog/trace"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/grpclog"
+)
+
+var (
+	addr                   = flag.String("addr", ":8003", "[host]:port to listen")
+	coffeeDirectoryBackend = flag.String("coffee-directory-addr", "", "address of coffee directory backend")
+
+	tlsCert = flag.String("tls-cert", "", "path to the tls certificate of the service, for mutual tls with the other services")
+	tlsKey  = flag.String("tls-key", "", "path to the private key of the tls certificate")
+	tlsCA   = flag.String("tls-ca", "", "path to the ca certificates to verify the other services with")
+
+	shutdownDelay     = flag.Duration("shutdown-delay", 0, "how long to fail the readiness checks for on SIGTERM before stopping")
+	shutdownTimeout   = flag.Duration("shutdown-timeout", 20*time.Second, "how long to wait for the requests in flight to finish on SIGTERM")
+	metricsAddr       = flag.String("metrics-addr", "", "[host]:port to serve prometheus metrics on at /metrics (default: disabled)")
+	logLevel          = flag.String("log-level", "info", "least severe level to log: debug, info, warn or error")
+	logFormat         = flag.String("log-format", "json", "format of the logs: json or text")
+	traceExporter     = flag.String("trace-exporter", "none", "where to send the traces: otlp, stdout or none")
+	traceEndpoint     = flag.String("trace-otlp-endpoint", "", "base url of the otlp/http receiver for -trace-exporter=otlp, such as http://otel-collector:4318")
+	traceSampleRatio  = flag.Float64("trace-sample-ratio", 1.0, "fraction of the new traces to sample")
+	traceMaxPerSecond = flag.Float64("trace-max-per-second", 10, "most new traces to sample per second, 0 for no limit")
+
+	log *logrus.Entry
+)
+
+func main() {
+	flag.Parse()
+	var err error
+	log, err = logging.New("gateway", *logLevel, *logFormat)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	grpclog.SetLogger(log.WithField("facility", "grpc"))
+
+	if *coffeeDirectoryBackend == "" {
+		log.Fatal("coffee directory address flag not specified")
+	}
+
+	tc, err := trace.NewClient(trace.Config{
+		Service:      "gateway",
+		Exporter:     *traceExporter,
+		Endpoint:     *traceEndpoint,
+		SampleRatio:  *traceSampleRatio,
+		MaxPerSecond: *traceMaxPerSecond}, log)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "failed to initialize tracing client"))
+	}
+	tr, err := mtls.Load(mtls.Config{CertFile: *tlsCert, KeyFile: *tlsKey, CAFile: *tlsCA}, log)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "failed to load tls files"))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gw := runtime.NewServeMux(runtime.WithMarshalerOption(runtime.MIMEWildcard,
+		&runtime.JSONPb{OrigName: true, EmitDefaults: true}))
+	opts := []grpc.DialOption{tr.DialOption(), grpc.WithUnaryInterceptor(interceptor.ChainUnaryClient(
+		tc.GRPCClientInterceptor(),
+		metrics.UnaryClientInterceptor()))}
+	if err := pb.RegisterRoasterDirectoryHandlerFromEndpoint(ctx, gw, *coffeeDirectoryBackend, opts); err != nil {
+		log.Fatal(errors.Wrap(err, "cannot register roaster directory handlers"))
+	}
+	if err := pb.RegisterActivityDirectoryHandlerFromEndpoint(ctx, gw, *coffeeDirectoryBackend, opts); err != nil {
+		log.Fatal(errors.Wrap(err, "cannot register activity directory handlers"))
+	}
+
+	srv := &http.Server{
+		Addr:    *addr,
+		Handler: metrics.InstrumentHTTP(tc.HTTPHandler(logging.HTTPHandler(log, metadataHandler(gw))), nil)}
+	log.WithFields(logrus.Fields{"addr": *addr,
+		"coffeedirectory": *coffeeDirectoryBackend}).Info("starting to listen on http")
+	if *metricsAddr != "" {
+		go func() {
+			log.WithField("addr", *metricsAddr).Info("serving metrics")
+			log.Fatal(errors.Wrap(metrics.ListenAndServe(*metricsAddr), "failed to serve metrics"))
+		}()
+	}
+	sd := shutdown.Watch(log, *shutdownDelay, *shutdownTimeout)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !sd.Draining() {
+			log.Fatal(errors.Wrap(err, "failed to listen/serve"))
+		}
+	}()
+
+	stopCtx, stopCancel := sd.Wait()
+	defer stopCancel()
+	sd.StopHTTP(stopCtx, srv)
+	sd.FlushTraces(tc.Shutdown)
+}
+
+// metadataHandler passes the trace of the request on to the backends in the
+// metadata grpc-gateway makes from the Grpc-Metadata- headers, as the RPCs
+// are not made with the context of the request. The metadata headers of the
+// client are dropped, so that they cannot pose as the gateway.
+func metadataHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k := range r.Header {
+			if strings.HasPrefix(k, runtime.MetadataHeaderPrefix) {
+				r.Header.Del(k)
+			}
+		}
+		r.Header.Set(runtime.MetadataHeaderPrefix+"Traceparent", trace.FromContext(r.Context()).Traceparent())
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,378 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/ahmetb/coffeelog/caller"
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/trace"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	kindDeletion = "Deletion" // datastore kind
+
+	// deletionBatchSize is the number of entities deleted at once.
+	deletionBatchSize = 500
+)
+
+// deletion is a background job deleting the data of a user. It runs the
+// deletionSteps in order and saves the step it is at, so that it resumes
+// there when interrupted. Steps are safe to run more than once, and
+// therefore more than one replica picking up the job at the same time.
+type deletion struct {
+	K         *datastore.Key `datastore:"__key__"`
+	UserID    string         `datastore:"UserID"`
+	Done      bool           `datastore:"Done"`
+	Step      string         `datastore:"Step,noindex"`
+	Error     string         `datastore:"Error,noindex"`
+	Picture   string         `datastore:"Picture,noindex"`
+	Requested time.Time      `datastore:"Requested,noindex"`
+	Updated   time.Time      `datastore:"Updated,noindex"`
+}
+
+func (d *deletion) ToProto() (*pb.Deletion, error) {
+	var ts [2]*timestamp.Timestamp
+	for i, t := range []time.Time{d.Requested, d.Updated} {
+		v, err := ptypes.TimestampProto(t)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to convert timestamp")
+		}
+		ts[i] = v
+	}
+	return &pb.Deletion{
+		ID:        fmt.Sprintf("%d", d.K.ID),
+		Done:      d.Done,
+		Step:      d.Step,
+		Retrying:  d.Error != "",
+		Requested: ts[0],
+		Updated:   ts[1]}, nil
+}
+
+// deletionStep deletes a batch of the data of the user, returning true when
+// there is no more left.
+type deletionStep func(u *userDirectory, ctx context.Context, d *deletion) (bool, error)
+
+var deletionSteps = []struct {
+	name string
+	run  deletionStep
+}{
+	{"sessions", deleteByUserID(kindSession)},
+	{"tokens", deleteByUserID(kindToken)},
+	{"identities", deleteByUserID(kindIdentity)},
+	{"account tokens", deleteByUserID(kindAccountToken)},
+	{"login attempts", deleteLoginAttempts},
+	{"credentials", deleteByUserID(kindCredential)},
+	{"follows", deleteFollows},
+	{"username", deleteUsername},
+	{"activities", deleteActivities},
+	{"account", deleteAccount},
+}
+
+func (u *userDirectory) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.Deletion, error) {
+	span := trace.FromContext(ctx).NewChild("usersvc/DeleteUser")
+	defer span.Finish()
+
+	if err := caller.Check(ctx, req.GetUserID()); err != nil {
+		return nil, err
+	}
+
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"op":      "DeleteUser",
+		"user.id": req.GetUserID()})
+	log.Debug("received request")
+
+	id, err := strconv.ParseInt(req.GetUserID(), 10, 64)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "cannot parse ID")
+	}
+
+	// allocate the key of the job that may be created in the transaction
+	keys, err := u.ds.AllocateIDs(ctx, []*datastore.Key{datastore.IncompleteKey(kindDeletion, nil)})
+	if err != nil {
+		log.WithField("error", err).Error("failed to allocate deletion id")
+		return nil, errors.Wrap(err, "failed to allocate deletion id")
+	}
+
+	// the account is hidden in the same transaction the job is created, so
+	// that it is no longer found, and its sessions and tokens no longer work,
+	// before any of its data is deleted
+	var d deletion
+	k := datastore.IDKey("Account", id, nil)
+	cs := span.NewChild("datastore/tx/delete_user")
+	_, err = u.ds.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var v account
+		if err := tx.Get(k, &v); err != nil {
+			return err
+		} else if v.Deleting {
+			return errAlreadyDeleting
+		}
+		v.Deleting = true
+		if _, err := tx.Put(k, &v); err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		d = deletion{
+			K:         keys[0],
+			UserID:    req.GetUserID(),
+			Step:      deletionSteps[0].name,
+			Picture:   v.Picture,
+			Requested: now,
+			Updated:   now}
+		_, err := tx.Put(d.K, &d)
+		return err
+	})
+	cs.Finish()
+	if err == errAlreadyDeleting {
+		return nil, err
+	} else if err == datastore.ErrNoSuchEntity {
+		return nil, status.Error(codes.NotFound, "user not found")
+	} else if err != nil {
+		log.WithField("error", err).Error("failed to start deletion")
+		return nil, errors.Wrap(err, "failed to save")
+	}
+	log.WithField("deletion.id", d.K.ID).Info("started account deletion")
+
+	// start on the job right away instead of the next tick
+	select {
+	case u.deletions <- struct{}{}:
+	default:
+	}
+	return d.ToProto()
+}
+
+var errAlreadyDeleting = status.Error(codes.FailedPrecondition, "account is already being deleted")
+
+func (u *userDirectory) GetDeletion(ctx context.Context, req *pb.GetDeletionRequest) (*pb.DeletionResponse, error) {
+	log := logging.FromContext(ctx)
+	span := trace.FromContext(ctx).NewChild("usersvc/GetDeletion")
+	defer span.Finish()
+
+	id, err := strconv.ParseInt(req.GetID(), 10, 64)
+	if err != nil {
+		return &pb.DeletionResponse{Found: false}, nil
+	}
+	var v deletion
+	if err := u.ds.Get(ctx, datastore.IDKey(kindDeletion, id, nil), &v); err == datastore.ErrNoSuchEntity {
+		return &pb.DeletionResponse{Found: false}, nil
+	} else if err != nil {
+		log.WithField("error", err).Error("failed to query the datastore")
+		return nil, errors.Wrap(err, "failed to query")
+	}
+	dp, err := v.ToProto()
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DeletionResponse{Found: true, Deletion: dp}, nil
+}
+
+// runDeletions works on the unfinished deletion jobs every interval, or when
+// a new job is started, until ctx is cancelled.
+func (u *userDirectory) runDeletions(ctx context.Context, interval time.Duration) {
+	log := logging.FromContext(ctx)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		var v []deletion
+		if _, err := u.ds.GetAll(ctx, datastore.NewQuery(kindDeletion).Filter("Done =", false), &v); err != nil {
+			log.WithField("error", err).Error("failed to query deletion jobs")
+		}
+		for i := range v {
+			u.runDeletion(ctx, &v[i])
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		case <-u.deletions:
+		}
+	}
+}
+
+// runDeletion runs the job from the step it is at. An error is saved to the
+// job, and the step is retried the next time.
+func (u *userDirectory) runDeletion(ctx context.Context, d *deletion) {
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"deletion.id": d.K.ID,
+		"user.id":     d.UserID})
+	i := 0
+	for i < len(deletionSteps) && deletionSteps[i].name != d.Step {
+		i++
+	}
+	if i == len(deletionSteps) {
+		log.WithField("step", d.Step).Error("unknown deletion step")
+		return
+	}
+	for ; i < len(deletionSteps); i++ {
+		s := deletionSteps[i]
+		for done := false; !done; {
+			var err error
+			done, err = s.run(u, ctx, d)
+			if err != nil {
+				log.WithFields(logrus.Fields{
+					"step":  s.name,
+					"error": err}).Error("deletion step failed")
+				d.Error = fmt.Sprintf("%s: %v", s.name, err)
+				u.saveDeletion(ctx, d)
+				return
+			}
+		}
+		log.WithField("step", s.name).Debug("deletion step completed")
+		d.Error = ""
+		if i+1 < len(deletionSteps) {
+			d.Step = deletionSteps[i+1].name
+		} else {
+			d.Step, d.Done = "", true
+		}
+		if err := u.saveDeletion(ctx, d); err != nil {
+			return
+		}
+	}
+	log.Info("deleted account")
+}
+
+func (u *userDirectory) saveDeletion(ctx context.Context, d *deletion) error {
+	log := logging.FromContext(ctx)
+	d.Updated = time.Now().UTC()
+	if _, err := u.ds.Put(ctx, d.K, d); err != nil {
+		log.WithFields(logrus.Fields{
+			"deletion.id": d.K.ID,
+			"error":       err}).Error("failed to save deletion job")
+		return err
+	}
+	return nil
+}
+
+// deleteByUserID returns a step deleting the entities of the kind that have
+// the UserID of the deleted user.
+func deleteByUserID(kind string) deletionStep {
+	return func(u *userDirectory, ctx context.Context, d *deletion) (bool, error) {
+		return u.deleteQuery(ctx, datastore.NewQuery(kind).Filter("UserID =", d.UserID))
+	}
+}
+
+// deleteQuery deletes a batch of the entities matching q, returning true if
+// it was the last one.
+func (u *userDirectory) deleteQuery(ctx context.Context, q *datastore.Query) (bool, error) {
+	keys, err := u.ds.GetAll(ctx, q.KeysOnly().Limit(deletionBatchSize), nil)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to query")
+	}
+	if err := u.ds.DeleteMulti(ctx, keys); err != nil {
+		return false, errors.Wrap(err, "failed to delete")
+	}
+	return len(keys) < deletionBatchSize, nil
+}
+
+// deleteLoginAttempts deletes the failed logins counted for the email
+// addresses of the user, which are the key names of its credentials, so it
+// runs before the credentials are deleted.
+func deleteLoginAttempts(u *userDirectory, ctx context.Context, d *deletion) (bool, error) {
+	keys, err := u.ds.GetAll(ctx, datastore.NewQuery(kindCredential).
+		Filter("UserID =", d.UserID).KeysOnly(), nil)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to query")
+	}
+	var del []*datastore.Key
+	for _, k := range keys {
+		del = append(del, datastore.NameKey(kindLoginAttempts, "email|"+k.Name, nil))
+	}
+	if err := u.ds.DeleteMulti(ctx, del); err != nil {
+		return false, errors.Wrap(err, "failed to delete")
+	}
+	return true, nil
+}
+
+// deleteFollows deletes both the follows of the user and its followers.
+func deleteFollows(u *userDirectory, ctx context.Context, d *deletion) (bool, error) {
+	done, err := u.deleteQuery(ctx, datastore.NewQuery(kindFollow).Filter("FollowerID =", d.UserID))
+	if err != nil || !done {
+		return done, err
+	}
+	return u.deleteQuery(ctx, datastore.NewQuery(kindFollow).Filter("UserID =", d.UserID))
+}
+
+// deleteUsername releases the username of the user for others to take.
+func deleteUsername(u *userDirectory, ctx context.Context, d *deletion) (bool, error) {
+	id, err := strconv.ParseInt(d.UserID, 10, 64)
+	if err != nil {
+		return false, errors.New("cannot parse ID")
+	}
+	_, err = u.ds.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var v account
+		if err := tx.Get(datastore.IDKey("Account", id, nil), &v); err == datastore.ErrNoSuchEntity {
+			return nil
+		} else if err != nil {
+			return err
+		} else if v.Username == "" {
+			return nil
+		}
+		var n username
+		if err := tx.Get(usernameKey(v.Username), &n); err == datastore.ErrNoSuchEntity {
+			return nil
+		} else if err != nil {
+			return err
+		} else if n.UserID != d.UserID {
+			return nil
+		}
+		return tx.Delete(usernameKey(v.Username))
+	})
+	return err == nil, err
+}
+
+// deleteActivities deletes the activities and pictures of the user in the
+// coffee directory, including the avatar if it was uploaded there.
+func deleteActivities(u *userDirectory, ctx context.Context, d *deletion) (bool, error) {
+	log := logging.FromContext(ctx)
+	var pics []string
+	if d.Picture != "" {
+		pics = append(pics, d.Picture)
+	}
+	// the job acts on behalf of the user, who asked for the deletion
+	resp, err := u.coffee.PurgeUser(caller.NewContext(ctx, d.UserID), &pb.PurgeUserRequest{
+		UserID:      d.UserID,
+		PictureURLs: pics})
+	if err != nil {
+		return false, errors.Wrap(err, "failed to purge activities")
+	}
+	log.WithFields(logrus.Fields{
+		"user.id": d.UserID,
+		"count":   resp.GetDeleted()}).Debug("purged activities")
+	return resp.GetDone(), nil
+}
+
+// deleteAccount deletes the account itself.
+func deleteAccount(u *userDirectory, ctx context.Context, d *deletion) (bool, error) {
+	id, err := strconv.ParseInt(d.UserID, 10, 64)
+	if err != nil {
+		return false, errors.New("cannot parse ID")
+	}
+	if err := u.ds.Delete(ctx, datastore.IDKey("Account", id, nil)); err != nil {
+		return false, errors.Wrap(err, "failed to delete")
+	}
+	return true, nil
+}
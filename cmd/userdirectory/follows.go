@@ -0,0 +1,229 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"sort"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/ahmetb/coffeelog/caller"
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/trace"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const kindFollow = "Follow" // datastore kind
+
+// follow is a user following another. The key name is
+// "<follower id>|<user id>", so a user can follow another only once.
+type follow struct {
+	K          *datastore.Key `datastore:"__key__"`
+	UserID     string         `datastore:"UserID"`
+	FollowerID string         `datastore:"FollowerID"`
+	Pending    bool           `datastore:"Pending,noindex"`
+	Created    time.Time      `datastore:"Created,noindex"`
+}
+
+func followKey(followerID, userID string) *datastore.Key {
+	return datastore.NameKey(kindFollow, followerID+"|"+userID, nil)
+}
+
+func (f *follow) response() *pb.FollowResponse {
+	if f == nil {
+		return &pb.FollowResponse{}
+	}
+	return &pb.FollowResponse{Following: true, Pending: f.Pending}
+}
+
+func (u *userDirectory) getFollow(ctx context.Context, req *pb.FollowRequest) (*follow, error) {
+	log := logging.FromContext(ctx)
+	var v follow
+	if err := u.ds.Get(ctx, followKey(req.GetFollowerID(), req.GetUserID()), &v); err == datastore.ErrNoSuchEntity {
+		return nil, nil
+	} else if err != nil {
+		log.WithField("error", err).Error("failed to query the datastore")
+		return nil, errors.Wrap(err, "failed to query")
+	}
+	return &v, nil
+}
+
+func (u *userDirectory) GetFollow(ctx context.Context, req *pb.FollowRequest) (*pb.FollowResponse, error) {
+	span := trace.FromContext(ctx).NewChild("usersvc/GetFollow")
+	defer span.Finish()
+
+	f, err := u.getFollow(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return f.response(), nil
+}
+
+func (u *userDirectory) Follow(ctx context.Context, req *pb.FollowRequest) (*pb.FollowResponse, error) {
+	span := trace.FromContext(ctx).NewChild("usersvc/Follow")
+	defer span.Finish()
+
+	if err := caller.Check(ctx, req.GetFollowerID()); err != nil {
+		return nil, err
+	}
+
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"op":          "Follow",
+		"user.id":     req.GetUserID(),
+		"follower.id": req.GetFollowerID()})
+
+	if req.GetUserID() == req.GetFollowerID() {
+		return nil, status.Error(codes.InvalidArgument, "users cannot follow themselves")
+	}
+	user, err := u.GetUser(ctx, &pb.UserRequest{ID: req.GetUserID()})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to look up the user")
+	} else if !user.GetFound() {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if follower, err := u.GetUser(ctx, &pb.UserRequest{ID: req.GetFollowerID()}); err != nil {
+		return nil, errors.Wrap(err, "failed to look up the follower")
+	} else if !follower.GetFound() {
+		return nil, status.Error(codes.NotFound, "follower not found")
+	}
+
+	var v follow
+	k := followKey(req.GetFollowerID(), req.GetUserID())
+	cs := span.NewChild("datastore/tx/follow")
+	_, err = u.ds.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		if err := tx.Get(k, &v); err == nil {
+			return nil // already following or requested
+		} else if err != datastore.ErrNoSuchEntity {
+			return err
+		}
+		vis := user.GetUser().GetVisibility()
+		v = follow{
+			UserID:     req.GetUserID(),
+			FollowerID: req.GetFollowerID(),
+			Pending:    vis != pb.Visibility_DEFAULT && vis != pb.Visibility_PUBLIC,
+			Created:    time.Now()}
+		_, err := tx.Put(k, &v)
+		return err
+	})
+	cs.Finish()
+	if err != nil {
+		log.WithField("error", err).Error("failed to follow")
+		return nil, errors.Wrap(err, "failed to save")
+	}
+	log.WithField("pending", v.Pending).Info("followed user")
+	return v.response(), nil
+}
+
+// Unfollow removes the follow or follow request, by either of the users.
+func (u *userDirectory) Unfollow(ctx context.Context, req *pb.FollowRequest) (*pb.FollowResponse, error) {
+	log := logging.FromContext(ctx)
+	span := trace.FromContext(ctx).NewChild("usersvc/Unfollow")
+	defer span.Finish()
+
+	// either of the users can end the follow
+	if err := caller.Check(ctx, req.GetUserID()); err != nil {
+		if err := caller.Check(ctx, req.GetFollowerID()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := u.ds.Delete(ctx, followKey(req.GetFollowerID(), req.GetUserID())); err != nil {
+		log.WithField("error", err).Error("failed to unfollow")
+		return nil, errors.Wrap(err, "failed to delete")
+	}
+	log.WithFields(logrus.Fields{
+		"user.id":     req.GetUserID(),
+		"follower.id": req.GetFollowerID()}).Info("unfollowed user")
+	return &pb.FollowResponse{}, nil
+}
+
+func (u *userDirectory) ApproveFollower(ctx context.Context, req *pb.FollowRequest) (*pb.FollowResponse, error) {
+	log := logging.FromContext(ctx)
+	span := trace.FromContext(ctx).NewChild("usersvc/ApproveFollower")
+	defer span.Finish()
+
+	if err := caller.Check(ctx, req.GetUserID()); err != nil {
+		return nil, err
+	}
+
+	var v follow
+	k := followKey(req.GetFollowerID(), req.GetUserID())
+	_, err := u.ds.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		if err := tx.Get(k, &v); err != nil {
+			return err
+		}
+		if !v.Pending {
+			return nil
+		}
+		v.Pending = false
+		_, err := tx.Put(k, &v)
+		return err
+	})
+	if err == datastore.ErrNoSuchEntity {
+		return &pb.FollowResponse{}, nil
+	} else if err != nil {
+		log.WithField("error", err).Error("failed to approve follower")
+		return nil, errors.Wrap(err, "failed to save")
+	}
+	log.WithFields(logrus.Fields{
+		"user.id":     req.GetUserID(),
+		"follower.id": req.GetFollowerID()}).Info("approved follower")
+	return v.response(), nil
+}
+
+func (u *userDirectory) ListFollowers(ctx context.Context, req *pb.ListFollowersRequest) (*pb.ListFollowersResponse, error) {
+	log := logging.FromContext(ctx)
+	span := trace.FromContext(ctx).NewChild("usersvc/ListFollowers")
+	defer span.Finish()
+
+	if err := caller.Check(ctx, req.GetUserID()); err != nil {
+		return nil, err
+	}
+
+	cs := span.NewChild("datastore/query/follow/by_user")
+	var v []follow
+	_, err := u.ds.GetAll(ctx, datastore.NewQuery(kindFollow).Filter("UserID =", req.GetUserID()), &v)
+	cs.Finish()
+	if err != nil {
+		log.WithField("error", err).Error("failed to query the datastore")
+		return nil, errors.Wrap(err, "failed to query")
+	}
+	sort.Slice(v, func(i, j int) bool { return v[i].Created.After(v[j].Created) })
+
+	resp := new(pb.ListFollowersResponse)
+	for _, f := range v {
+		user, err := u.GetUser(ctx, &pb.UserRequest{ID: f.FollowerID})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to look up the follower")
+		} else if !user.GetFound() {
+			continue
+		}
+		ts, err := ptypes.TimestampProto(f.Created)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to convert timestamp")
+		}
+		resp.Followers = append(resp.Followers, &pb.Follower{
+			User:    user.GetUser(),
+			Pending: f.Pending,
+			Since:   ts})
+	}
+	return resp, nil
+}
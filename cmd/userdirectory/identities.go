@@ -0,0 +1,338 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/ahmetb/coffeelog/caller"
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/trace"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	kindIdentity = "Identity" // datastore kind
+
+	providerGoogle = "google"
+)
+
+var providerPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// identity links an account at an external identity provider to an account.
+// The key name is "<provider>|<subject>", so an external account can only be
+// linked to one account.
+type identity struct {
+	K        *datastore.Key `datastore:"__key__"`
+	UserID   string         `datastore:"UserID"`
+	Provider string         `datastore:"Provider,noindex"`
+	Subject  string         `datastore:"Subject,noindex"`
+	Email    string         `datastore:"Email,noindex"`
+	Linked   time.Time      `datastore:"Linked,noindex"`
+}
+
+func identityKey(provider, subject string) *datastore.Key {
+	return datastore.NameKey(kindIdentity, provider+"|"+subject, nil)
+}
+
+func (i *identity) ToProto() (*pb.Identity, error) {
+	ts, err := ptypes.TimestampProto(i.Linked)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert timestamp")
+	}
+	return &pb.Identity{
+		Provider: i.Provider,
+		Subject:  i.Subject,
+		Email:    i.Email,
+		Linked:   ts}, nil
+}
+
+func (u *userDirectory) AuthorizeExternal(ctx context.Context, req *pb.AuthorizeExternalRequest) (*pb.User, error) {
+	span := trace.FromContext(ctx).NewChild("usersvc/AuthorizeExternal")
+	defer span.Finish()
+
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"op":       "AuthorizeExternal",
+		"provider": req.GetProvider(),
+		"subject":  req.GetSubject()})
+	log.Debug("received request")
+
+	if !providerPattern.MatchString(req.GetProvider()) {
+		return nil, status.Error(codes.InvalidArgument, "invalid provider name")
+	} else if req.GetSubject() == "" {
+		return nil, status.Error(codes.InvalidArgument, "subject is required")
+	}
+
+	if req.GetLinkToUserID() != "" {
+		if err := caller.Check(ctx, req.GetLinkToUserID()); err != nil {
+			return nil, err
+		}
+		if user, err := u.GetUser(ctx, &pb.UserRequest{ID: req.GetLinkToUserID()}); err != nil {
+			return nil, errors.Wrap(err, "failed to look up the user")
+		} else if !user.GetFound() {
+			return nil, status.Error(codes.NotFound, "user to link to not found")
+		}
+	}
+
+	// accounts created before identities were introduced only have the
+	// GoogleID property, migrate them on their first login. The account is
+	// read again in the transaction, this only finds its key.
+	var legacy *account
+	if req.GetProvider() == providerGoogle && req.GetLinkToUserID() == "" {
+		cs := span.NewChild("datastore/query/account/by_googleid")
+		var v []account
+		_, err := u.ds.GetAll(ctx, datastore.NewQuery("Account").Filter("GoogleID =", req.GetSubject()).Limit(1), &v)
+		cs.Finish()
+		if err != nil {
+			log.WithField("error", err).Error("failed to query the datastore")
+			return nil, errors.Wrap(err, "failed to query")
+		}
+		if len(v) > 0 {
+			legacy = &v[0]
+		}
+	}
+
+	// allocate the key of the account that may be created in the transaction
+	cs := span.NewChild("datastore/allocate/account")
+	keys, err := u.ds.AllocateIDs(ctx, []*datastore.Key{datastore.IncompleteKey("Account", nil)})
+	cs.Finish()
+	if err != nil {
+		log.WithField("error", err).Error("failed to allocate account id")
+		return nil, errors.Wrap(err, "failed to allocate account id")
+	}
+
+	var (
+		userID  string
+		created bool
+		p       = req.GetProfile()
+		email   = p.GetEmail()
+	)
+	if !p.GetEmailVerified() {
+		email = ""
+	}
+	cs = span.NewChild("datastore/tx/authorize_external")
+	_, err = u.ds.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		created = false
+		k := identityKey(req.GetProvider(), req.GetSubject())
+		var v identity
+		if err := tx.Get(k, &v); err == nil {
+			if req.GetLinkToUserID() != "" && v.UserID != req.GetLinkToUserID() {
+				return status.Error(codes.AlreadyExists, "identity is already linked to another account")
+			}
+			userID = v.UserID
+			return nil
+		} else if err != datastore.ErrNoSuchEntity {
+			return err
+		}
+
+		var legacyAcc *account
+		if legacy != nil {
+			var a account
+			if err := tx.Get(legacy.K, &a); err == nil && a.GoogleID == req.GetSubject() {
+				legacyAcc = &a
+			} else if err != nil && err != datastore.ErrNoSuchEntity {
+				return err
+			}
+		}
+
+		switch {
+		case req.GetLinkToUserID() != "":
+			userID = req.GetLinkToUserID()
+		case legacyAcc != nil:
+			userID = fmt.Sprintf("%d", legacy.K.ID)
+			legacyAcc.GoogleID = ""
+			if _, err := tx.Put(legacy.K, legacyAcc); err != nil {
+				return err
+			}
+		default:
+			userID = fmt.Sprintf("%d", keys[0].ID)
+			if _, err := tx.Put(keys[0], &account{
+				Email:       email,
+				DisplayName: p.GetDisplayName(),
+				Picture:     p.GetPictureURL(),
+			}); err != nil {
+				return err
+			}
+			created = true
+		}
+		_, err := tx.Put(k, &identity{
+			UserID:   userID,
+			Provider: req.GetProvider(),
+			Subject:  req.GetSubject(),
+			Email:    email,
+			Linked:   time.Now()})
+		return err
+	})
+	cs.Finish()
+	if err != nil {
+		log.WithField("error", err).Error("failed to authorize external identity")
+		return nil, errors.Wrap(err, "failed to save")
+	}
+	log = log.WithField("id", userID)
+	if created {
+		log.Info("created new user account")
+	} else if req.GetLinkToUserID() != "" {
+		log.Info("linked identity")
+	} else {
+		log.Debug("user exists")
+		cs = span.NewChild("datastore/tx/sync_profile")
+		err := u.syncProfile(ctx, userID, p)
+		cs.Finish()
+		if err != nil {
+			log.WithField("error", err).Error("failed to sync profile")
+			return nil, err
+		}
+	}
+
+	// retrieve user again from backend
+	user, err := u.GetUser(ctx, &pb.UserRequest{ID: userID})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve user")
+	} else if !user.GetFound() {
+		return nil, errors.New("cannot find user that is just created")
+	}
+	return user.GetUser(), nil
+}
+
+// userIdentities returns the identities linked to the user, including the
+// legacy Google ID of an account that has not logged in since identities
+// were introduced.
+func (u *userDirectory) userIdentities(ctx context.Context, userID string) ([]identity, error) {
+	log := logging.FromContext(ctx)
+	var v []identity
+	if _, err := u.ds.GetAll(ctx, datastore.NewQuery(kindIdentity).Filter("UserID =", userID), &v); err != nil {
+		log.WithField("error", err).Error("failed to query the datastore")
+		return nil, errors.Wrap(err, "failed to query")
+	}
+	if id, err := strconv.ParseInt(userID, 10, 64); err == nil {
+		var a account
+		if err := u.ds.Get(ctx, datastore.IDKey("Account", id, nil), &a); err == nil && a.GoogleID != "" {
+			v = append(v, identity{
+				UserID:   userID,
+				Provider: providerGoogle,
+				Subject:  a.GoogleID,
+				Email:    a.Email})
+		}
+	}
+	sort.Slice(v, func(i, j int) bool { return v[i].Linked.Before(v[j].Linked) })
+	return v, nil
+}
+
+func (u *userDirectory) ListIdentities(ctx context.Context, req *pb.ListIdentitiesRequest) (*pb.ListIdentitiesResponse, error) {
+	span := trace.FromContext(ctx).NewChild("usersvc/ListIdentities")
+	defer span.Finish()
+
+	if err := caller.Check(ctx, req.GetUserID()); err != nil {
+		return nil, err
+	}
+
+	v, err := u.userIdentities(ctx, req.GetUserID())
+	if err != nil {
+		return nil, err
+	}
+	resp := new(pb.ListIdentitiesResponse)
+	for _, i := range v {
+		ip, err := i.ToProto()
+		if err != nil {
+			return nil, err
+		}
+		resp.Identities = append(resp.Identities, ip)
+	}
+	n, err := u.passwordCount(trace.NewContext(ctx, span), req.GetUserID())
+	if err != nil {
+		return nil, err
+	}
+	resp.HasPassword = n > 0
+	return resp, nil
+}
+
+// passwordCount returns the number of passwords the user can log in with.
+func (u *userDirectory) passwordCount(ctx context.Context, userID string) (int, error) {
+	cs := trace.FromContext(ctx).NewChild("datastore/query/credential/count")
+	defer cs.Finish()
+	n, err := u.ds.Count(ctx, datastore.NewQuery(kindCredential).Filter("UserID =", userID).KeysOnly())
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to count the passwords of the user")
+	}
+	return n, nil
+}
+
+func (u *userDirectory) UnlinkIdentity(ctx context.Context, req *pb.UnlinkIdentityRequest) (*pb.UnlinkIdentityResponse, error) {
+	log := logging.FromContext(ctx)
+	span := trace.FromContext(ctx).NewChild("usersvc/UnlinkIdentity")
+	defer span.Finish()
+
+	if err := caller.Check(ctx, req.GetUserID()); err != nil {
+		return nil, err
+	}
+
+	v, err := u.userIdentities(ctx, req.GetUserID())
+	if err != nil {
+		return nil, err
+	}
+	var found *identity
+	for i := range v {
+		if v[i].Provider == req.GetProvider() && v[i].Subject == req.GetSubject() {
+			found = &v[i]
+		}
+	}
+	if found == nil {
+		return &pb.UnlinkIdentityResponse{Found: false}, nil
+	}
+	// a password can be logged in with too
+	passwords, err := u.passwordCount(trace.NewContext(ctx, span), req.GetUserID())
+	if err != nil {
+		return nil, err
+	}
+	if len(v)+passwords == 1 {
+		return nil, status.Error(codes.FailedPrecondition, "cannot unlink the only way to log in to the account")
+	}
+
+	if found.K == nil {
+		// legacy Google ID stored on the account
+		id, _ := strconv.ParseInt(req.GetUserID(), 10, 64)
+		k := datastore.IDKey("Account", id, nil)
+		_, err = u.ds.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+			var a account
+			if err := tx.Get(k, &a); err != nil {
+				return err
+			}
+			a.GoogleID = ""
+			_, err := tx.Put(k, &a)
+			return err
+		})
+	} else {
+		err = u.ds.Delete(ctx, found.K)
+	}
+	if err != nil {
+		log.WithField("error", err).Error("failed to unlink identity")
+		return nil, errors.Wrap(err, "failed to unlink")
+	}
+	log.WithFields(logrus.Fields{
+		"user.id":  req.GetUserID(),
+		"provider": req.GetProvider()}).Info("unlinked identity")
+	return &pb.UnlinkIdentityResponse{Found: true}, nil
+}
@@ -19,11 +19,20 @@ import (
 	"flag"
 	"net"
 	"os"
+	"time"
 
 	"cloud.google.com/go/datastore"
-	"cloud.google.com/go/trace"
+	"github.com/ahmetb/coffeelog/caller"
 	pb "github.com/ahmetb/coffeelog/coffeelog"
-	"github.com/ahmetb/coffeelog/version"
+	"github.com/ahmetb/coffeelog/health"
+	healthpb "github.com/ahmetb/coffeelog/health/grpc_health_v1"
+	"github.com/ahmetb/coffeelog/interceptor"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/metrics"
+	"github.com/ahmetb/coffeelog/mtls"
+	"github.com/ahmetb/coffeelog/rpcerror"
+	"github.com/ahmetb/coffeelog/shutdown"
+	"github.com/ahmetb/coffeelog/trace"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
@@ -34,31 +43,65 @@ var (
 	projectID = flag.String("google-project-id", "", "google cloud project id")
 	addr      = flag.String("addr", ":8001", "[host]:port to listen")
 
+	coffeeDirectoryBackend = flag.String("coffee-directory-addr", "", "address of coffee directory backend")
+	identityKeysFile       = flag.String("identity-keys-file", "", "path to the file with the keys of the identity tokens of the callers (default: $"+caller.KeysEnv+")")
+	deletionInterval       = flag.Duration("deletion-interval", time.Minute, "how often to retry unfinished account deletions")
+
+	sessionIdleTimeout = flag.Duration("session-idle-timeout", 14*24*time.Hour, "expire login sessions not used for this long")
+	sessionMaxAge      = flag.Duration("session-max-age", 90*24*time.Hour, "expire login sessions this long after the login")
+
+	tlsCert = flag.String("tls-cert", "", "path to the tls certificate of the service, for mutual tls with the other services")
+	tlsKey  = flag.String("tls-key", "", "path to the private key of the tls certificate")
+	tlsCA   = flag.String("tls-ca", "", "path to the ca certificates to verify the other services with")
+
+	shutdownDelay     = flag.Duration("shutdown-delay", 0, "how long to fail the readiness checks for on SIGTERM before stopping")
+	shutdownTimeout   = flag.Duration("shutdown-timeout", 20*time.Second, "how long to wait for the requests in flight to finish on SIGTERM")
+	metricsAddr       = flag.String("metrics-addr", "", "[host]:port to serve prometheus metrics on at /metrics (default: disabled)")
+	logLevel          = flag.String("log-level", "info", "least severe level to log: debug, info, warn or error")
+	logFormat         = flag.String("log-format", "json", "format of the logs: json or text")
+	traceExporter     = flag.String("trace-exporter", "none", "where to send the traces: otlp, stdout or none")
+	traceEndpoint     = flag.String("trace-otlp-endpoint", "", "base url of the otlp/http receiver for -trace-exporter=otlp, such as http://otel-collector:4318")
+	traceSampleRatio  = flag.Float64("trace-sample-ratio", 1.0, "fraction of the new traces to sample")
+	traceMaxPerSecond = flag.Float64("trace-max-per-second", 10, "most new traces to sample per second, 0 for no limit")
+	probe             = flag.Bool("probe", false, "check whether the service listening on -addr is serving and exit, for the kubernetes probes")
+
 	log *logrus.Entry
 )
 
 func main() {
 	flag.Parse()
-	host, err := os.Hostname()
+	var err error
+	log, err = logging.New("userdirectory", *logLevel, *logFormat)
 	if err != nil {
-		log.Fatal(errors.Wrap(err, "cannot get hostname"))
+		logrus.Fatal(err)
 	}
-	logrus.SetLevel(logrus.DebugLevel)
-	logrus.SetFormatter(&logrus.JSONFormatter{FieldMap: logrus.FieldMap{logrus.FieldKeyLevel: "severity"}})
-	log = logrus.WithFields(logrus.Fields{
-		"service": "userdirectory",
-		"host":    host,
-		"v":       version.Version(),
-	})
 	grpclog.SetLogger(log.WithField("facility", "grpc"))
 
+	tr, err := mtls.Load(mtls.Config{CertFile: *tlsCert, KeyFile: *tlsKey, CAFile: *tlsCA}, log)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "failed to load tls files"))
+	}
+	if *probe {
+		if err := health.Probe(*addr, tr.DialOption()); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	if env := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); env == "" {
 		log.Fatal("GOOGLE_APPLICATION_CREDENTIALS environment variable is not set")
 	}
 
+	if *coffeeDirectoryBackend == "" {
+		log.Fatal("coffee directory flag not specified")
+	}
 	if *projectID == "" {
 		log.Fatal("google cloud project id is not set")
 	}
+	idKeys, err := caller.LoadKeys(*identityKeysFile)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	ctx := context.Background()
 	ds, err := datastore.NewClient(ctx, *projectID)
@@ -67,22 +110,73 @@ func main() {
 	}
 	defer ds.Close()
 
-	tc, err := trace.NewClient(ctx, *projectID)
+	tc, err := trace.NewClient(trace.Config{
+		Service:      "userdirectory",
+		Exporter:     *traceExporter,
+		Endpoint:     *traceEndpoint,
+		SampleRatio:  *traceSampleRatio,
+		MaxPerSecond: *traceMaxPerSecond}, log)
 	if err != nil {
 		log.Fatal(errors.Wrap(err, "failed to initialize tracing client"))
 	}
-	ts, err := trace.NewLimitedSampler(1.0, 10)
+
+	cc, err := grpc.Dial(*coffeeDirectoryBackend,
+		tr.DialOption(),
+		grpc.WithUnaryInterceptor(interceptor.ChainUnaryClient(
+			tc.GRPCClientInterceptor(),
+			metrics.UnaryClientInterceptor(),
+			caller.UnaryClientInterceptor(idKeys))))
 	if err != nil {
-		log.Fatal(errors.Wrap(err, "failed to initialize sampling policy"))
+		log.Fatal(errors.Wrap(err, "failed to contact coffee directory"))
 	}
-	tc.SetSamplingPolicy(ts)
+	defer func() {
+		log.Debug("closing connection to coffee directory")
+		cc.Close()
+	}()
 
 	lis, err := net.Listen("tcp", *addr)
 	if err != nil {
 		log.Fatal(err)
 	}
-	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(tc.GRPCServerInterceptor()))
-	pb.RegisterUserDirectoryServer(grpcServer, &userDirectory{ds})
-	log.WithField("addr", *addr).Info("starting to listen on grpc")
-	log.Fatal(grpcServer.Serve(lis))
+	grpcServer := grpc.NewServer(append(tr.ServerOptions(), grpc.UnaryInterceptor(interceptor.ChainUnaryServer(
+		tc.GRPCServerInterceptor(),
+		metrics.UnaryServerInterceptor(),
+		peerPolicy.UnaryServerInterceptor(),
+		caller.UnaryServerInterceptor(idKeys),
+		logging.UnaryServerInterceptor(log),
+		rpcerror.UnaryServerInterceptor())))...)
+	svc := &userDirectory{
+		ds:        ds,
+		coffee:    pb.NewActivityDirectoryClient(cc),
+		deletions: make(chan struct{}, 1)}
+	pb.RegisterUserDirectoryServer(grpcServer, svc)
+	sd := shutdown.Watch(log, *shutdownDelay, *shutdownTimeout)
+	hs := health.NewServer(log, sd.Draining, "UserDirectory")
+	hs.AddCheck("datastore", datastoreCheck(ds))
+	hs.AddCheck("coffeedirectory", health.PeerCheck(cc))
+	hs.Start(ctx)
+	healthpb.RegisterHealthServer(grpcServer, hs)
+	deletionsCtx, stopDeletions := context.WithCancel(ctx)
+	go svc.runDeletions(deletionsCtx, *deletionInterval)
+	log.WithFields(logrus.Fields{"addr": *addr,
+		"service":         "userdirectory",
+		"coffeedirectory": *coffeeDirectoryBackend,
+	}).Info("starting to listen on grpc")
+	if *metricsAddr != "" {
+		go func() {
+			log.WithField("addr", *metricsAddr).Info("serving metrics")
+			log.Fatal(errors.Wrap(metrics.ListenAndServe(*metricsAddr), "failed to serve metrics"))
+		}()
+	}
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil && !sd.Draining() {
+			log.Fatal(err)
+		}
+	}()
+
+	stopCtx, cancel := sd.Wait()
+	defer cancel()
+	sd.StopGRPC(stopCtx, grpcServer)
+	stopDeletions()
+	sd.FlushTraces(tc.Shutdown)
 }
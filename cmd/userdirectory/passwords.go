@@ -0,0 +1,426 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/trace"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	kindCredential    = "Credential"    // datastore kind
+	kindAccountToken  = "AccountToken"  // datastore kind
+	kindLoginAttempts = "LoginAttempts" // datastore kind
+
+	bcryptCost        = 12
+	minPasswordLength = 10
+	maxPasswordLength = 72 // bcrypt ignores the rest
+
+	purposeVerify  = "verify"
+	purposeReset   = "reset"
+	verifyTokenTTL = 48 * time.Hour
+	resetTokenTTL  = time.Hour
+
+	throttleWindow     = 15 * time.Minute
+	maxFailuresByEmail = 5
+	maxFailuresByIP    = 50
+)
+
+// dummyHash is compared against when the email is not registered, so that
+// the response time does not reveal which emails have an account.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not a real password"), bcryptCost)
+
+// credential is the password of a local account. The key name is the
+// normalized email address, which makes the addresses unique.
+type credential struct {
+	K        *datastore.Key `datastore:"__key__"`
+	UserID   string         `datastore:"UserID"`
+	Hash     []byte         `datastore:"Hash,noindex"`
+	Verified bool           `datastore:"Verified,noindex"`
+	Created  time.Time      `datastore:"Created,noindex"`
+}
+
+// accountToken is a single use email verification or password reset token.
+// The key name is the hash of the token secret. UserID is indexed for the
+// tokens to be deleted with the account.
+type accountToken struct {
+	K       *datastore.Key `datastore:"__key__"`
+	UserID  string         `datastore:"UserID"`
+	Email   string         `datastore:"Email,noindex"`
+	Purpose string         `datastore:"Purpose,noindex"`
+	Expires time.Time      `datastore:"Expires,noindex"`
+}
+
+// loginAttempts counts the failed logins for an email address or a client IP
+// within the throttling window.
+type loginAttempts struct {
+	Failures int       `datastore:"Failures,noindex"`
+	First    time.Time `datastore:"First,noindex"`
+}
+
+func normalizeEmail(v string) string { return strings.ToLower(strings.TrimSpace(v)) }
+
+func credentialKey(email string) *datastore.Key {
+	return datastore.NameKey(kindCredential, normalizeEmail(email), nil)
+}
+
+func validatePassword(v string) error {
+	if len(v) < minPasswordLength {
+		return errors.Errorf("password must be at least %d characters", minPasswordLength)
+	} else if len(v) > maxPasswordLength {
+		return errors.Errorf("password must be at most %d bytes", maxPasswordLength)
+	}
+	return nil
+}
+
+// newAccountToken saves a new token for the purpose and returns its secret.
+func (u *userDirectory) newAccountToken(ctx context.Context, userID, email, purpose string, ttl time.Duration) (string, error) {
+	log := logging.FromContext(ctx)
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to generate token")
+	}
+	secret := base64.RawURLEncoding.EncodeToString(b)
+	k := datastore.NameKey(kindAccountToken, hashToken(secret), nil)
+	if _, err := u.ds.Put(ctx, k, &accountToken{
+		UserID:  userID,
+		Email:   normalizeEmail(email),
+		Purpose: purpose,
+		Expires: time.Now().Add(ttl)}); err != nil {
+		log.WithField("error", err).Error("failed to save to datastore")
+		return "", errors.New("failed to save")
+	}
+	return secret, nil
+}
+
+// useAccountToken deletes the token and returns it, or nil if the token is
+// unknown, expired or issued for another purpose.
+func (u *userDirectory) useAccountToken(ctx context.Context, secret, purpose string) (*accountToken, error) {
+	log := logging.FromContext(ctx)
+	if secret == "" {
+		return nil, nil
+	}
+	var v accountToken
+	k := datastore.NameKey(kindAccountToken, hashToken(secret), nil)
+	_, err := u.ds.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		if err := tx.Get(k, &v); err != nil {
+			return err
+		}
+		return tx.Delete(k)
+	})
+	if err == datastore.ErrNoSuchEntity {
+		return nil, nil
+	} else if err != nil {
+		log.WithField("error", err).Error("failed to query the datastore")
+		return nil, errors.Wrap(err, "failed to query")
+	}
+	if v.Purpose != purpose || time.Now().After(v.Expires) {
+		return nil, nil
+	}
+	return &v, nil
+}
+
+func (u *userDirectory) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.AccountTokenResponse, error) {
+	log := logging.FromContext(ctx)
+	span := trace.FromContext(ctx).NewChild("usersvc/Register")
+	defer span.Finish()
+
+	email := normalizeEmail(req.GetEmail())
+	if !strings.Contains(email, "@") {
+		return nil, status.Error(codes.InvalidArgument, "invalid email address")
+	} else if err := validatePassword(req.GetPassword()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	name := strings.TrimSpace(req.GetDisplayName())
+	if name == "" {
+		name = strings.SplitN(email, "@", 2)[0]
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.GetPassword()), bcryptCost)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to hash password")
+	}
+
+	cs := span.NewChild("datastore/allocate/account")
+	keys, err := u.ds.AllocateIDs(ctx, []*datastore.Key{datastore.IncompleteKey("Account", nil)})
+	cs.Finish()
+	if err != nil {
+		log.WithField("error", err).Error("failed to allocate account id")
+		return nil, errors.Wrap(err, "failed to allocate account id")
+	}
+	userID := fmt.Sprintf("%d", keys[0].ID)
+
+	exists := false
+	cs = span.NewChild("datastore/tx/register")
+	_, err = u.ds.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var v credential
+		if err := tx.Get(credentialKey(email), &v); err == nil {
+			exists = true
+			return nil
+		} else if err != datastore.ErrNoSuchEntity {
+			return err
+		}
+		if _, err := tx.Put(keys[0], &account{Email: email, DisplayName: name}); err != nil {
+			return err
+		}
+		_, err := tx.Put(credentialKey(email), &credential{
+			UserID:  userID,
+			Hash:    hash,
+			Created: time.Now()})
+		return err
+	})
+	cs.Finish()
+	if err != nil {
+		log.WithField("error", err).Error("failed to register account")
+		return nil, errors.Wrap(err, "failed to save")
+	}
+	if exists {
+		// do not reveal that the email is registered, nothing to send
+		log.Debug("email already registered")
+		return &pb.AccountTokenResponse{}, nil
+	}
+	log.WithField("id", userID).Info("registered local account")
+
+	tok, err := u.newAccountToken(ctx, userID, email, purposeVerify, verifyTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.AccountTokenResponse{Token: tok}, nil
+}
+
+func (u *userDirectory) RequestEmailVerification(ctx context.Context, req *pb.EmailRequest) (*pb.AccountTokenResponse, error) {
+	log := logging.FromContext(ctx)
+	span := trace.FromContext(ctx).NewChild("usersvc/RequestEmailVerification")
+	defer span.Finish()
+
+	var v credential
+	if err := u.ds.Get(ctx, credentialKey(req.GetEmail()), &v); err == datastore.ErrNoSuchEntity {
+		return &pb.AccountTokenResponse{}, nil
+	} else if err != nil {
+		log.WithField("error", err).Error("failed to query the datastore")
+		return nil, errors.Wrap(err, "failed to query")
+	} else if v.Verified {
+		return &pb.AccountTokenResponse{}, nil
+	}
+	tok, err := u.newAccountToken(ctx, v.UserID, req.GetEmail(), purposeVerify, verifyTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.AccountTokenResponse{Token: tok}, nil
+}
+
+// markVerified marks the email address of the local account as verified.
+func (u *userDirectory) markVerified(ctx context.Context, email, userID string, hash []byte) error {
+	_, err := u.ds.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var v credential
+		if err := tx.Get(credentialKey(email), &v); err != nil {
+			return err
+		} else if v.UserID != userID {
+			return errors.New("credential belongs to another user")
+		}
+		v.Verified = true
+		if hash != nil {
+			v.Hash = hash
+		}
+		_, err := tx.Put(v.K, &v)
+		return err
+	})
+	return err
+}
+
+func (u *userDirectory) VerifyEmail(ctx context.Context, req *pb.AccountTokenRequest) (*pb.UserResponse, error) {
+	log := logging.FromContext(ctx)
+	span := trace.FromContext(ctx).NewChild("usersvc/VerifyEmail")
+	defer span.Finish()
+
+	t, err := u.useAccountToken(ctx, req.GetToken(), purposeVerify)
+	if err != nil {
+		return nil, err
+	} else if t == nil {
+		return &pb.UserResponse{Found: false}, nil
+	}
+	if err := u.markVerified(ctx, t.Email, t.UserID, nil); err == datastore.ErrNoSuchEntity {
+		return &pb.UserResponse{Found: false}, nil
+	} else if err != nil {
+		log.WithField("error", err).Error("failed to verify email")
+		return nil, errors.Wrap(err, "failed to save")
+	}
+	log.WithField("user.id", t.UserID).Info("verified email")
+	return u.GetUser(ctx, &pb.UserRequest{ID: t.UserID})
+}
+
+// throttled reports whether there were too many failed logins for the key.
+func (u *userDirectory) throttled(ctx context.Context, name string, max int) (bool, error) {
+	var v loginAttempts
+	if err := u.ds.Get(ctx, datastore.NameKey(kindLoginAttempts, name, nil), &v); err == datastore.ErrNoSuchEntity {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return v.Failures >= max && time.Since(v.First) < throttleWindow, nil
+}
+
+// recordFailure counts a failed login for the key.
+func (u *userDirectory) recordFailure(ctx context.Context, name string) error {
+	k := datastore.NameKey(kindLoginAttempts, name, nil)
+	_, err := u.ds.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var v loginAttempts
+		if err := tx.Get(k, &v); err != nil && err != datastore.ErrNoSuchEntity {
+			return err
+		}
+		if time.Since(v.First) >= throttleWindow {
+			v = loginAttempts{First: time.Now()}
+		}
+		v.Failures++
+		_, err := tx.Put(k, &v)
+		return err
+	})
+	return err
+}
+
+func (u *userDirectory) PasswordLogin(ctx context.Context, req *pb.PasswordLoginRequest) (*pb.PasswordLoginResponse, error) {
+	span := trace.FromContext(ctx).NewChild("usersvc/PasswordLogin")
+	defer span.Finish()
+
+	email := normalizeEmail(req.GetEmail())
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{"op": "PasswordLogin", "ip": req.GetIP()})
+	byEmail, byIP := "email|"+email, "ip|"+req.GetIP()
+
+	cs := span.NewChild("datastore/get/login_attempts")
+	t1, err := u.throttled(ctx, byEmail, maxFailuresByEmail)
+	if err == nil && req.GetIP() != "" {
+		var t2 bool
+		t2, err = u.throttled(ctx, byIP, maxFailuresByIP)
+		t1 = t1 || t2
+	}
+	cs.Finish()
+	if err != nil {
+		log.WithField("error", err).Error("failed to query the datastore")
+		return nil, errors.Wrap(err, "failed to query")
+	} else if t1 {
+		log.Warn("login throttled")
+		return &pb.PasswordLoginResponse{Status: pb.PasswordLoginResponse_THROTTLED}, nil
+	}
+
+	var v credential
+	hash := dummyHash
+	err = u.ds.Get(ctx, credentialKey(email), &v)
+	if err == nil {
+		hash = v.Hash
+	} else if err != datastore.ErrNoSuchEntity {
+		log.WithField("error", err).Error("failed to query the datastore")
+		return nil, errors.Wrap(err, "failed to query")
+	}
+	cs = span.NewChild("bcrypt/compare")
+	cmpErr := bcrypt.CompareHashAndPassword(hash, []byte(req.GetPassword()))
+	cs.Finish()
+	if err != nil || cmpErr != nil {
+		for _, k := range []string{byEmail, byIP} {
+			if k == byIP && req.GetIP() == "" {
+				continue
+			}
+			if err := u.recordFailure(ctx, k); err != nil {
+				log.WithField("error", err).Warn("failed to record failed login")
+			}
+		}
+		log.Info("invalid credentials")
+		return &pb.PasswordLoginResponse{Status: pb.PasswordLoginResponse_INVALID_CREDENTIALS}, nil
+	}
+	if err := u.ds.Delete(ctx, datastore.NameKey(kindLoginAttempts, byEmail, nil)); err != nil {
+		log.WithField("error", err).Warn("failed to reset failed logins")
+	}
+	if !v.Verified {
+		return &pb.PasswordLoginResponse{Status: pb.PasswordLoginResponse_UNVERIFIED}, nil
+	}
+
+	user, err := u.GetUser(ctx, &pb.UserRequest{ID: v.UserID})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve user")
+	} else if !user.GetFound() {
+		return &pb.PasswordLoginResponse{Status: pb.PasswordLoginResponse_INVALID_CREDENTIALS}, nil
+	}
+	log.WithField("user.id", v.UserID).Info("authenticated with password")
+	return &pb.PasswordLoginResponse{Status: pb.PasswordLoginResponse_OK, User: user.GetUser()}, nil
+}
+
+func (u *userDirectory) RequestPasswordReset(ctx context.Context, req *pb.EmailRequest) (*pb.AccountTokenResponse, error) {
+	log := logging.FromContext(ctx)
+	span := trace.FromContext(ctx).NewChild("usersvc/RequestPasswordReset")
+	defer span.Finish()
+
+	var v credential
+	if err := u.ds.Get(ctx, credentialKey(req.GetEmail()), &v); err == datastore.ErrNoSuchEntity {
+		log.Debug("password reset for unknown email")
+		return &pb.AccountTokenResponse{}, nil
+	} else if err != nil {
+		log.WithField("error", err).Error("failed to query the datastore")
+		return nil, errors.Wrap(err, "failed to query")
+	}
+	tok, err := u.newAccountToken(ctx, v.UserID, req.GetEmail(), purposeReset, resetTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	log.WithField("user.id", v.UserID).Info("password reset requested")
+	return &pb.AccountTokenResponse{Token: tok}, nil
+}
+
+func (u *userDirectory) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.UserResponse, error) {
+	log := logging.FromContext(ctx)
+	span := trace.FromContext(ctx).NewChild("usersvc/ResetPassword")
+	defer span.Finish()
+
+	if err := validatePassword(req.GetPassword()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	t, err := u.useAccountToken(ctx, req.GetToken(), purposeReset)
+	if err != nil {
+		return nil, err
+	} else if t == nil {
+		return &pb.UserResponse{Found: false}, nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.GetPassword()), bcryptCost)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to hash password")
+	}
+	// the reset link was received by email, so the address is verified too
+	if err := u.markVerified(ctx, t.Email, t.UserID, hash); err == datastore.ErrNoSuchEntity {
+		return &pb.UserResponse{Found: false}, nil
+	} else if err != nil {
+		log.WithField("error", err).Error("failed to reset password")
+		return nil, errors.Wrap(err, "failed to save")
+	}
+	if err := u.ds.Delete(ctx, datastore.NameKey(kindLoginAttempts, "email|"+t.Email, nil)); err != nil {
+		log.WithField("error", err).Warn("failed to reset failed logins")
+	}
+	// log out everywhere, the old password may have been compromised
+	if _, err := u.revokeAllSessions(ctx, t.UserID); err != nil {
+		return nil, err
+	}
+	log.WithField("user.id", t.UserID).Info("password reset")
+	return u.GetUser(ctx, &pb.UserRequest{ID: t.UserID})
+}
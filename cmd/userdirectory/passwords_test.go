@@ -0,0 +1,47 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{name: "shortest", password: strings.Repeat("a", minPasswordLength)},
+		{name: "longest", password: strings.Repeat("a", maxPasswordLength)},
+		{name: "too short", password: strings.Repeat("a", minPasswordLength-1), wantErr: true},
+		{name: "longer than bcrypt reads", password: strings.Repeat("a", maxPasswordLength+1), wantErr: true},
+		{name: "empty", password: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := validatePassword(tt.password); (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, expected error: %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCredentialKey(t *testing.T) {
+	for _, v := range []string{"jane@example.com", " Jane@Example.com ", "JANE@EXAMPLE.COM"} {
+		if got := credentialKey(v).Name; got != "jane@example.com" {
+			t.Errorf("%q: got key %q, expected %q", v, got, "jane@example.com")
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cloud.google.com/go/datastore"
+	"github.com/ahmetb/coffeelog/caller"
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/trace"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	maxDisplayNameLength = 64
+	maxBioLength         = 500
+)
+
+// profileFields are the names of the User fields that can be updated.
+var profileFields = []string{"DisplayName", "Username", "Picture", "Bio", "Units", "TimeZone", "Visibility", "SyncProfile"}
+
+// setField validates the field of the user and copies it to the account.
+func (a *account) setField(name string, u *pb.User) error {
+	switch name {
+	case "DisplayName":
+		v := strings.TrimSpace(u.GetDisplayName())
+		if v == "" {
+			return errors.New("display name is required")
+		} else if utf8.RuneCountInString(v) > maxDisplayNameLength {
+			return errors.Errorf("display name is longer than %d characters", maxDisplayNameLength)
+		}
+		a.DisplayName = v
+	case "Username":
+		if v := u.GetUsername(); v != "" {
+			if err := validateUsername(v); err != nil {
+				return err
+			}
+		}
+		a.Username = u.GetUsername()
+	case "Picture":
+		if v := u.GetPicture(); v != "" {
+			if pu, err := url.Parse(v); err != nil || pu.Scheme != "https" || pu.Host == "" {
+				return errors.New("picture must be an https url")
+			}
+		}
+		a.Picture = u.GetPicture()
+	case "Bio":
+		v := strings.TrimSpace(u.GetBio())
+		if utf8.RuneCountInString(v) > maxBioLength {
+			return errors.Errorf("bio is longer than %d characters", maxBioLength)
+		}
+		a.Bio = v
+	case "Units":
+		switch u.GetUnits() {
+		case "", "oz", "ml":
+		default:
+			return errors.Errorf("unknown units %q", u.GetUnits())
+		}
+		a.Units = u.GetUnits()
+	case "TimeZone":
+		if v := u.GetTimeZone(); v != "" {
+			if _, err := time.LoadLocation(v); err != nil || v == "Local" {
+				return errors.Errorf("unknown time zone %q", v)
+			}
+		}
+		a.TimeZone = u.GetTimeZone()
+	case "Visibility":
+		if _, ok := pb.Visibility_name[int32(u.GetVisibility())]; !ok {
+			return errors.New("unknown visibility")
+		}
+		a.Visibility = int32(u.GetVisibility())
+	case "SyncProfile":
+		a.SyncProfile = u.GetSyncProfile()
+	default:
+		return errors.Errorf("field %q cannot be updated", name)
+	}
+	return nil
+}
+
+func (u *userDirectory) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.User, error) {
+	span := trace.FromContext(ctx).NewChild("usersvc/UpdateUser")
+	defer span.Finish()
+
+	if err := caller.Check(ctx, req.GetUser().GetID()); err != nil {
+		return nil, err
+	}
+
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"op":      "UpdateUser",
+		"user.id": req.GetUser().GetID()})
+	log.Debug("received request")
+
+	id, err := strconv.ParseInt(req.GetUser().GetID(), 10, 64)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "cannot parse ID")
+	}
+	fields := req.GetFields()
+	if len(fields) == 0 {
+		fields = profileFields
+	}
+	// validate before reading the account, so that invalid requests do not
+	// touch the datastore
+	var tmp account
+	for _, f := range fields {
+		if err := tmp.setField(f, req.GetUser()); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	var v account
+	k := datastore.IDKey("Account", id, nil)
+	cs := span.NewChild("datastore/tx/update_account")
+	_, err = u.ds.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		if err := tx.Get(k, &v); err != nil {
+			return err
+		}
+		old := v.Username
+		for _, f := range fields {
+			v.setField(f, req.GetUser())
+		}
+		if err := claimUsername(tx, req.GetUser().GetID(), old, v.Username); err != nil {
+			return err
+		}
+		_, err := tx.Put(k, &v)
+		return err
+	})
+	cs.Finish()
+	if err == datastore.ErrNoSuchEntity {
+		return nil, status.Error(codes.NotFound, "user not found")
+	} else if err == errUsernameTaken {
+		return nil, err
+	} else if err != nil {
+		log.WithField("error", err).Error("failed to update account")
+		return nil, errors.Wrap(err, "failed to save")
+	}
+	v.K = k
+	log.WithField("fields", fields).Info("updated user")
+	return v.ToProto(), nil
+}
+
+// syncProfile updates the name and picture of the user from the profile at
+// the identity provider, if the user chose to keep them in sync.
+func (u *userDirectory) syncProfile(ctx context.Context, userID string, p *pb.ExternalProfile) error {
+	id, err := strconv.ParseInt(userID, 10, 64)
+	if err != nil {
+		return errors.New("cannot parse ID")
+	}
+	k := datastore.IDKey("Account", id, nil)
+	_, err = u.ds.RunInTransaction(ctx, func(tx *datastore.Transaction) error {
+		var v account
+		if err := tx.Get(k, &v); err != nil {
+			return err
+		}
+		if !v.SyncProfile {
+			return nil
+		}
+		changed := false
+		if name := strings.TrimSpace(p.GetDisplayName()); name != "" && name != v.DisplayName &&
+			utf8.RuneCountInString(name) <= maxDisplayNameLength {
+			v.DisplayName, changed = name, true
+		}
+		if pic := p.GetPictureURL(); pic != "" && pic != v.Picture {
+			v.Picture, changed = pic, true
+		}
+		if !changed {
+			return nil
+		}
+		_, err := tx.Put(k, &v)
+		return err
+	})
+	return errors.Wrap(err, "failed to sync profile")
+}
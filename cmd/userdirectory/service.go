@@ -20,15 +20,22 @@ import (
 	"time"
 
 	"cloud.google.com/go/datastore"
-	"cloud.google.com/go/trace"
 	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/trace"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type userDirectory struct {
-	ds *datastore.Client
+	ds     *datastore.Client
+	coffee pb.ActivityDirectoryClient
+
+	// deletions is signalled to start on new deletion jobs right away
+	deletions chan struct{}
 }
 
 type account struct {
@@ -36,65 +43,47 @@ type account struct {
 	DisplayName string         `datastore:"DisplayName"`
 	Email       string         `datastore:"Email"`
 	Picture     string         `datastore:"Picture"`
-	GoogleID    string         `datastore:"GoogleID"`
+	GoogleID    string         `datastore:"GoogleID"` // legacy, see identity
+	Username    string         `datastore:"Username,noindex"`
+	Bio         string         `datastore:"Bio,noindex"`
+	Units       string         `datastore:"Units,noindex"`
+	TimeZone    string         `datastore:"TimeZone,noindex"`
+	Visibility  int32          `datastore:"Visibility,noindex"`
+	SyncProfile bool           `datastore:"SyncProfile,noindex"`
+	Deleting    bool           `datastore:"Deleting,noindex"` // see deletion
 }
 
-func (u *userDirectory) AuthorizeGoogle(ctx context.Context, goog *pb.GoogleUser) (*pb.User, error) {
-	span := trace.FromContext(ctx).NewChild("usersvc/AuthorizeGoogle")
-	defer span.Finish()
-
-	log := log.WithFields(logrus.Fields{
-		"op":        "AuthorizeGoogle",
-		"google.id": goog.GetID()})
-	log.Debug("received request")
-
-	cs := span.NewChild("datastore/query/account/by_googleid")
-	q := datastore.NewQuery("Account").Filter("GoogleID =", goog.ID).Limit(1)
-	var v []account
-	if _, err := u.ds.GetAll(ctx, q, &v); err != nil {
-		log.WithField("error", err).Error("failed to query the datastore")
-		return nil, errors.Wrap(err, "failed to query")
-	}
-	cs.Finish()
-
-	var id string
-	if len(v) == 0 {
-		cs = span.NewChild("datastore/put/account")
-		// create new account
-		k, err := u.ds.Put(ctx, datastore.IncompleteKey("Account", nil), &account{
-			Email:       goog.Email,
-			DisplayName: goog.DisplayName,
-			Picture:     goog.PictureURL,
-			GoogleID:    goog.ID,
-		})
-		if err != nil {
-			log.WithField("error", err).Error("failed to save to datastore")
-			return nil, errors.New("failed to save")
-		}
-		id = fmt.Sprintf("%d", k.ID)
-		log.WithField("id", id).Info("created new user account")
-		cs.Finish()
-	} else {
-		// return existing account
-		id = fmt.Sprintf("%d", v[0].K.ID)
-		log.WithField("id", id).Debug("user exists")
-	}
+func (a *account) ToProto() *pb.User {
+	return &pb.User{
+		ID:          fmt.Sprintf("%d", a.K.ID),
+		DisplayName: a.DisplayName,
+		Picture:     a.Picture,
+		Bio:         a.Bio,
+		Username:    a.Username,
+		Units:       a.Units,
+		TimeZone:    a.TimeZone,
+		Visibility:  pb.Visibility(a.Visibility),
+		SyncProfile: a.SyncProfile}
+}
 
-	// retrieve user again from backend
-	user, err := u.GetUser(ctx, &pb.UserRequest{ID: id})
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to retrieve user")
-	} else if !user.GetFound() {
-		return nil, errors.New("cannot find user that is just created")
-	}
-	return user.GetUser(), nil
+// AuthorizeGoogle is AuthorizeExternal for Google accounts, kept for
+// compatibility with existing clients.
+func (u *userDirectory) AuthorizeGoogle(ctx context.Context, goog *pb.GoogleUser) (*pb.User, error) {
+	return u.AuthorizeExternal(ctx, &pb.AuthorizeExternalRequest{
+		Provider: providerGoogle,
+		Subject:  goog.GetID(),
+		Profile: &pb.ExternalProfile{
+			DisplayName:   goog.GetDisplayName(),
+			PictureURL:    goog.GetPictureURL(),
+			Email:         goog.GetEmail(),
+			EmailVerified: true}})
 }
 
 func (u *userDirectory) GetUser(ctx context.Context, req *pb.UserRequest) (*pb.UserResponse, error) {
 	span := trace.FromContext(ctx).NewChild("usersvc/GetUser")
 	defer span.Finish()
 
-	log := log.WithFields(logrus.Fields{
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
 		"op": "GetUser",
 		"id": req.GetID()})
 	start := time.Now()
@@ -105,7 +94,7 @@ func (u *userDirectory) GetUser(ctx context.Context, req *pb.UserRequest) (*pb.U
 
 	id, err := strconv.ParseInt(req.ID, 10, 64)
 	if err != nil {
-		return nil, errors.New("cannot parse ID")
+		return nil, status.Error(codes.InvalidArgument, "cannot parse ID")
 	}
 
 	cs := span.NewChild("datastore/query/account/by_id")
@@ -120,11 +109,10 @@ func (u *userDirectory) GetUser(ctx context.Context, req *pb.UserRequest) (*pb.U
 		log.WithField("error", err).Error("failed to query the datastore")
 		return nil, errors.Wrap(err, "failed to query")
 	}
+	if v.Deleting {
+		log.Debug("user is being deleted")
+		return &pb.UserResponse{Found: false}, nil
+	}
 	log.Debug("found user")
-	return &pb.UserResponse{
-		Found: true,
-		User: &pb.User{
-			ID:          fmt.Sprintf("%d", v.K.ID),
-			DisplayName: v.DisplayName,
-			Picture:     v.Picture}}, nil
+	return &pb.UserResponse{Found: true, User: v.ToProto()}, nil
 }
@@ -0,0 +1,290 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/ahmetb/coffeelog/caller"
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/trace"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	kindSession = "Session" // datastore kind
+
+	maxUserAgent = 256
+)
+
+// session is a login session as represented in Datastore. The key name is
+// the hash of the session secret, which is only known to the client.
+type session struct {
+	K         *datastore.Key `datastore:"__key__"`
+	UserID    string         `datastore:"UserID"`
+	UserAgent string         `datastore:"UserAgent,noindex"`
+	IP        string         `datastore:"IP,noindex"`
+	Created   time.Time      `datastore:"Created"`
+	LastSeen  time.Time      `datastore:"LastSeen,noindex"`
+}
+
+// expires returns the time the session expires unless it is used again.
+func (s *session) expires() time.Time {
+	idle := s.LastSeen.Add(*sessionIdleTimeout)
+	if abs := s.Created.Add(*sessionMaxAge); abs.Before(idle) {
+		return abs
+	}
+	return idle
+}
+
+func (s *session) ToProto() (*pb.Session, error) {
+	var ts [3]*timestamp.Timestamp
+	for i, t := range []time.Time{s.Created, s.LastSeen, s.expires()} {
+		v, err := ptypes.TimestampProto(t)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to convert timestamp")
+		}
+		ts[i] = v
+	}
+	return &pb.Session{
+		ID:        s.K.Name,
+		UserID:    s.UserID,
+		UserAgent: s.UserAgent,
+		IP:        s.IP,
+		Created:   ts[0],
+		LastSeen:  ts[1],
+		Expires:   ts[2]}, nil
+}
+
+func (u *userDirectory) CreateSession(ctx context.Context, req *pb.CreateSessionRequest) (*pb.CreateSessionResponse, error) {
+	span := trace.FromContext(ctx).NewChild("usersvc/CreateSession")
+	defer span.Finish()
+
+	if err := caller.Check(ctx, req.GetUserID()); err != nil {
+		return nil, err
+	}
+
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"op":      "CreateSession",
+		"user.id": req.GetUserID()})
+	if user, err := u.GetUser(ctx, &pb.UserRequest{ID: req.GetUserID()}); err != nil {
+		return nil, errors.Wrap(err, "failed to look up the user")
+	} else if !user.GetFound() {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, errors.Wrap(err, "failed to generate session secret")
+	}
+	secret := base64.RawURLEncoding.EncodeToString(b)
+	ua := req.GetUserAgent()
+	if len(ua) > maxUserAgent {
+		ua = ua[:maxUserAgent]
+	}
+	now := time.Now()
+	v := session{
+		K:         datastore.NameKey(kindSession, hashToken(secret), nil),
+		UserID:    req.GetUserID(),
+		UserAgent: ua,
+		IP:        req.GetIP(),
+		Created:   now,
+		LastSeen:  now}
+
+	cs := span.NewChild("datastore/put/session")
+	_, err := u.ds.Put(ctx, v.K, &v)
+	cs.Finish()
+	if err != nil {
+		log.WithField("error", err).Error("failed to save to datastore")
+		return nil, errors.New("failed to save")
+	}
+	log.Info("created session")
+
+	sp, err := v.ToProto()
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateSessionResponse{Session: sp, Secret: secret}, nil
+}
+
+func (u *userDirectory) GetSession(ctx context.Context, req *pb.GetSessionRequest) (*pb.SessionResponse, error) {
+	log := logging.FromContext(ctx)
+	span := trace.FromContext(ctx).NewChild("usersvc/GetSession")
+	defer span.Finish()
+
+	if req.GetSecret() == "" {
+		return &pb.SessionResponse{Found: false}, nil
+	}
+
+	cs := span.NewChild("datastore/get/session")
+	var v session
+	err := u.ds.Get(ctx, datastore.NameKey(kindSession, hashToken(req.GetSecret()), nil), &v)
+	cs.Finish()
+	if err == datastore.ErrNoSuchEntity {
+		log.Debug("unknown session")
+		return &pb.SessionResponse{Found: false}, nil
+	} else if err != nil {
+		log.WithField("error", err).Error("failed to query the datastore")
+		return nil, errors.Wrap(err, "failed to query")
+	}
+
+	now := time.Now()
+	if now.After(v.expires()) {
+		log.WithField("user.id", v.UserID).Debug("session expired")
+		if err := u.ds.Delete(ctx, v.K); err != nil {
+			log.WithField("error", err).Warn("failed to delete expired session")
+		}
+		return &pb.SessionResponse{Found: false}, nil
+	}
+	if now.Sub(v.LastSeen) > lastUsedPeriod {
+		v.LastSeen = now
+		if _, err := u.ds.Put(ctx, v.K, &v); err != nil {
+			// not fatal, the session is still valid
+			log.WithField("error", err).Warn("failed to update session last seen time")
+		}
+	}
+
+	user, err := u.GetUser(ctx, &pb.UserRequest{ID: v.UserID})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve user")
+	} else if !user.GetFound() {
+		return &pb.SessionResponse{Found: false}, nil
+	}
+	sp, err := v.ToProto()
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SessionResponse{Found: true, Session: sp, User: user.GetUser()}, nil
+}
+
+// userSessions returns the sessions of the user, deleting the expired ones.
+func (u *userDirectory) userSessions(ctx context.Context, userID string) ([]session, error) {
+	log := logging.FromContext(ctx)
+	var v []session
+	if _, err := u.ds.GetAll(ctx, datastore.NewQuery(kindSession).Filter("UserID =", userID), &v); err != nil {
+		log.WithField("error", err).Error("failed to query the datastore")
+		return nil, errors.Wrap(err, "failed to query")
+	}
+	var (
+		live    []session
+		expired []*datastore.Key
+		now     = time.Now()
+	)
+	for _, s := range v {
+		if now.After(s.expires()) {
+			expired = append(expired, s.K)
+		} else {
+			live = append(live, s)
+		}
+	}
+	if len(expired) > 0 {
+		if err := u.ds.DeleteMulti(ctx, expired); err != nil {
+			log.WithField("error", err).Warn("failed to delete expired sessions")
+		}
+	}
+	return live, nil
+}
+
+func (u *userDirectory) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	span := trace.FromContext(ctx).NewChild("usersvc/ListSessions")
+	defer span.Finish()
+
+	if err := caller.Check(ctx, req.GetUserID()); err != nil {
+		return nil, err
+	}
+
+	v, err := u.userSessions(ctx, req.GetUserID())
+	if err != nil {
+		return nil, err
+	}
+	resp := new(pb.ListSessionsResponse)
+	for _, s := range v {
+		sp, err := s.ToProto()
+		if err != nil {
+			return nil, err
+		}
+		resp.Sessions = append(resp.Sessions, sp)
+	}
+	return resp, nil
+}
+
+func (u *userDirectory) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	log := logging.FromContext(ctx)
+	span := trace.FromContext(ctx).NewChild("usersvc/RevokeSession")
+	defer span.Finish()
+
+	if err := caller.Check(ctx, req.GetUserID()); err != nil {
+		return nil, err
+	}
+
+	if req.GetID() == "" {
+		return nil, status.Error(codes.InvalidArgument, "session ID is required")
+	}
+	k := datastore.NameKey(kindSession, req.GetID(), nil)
+	var v session
+	if err := u.ds.Get(ctx, k, &v); err == datastore.ErrNoSuchEntity {
+		return &pb.RevokeSessionResponse{Revoked: 0}, nil
+	} else if err != nil {
+		log.WithField("error", err).Error("failed to query the datastore")
+		return nil, errors.Wrap(err, "failed to query")
+	} else if v.UserID != req.GetUserID() {
+		// do not reveal sessions of other users
+		return &pb.RevokeSessionResponse{Revoked: 0}, nil
+	}
+	if err := u.ds.Delete(ctx, k); err != nil {
+		log.WithField("error", err).Error("failed to delete from datastore")
+		return nil, errors.Wrap(err, "failed to delete")
+	}
+	log.WithField("user.id", req.GetUserID()).Info("revoked session")
+	return &pb.RevokeSessionResponse{Revoked: 1}, nil
+}
+
+func (u *userDirectory) RevokeAllSessions(ctx context.Context, req *pb.RevokeAllSessionsRequest) (*pb.RevokeSessionResponse, error) {
+	span := trace.FromContext(ctx).NewChild("usersvc/RevokeAllSessions")
+	defer span.Finish()
+
+	if err := caller.Check(ctx, req.GetUserID()); err != nil {
+		return nil, err
+	}
+	return u.revokeAllSessions(trace.NewContext(ctx, span), req.GetUserID())
+}
+
+// revokeAllSessions logs the user out everywhere.
+func (u *userDirectory) revokeAllSessions(ctx context.Context, userID string) (*pb.RevokeSessionResponse, error) {
+	log := logging.FromContext(ctx)
+	keys, err := u.ds.GetAll(ctx, datastore.NewQuery(kindSession).Filter("UserID =", userID).KeysOnly(), nil)
+	if err != nil {
+		log.WithField("error", err).Error("failed to query the datastore")
+		return nil, errors.Wrap(err, "failed to query")
+	}
+	if err := u.ds.DeleteMulti(ctx, keys); err != nil {
+		log.WithField("error", err).Error("failed to delete from datastore")
+		return nil, errors.Wrap(err, "failed to delete")
+	}
+	log.WithFields(logrus.Fields{
+		"user.id": userID,
+		"count":   len(keys)}).Info("revoked all sessions")
+	return &pb.RevokeSessionResponse{Revoked: int32(len(keys))}, nil
+}
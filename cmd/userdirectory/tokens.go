@@ -0,0 +1,241 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/datastore"
+	"github.com/ahmetb/coffeelog/caller"
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/trace"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	kindToken = "Token" // datastore kind
+
+	tokenPrefix    = "clt_" // makes the tokens recognizable, e.g. by secret scanners
+	maxTokens      = 20     // per user
+	maxTokenName   = 100
+	lastUsedPeriod = time.Minute // granularity of LastUsed updates
+)
+
+// token is a personal access token as represented in Datastore. The secret
+// itself is never stored, only its SHA-256 hash.
+type token struct {
+	K        *datastore.Key `datastore:"__key__"`
+	UserID   string         `datastore:"UserID"`
+	Name     string         `datastore:"Name,noindex"`
+	Hash     string         `datastore:"Hash"`
+	Prefix   string         `datastore:"Prefix,noindex"`
+	Created  time.Time      `datastore:"Created"`
+	LastUsed time.Time      `datastore:"LastUsed,noindex"`
+}
+
+func (t *token) ToProto() (*pb.Token, error) {
+	created, err := ptypes.TimestampProto(t.Created)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert timestamp")
+	}
+	v := &pb.Token{
+		ID:      fmt.Sprintf("%d", t.K.ID),
+		UserID:  t.UserID,
+		Name:    t.Name,
+		Prefix:  t.Prefix,
+		Created: created}
+	if !t.LastUsed.IsZero() {
+		if v.LastUsed, err = ptypes.TimestampProto(t.LastUsed); err != nil {
+			return nil, errors.Wrap(err, "failed to convert timestamp")
+		}
+	}
+	return v, nil
+}
+
+func hashToken(secret string) string {
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
+
+func (u *userDirectory) CreateToken(ctx context.Context, req *pb.CreateTokenRequest) (*pb.CreateTokenResponse, error) {
+	span := trace.FromContext(ctx).NewChild("usersvc/CreateToken")
+	defer span.Finish()
+
+	if err := caller.Check(ctx, req.GetUserID()); err != nil {
+		return nil, err
+	}
+
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"op":      "CreateToken",
+		"user.id": req.GetUserID()})
+	name := strings.TrimSpace(req.GetName())
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "token name is required")
+	} else if len(name) > maxTokenName {
+		return nil, status.Error(codes.InvalidArgument, "token name is too long")
+	}
+	if user, err := u.GetUser(ctx, &pb.UserRequest{ID: req.GetUserID()}); err != nil {
+		return nil, errors.Wrap(err, "failed to look up the user")
+	} else if !user.GetFound() {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	cs := span.NewChild("datastore/query/token/by_user")
+	n, err := u.ds.Count(ctx, datastore.NewQuery(kindToken).Filter("UserID =", req.GetUserID()).KeysOnly())
+	cs.Finish()
+	if err != nil {
+		log.WithField("error", err).Error("failed to query the datastore")
+		return nil, errors.Wrap(err, "failed to query")
+	} else if n >= maxTokens {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot have more than %d tokens", maxTokens)
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, errors.Wrap(err, "failed to generate token")
+	}
+	secret := tokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	v := token{
+		UserID:  req.GetUserID(),
+		Name:    name,
+		Hash:    hashToken(secret),
+		Prefix:  secret[:len(tokenPrefix)+4],
+		Created: time.Now()}
+
+	cs = span.NewChild("datastore/put/token")
+	k, err := u.ds.Put(ctx, datastore.IncompleteKey(kindToken, nil), &v)
+	cs.Finish()
+	if err != nil {
+		log.WithField("error", err).Error("failed to save to datastore")
+		return nil, errors.New("failed to save")
+	}
+	v.K = k
+	log.WithField("token.id", k.ID).Info("created access token")
+
+	t, err := v.ToProto()
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateTokenResponse{Token: t, Secret: secret}, nil
+}
+
+func (u *userDirectory) ListTokens(ctx context.Context, req *pb.ListTokensRequest) (*pb.ListTokensResponse, error) {
+	log := logging.FromContext(ctx)
+	span := trace.FromContext(ctx).NewChild("usersvc/ListTokens")
+	defer span.Finish()
+
+	if err := caller.Check(ctx, req.GetUserID()); err != nil {
+		return nil, err
+	}
+
+	var v []token
+	if _, err := u.ds.GetAll(ctx, datastore.NewQuery(kindToken).Filter("UserID =", req.GetUserID()), &v); err != nil {
+		log.WithField("error", err).Error("failed to query the datastore")
+		return nil, errors.Wrap(err, "failed to query")
+	}
+	resp := new(pb.ListTokensResponse)
+	for _, t := range v {
+		tp, err := t.ToProto()
+		if err != nil {
+			return nil, err
+		}
+		resp.Tokens = append(resp.Tokens, tp)
+	}
+	return resp, nil
+}
+
+func (u *userDirectory) RevokeToken(ctx context.Context, req *pb.RevokeTokenRequest) (*pb.RevokeTokenResponse, error) {
+	span := trace.FromContext(ctx).NewChild("usersvc/RevokeToken")
+	defer span.Finish()
+
+	if err := caller.Check(ctx, req.GetUserID()); err != nil {
+		return nil, err
+	}
+
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"op":       "RevokeToken",
+		"user.id":  req.GetUserID(),
+		"token.id": req.GetID()})
+	id, err := strconv.ParseInt(req.GetID(), 10, 64)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "cannot parse ID")
+	}
+
+	k := datastore.IDKey(kindToken, id, nil)
+	var v token
+	if err := u.ds.Get(ctx, k, &v); err == datastore.ErrNoSuchEntity {
+		return &pb.RevokeTokenResponse{Found: false}, nil
+	} else if err != nil {
+		log.WithField("error", err).Error("failed to query the datastore")
+		return nil, errors.Wrap(err, "failed to query")
+	} else if v.UserID != req.GetUserID() {
+		// do not reveal tokens of other users
+		return &pb.RevokeTokenResponse{Found: false}, nil
+	}
+	if err := u.ds.Delete(ctx, k); err != nil {
+		log.WithField("error", err).Error("failed to delete from datastore")
+		return nil, errors.Wrap(err, "failed to delete")
+	}
+	log.Info("revoked access token")
+	return &pb.RevokeTokenResponse{Found: true}, nil
+}
+
+func (u *userDirectory) AuthenticateToken(ctx context.Context, req *pb.AuthenticateTokenRequest) (*pb.UserResponse, error) {
+	log := logging.FromContext(ctx)
+	span := trace.FromContext(ctx).NewChild("usersvc/AuthenticateToken")
+	defer span.Finish()
+
+	if !strings.HasPrefix(req.GetSecret(), tokenPrefix) {
+		return &pb.UserResponse{Found: false}, nil
+	}
+
+	cs := span.NewChild("datastore/query/token/by_hash")
+	var v []token
+	_, err := u.ds.GetAll(ctx, datastore.NewQuery(kindToken).Filter("Hash =", hashToken(req.GetSecret())).Limit(1), &v)
+	cs.Finish()
+	if err != nil {
+		log.WithField("error", err).Error("failed to query the datastore")
+		return nil, errors.Wrap(err, "failed to query")
+	} else if len(v) == 0 {
+		log.Debug("unknown access token")
+		return &pb.UserResponse{Found: false}, nil
+	}
+
+	t := v[0]
+	if time.Since(t.LastUsed) > lastUsedPeriod {
+		t.LastUsed = time.Now()
+		if _, err := u.ds.Put(ctx, t.K, &t); err != nil {
+			// not fatal, the token is still valid
+			log.WithField("error", err).Warn("failed to update token last used time")
+		}
+	}
+	log.WithFields(logrus.Fields{
+		"user.id":  t.UserID,
+		"token.id": t.K.ID}).Debug("authenticated access token")
+	return u.GetUser(ctx, &pb.UserRequest{ID: t.UserID})
+}
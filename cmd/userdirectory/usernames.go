@@ -0,0 +1,124 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"cloud.google.com/go/datastore"
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/trace"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const kindUsername = "Username" // datastore kind
+
+var (
+	// usernamePattern does not allow all digits, so that usernames cannot be
+	// mistaken for user IDs.
+	usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,30}$`)
+	digitsPattern   = regexp.MustCompile(`^[0-9]+$`)
+
+	// reservedUsernames cannot be taken by users, as they could be mistaken
+	// for the service or its staff.
+	reservedUsernames = map[string]bool{
+		"about": true, "abuse": true, "account": true, "admin": true,
+		"administrator": true, "api": true, "coffee": true, "coffeelog": true,
+		"help": true, "login": true, "logout": true, "me": true,
+		"moderator": true, "null": true, "official": true, "postmaster": true,
+		"root": true, "security": true, "settings": true, "staff": true,
+		"support": true, "system": true, "undefined": true, "webmaster": true,
+	}
+
+	errUsernameTaken = status.Error(codes.AlreadyExists, "username is already taken")
+)
+
+// username reserves a username for an account. The key name is the
+// lowercased username, which makes usernames unique ignoring case.
+type username struct {
+	K      *datastore.Key `datastore:"__key__"`
+	UserID string         `datastore:"UserID,noindex"`
+}
+
+func usernameKey(name string) *datastore.Key {
+	return datastore.NameKey(kindUsername, strings.ToLower(name), nil)
+}
+
+func validateUsername(name string) error {
+	if !usernamePattern.MatchString(name) {
+		return errors.New("username must be 3 to 30 letters, digits or underscores")
+	} else if digitsPattern.MatchString(name) {
+		return errors.New("username cannot be only digits")
+	} else if reservedUsernames[strings.ToLower(name)] {
+		return errors.Errorf("username %q is reserved", name)
+	}
+	return nil
+}
+
+// claimUsername moves the username reservation of the user from old to name
+// in the transaction. Either can be empty.
+func claimUsername(tx *datastore.Transaction, userID, old, name string) error {
+	if strings.EqualFold(old, name) {
+		return nil
+	}
+	if name != "" {
+		k := usernameKey(name)
+		var v username
+		if err := tx.Get(k, &v); err == nil && v.UserID != userID {
+			return errUsernameTaken
+		} else if err != nil && err != datastore.ErrNoSuchEntity {
+			return err
+		}
+		if _, err := tx.Put(k, &username{UserID: userID}); err != nil {
+			return err
+		}
+	}
+	if old != "" {
+		return tx.Delete(usernameKey(old))
+	}
+	return nil
+}
+
+func (u *userDirectory) GetUserByUsername(ctx context.Context, req *pb.UsernameRequest) (*pb.UserResponse, error) {
+	span := trace.FromContext(ctx).NewChild("usersvc/GetUserByUsername")
+	defer span.Finish()
+
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"op":       "GetUserByUsername",
+		"username": req.GetUsername()})
+	log.Debug("received request")
+
+	if !usernamePattern.MatchString(req.GetUsername()) {
+		return &pb.UserResponse{Found: false}, nil
+	}
+	cs := span.NewChild("datastore/get/username")
+	var v username
+	err := u.ds.Get(ctx, usernameKey(req.GetUsername()), &v)
+	cs.Finish()
+	if err == datastore.ErrNoSuchEntity {
+		log.Debug("username not found")
+		return &pb.UserResponse{Found: false}, nil
+	} else if err != nil {
+		log.WithField("error", err).Error("failed to query the datastore")
+		return nil, errors.Wrap(err, "failed to query")
+	}
+	return u.GetUser(trace.NewContext(ctx, span), &pb.UserRequest{ID: v.UserID})
+}
@@ -0,0 +1,46 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateUsername(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "jane_doe"},
+		{name: "Jane99"},
+		{name: "abc"},
+		{name: strings.Repeat("a", 30)},
+		{name: "ab", wantErr: true},
+		{name: strings.Repeat("a", 31), wantErr: true},
+		{name: "jane.doe", wantErr: true},
+		{name: "jane doe", wantErr: true},
+		{name: "jané", wantErr: true},
+		{name: "12345", wantErr: true},
+		{name: "admin", wantErr: true},
+		{name: "Admin", wantErr: true},
+		{name: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := validateUsername(tt.name); (err != nil) != tt.wantErr {
+			t.Errorf("%q: got error %v, expected error: %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
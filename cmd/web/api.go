@@ -0,0 +1,649 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/rpcerror"
+	"github.com/ahmetb/coffeelog/trace"
+	"github.com/ahmetb/coffeelog/validation"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	apiPrefix = "/api/v1"
+
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// apiRoute describes a single endpoint of the JSON API. The same table is
+// used to register the handlers and to generate the OpenAPI document, so
+// the two cannot drift apart.
+type apiRoute struct {
+	method    string
+	path      string // mux path template, relative to apiPrefix
+	summary   string
+	auth      bool        // requires an authenticated user
+	paginated bool        // accepts page_size/page_token
+	query     []apiParam  // additional query parameters
+	body      interface{} // zero value of the request body, if any
+	response  interface{} // zero value of the response body, if any
+	status    int         // success status code
+	limiter   *limiter    // rate limits, if any
+	handler   http.HandlerFunc
+}
+
+type apiParam struct {
+	name, description string
+}
+
+// apiRoutes returns the endpoints of the v1 API.
+func (s *server) apiRoutes() []apiRoute {
+	return []apiRoute{
+		{method: http.MethodGet, path: "/me", summary: "Get the authenticated user",
+			auth: true, response: apiUser{}, handler: s.apiMe},
+		{method: http.MethodGet, path: "/users/{id:[0-9]+}", summary: "Get a user",
+			response: apiUser{}, handler: s.apiGetUser},
+		{method: http.MethodGet, path: "/users/{id:[0-9]+}/activities", summary: "List activities of a user",
+			paginated: true, response: apiActivityList{}, handler: s.apiListUserActivities},
+		{method: http.MethodGet, path: "/users/{id:[0-9]+}/stats", summary: "Get caffeine statistics of a user",
+			response: apiStats{}, limiter: s.statsLimiter, handler: s.apiUserStats},
+		{method: http.MethodPost, path: "/activities", summary: "Log a new activity",
+			auth: true, body: apiActivityInput{}, response: apiActivity{}, status: http.StatusCreated,
+			limiter: s.postLimiter, handler: s.apiPostActivity},
+		{method: http.MethodGet, path: "/activities/{id:[0-9]+}", summary: "Get an activity",
+			response: apiActivity{}, handler: s.apiGetActivity},
+		{method: http.MethodGet, path: "/roasters", summary: "List roasters",
+			paginated: true, query: []apiParam{{"q", "case-insensitive substring to filter roaster names"}},
+			response: apiRoasterList{}, limiter: s.roasterLimiter, handler: s.apiListRoasters},
+		{method: http.MethodGet, path: "/roasters/{id:[0-9]+}", summary: "Get a roaster",
+			response: apiRoaster{}, handler: s.apiGetRoaster},
+		{method: http.MethodGet, path: "/tokens", summary: "List personal access tokens",
+			auth: true, response: apiTokenList{}, handler: s.apiListTokens},
+		{method: http.MethodPost, path: "/tokens", summary: "Create a personal access token",
+			auth: true, body: apiTokenInput{}, response: apiNewToken{}, status: http.StatusCreated,
+			handler: s.apiCreateToken},
+		{method: http.MethodDelete, path: "/tokens/{id:[0-9]+}", summary: "Revoke a personal access token",
+			auth: true, status: http.StatusNoContent, handler: s.apiRevokeToken},
+	}
+}
+
+// registerAPI mounts the JSON API and its OpenAPI document on the router.
+func (s *server) registerAPI(r *mux.Router) {
+	api := r.PathPrefix(apiPrefix).Subrouter()
+	for _, rt := range s.apiRoutes() {
+		h := rt.handler
+		if rt.limiter != nil {
+			h = s.rateLimited(rt.limiter, h)
+		}
+		api.Handle(rt.path, s.traceHandler(logHandler(h))).Methods(rt.method)
+	}
+	api.Handle("/openapi.json", s.traceHandler(logHandler(s.apiOpenAPI))).Methods(http.MethodGet)
+	api.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiErrorCode(w, r, http.StatusNotFound, publicError(fmt.Sprintf("no such endpoint: %s %s", r.Method, r.URL.Path)))
+	})
+}
+
+// API representations of the resources. These are decoupled from the
+// protobuf messages so that the wire format of the API stays stable.
+type (
+	apiUser struct {
+		ID          string `json:"id"`
+		DisplayName string `json:"display_name"`
+		Username    string `json:"username,omitempty"`
+		Picture     string `json:"picture,omitempty"`
+		Bio         string `json:"bio,omitempty"`
+	}
+
+	apiAmount struct {
+		N    int32  `json:"n"`
+		Unit string `json:"unit" enum:"shots,oz"`
+	}
+
+	apiRoaster struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+
+	apiActivity struct {
+		ID         int64       `json:"id"`
+		User       *apiUser    `json:"user,omitempty"`
+		Homebrew   bool        `json:"homebrew"`
+		Drink      string      `json:"drink"`
+		Method     string      `json:"method,omitempty"`
+		Amount     *apiAmount  `json:"amount,omitempty"`
+		Roaster    *apiRoaster `json:"roaster,omitempty"`
+		Origin     string      `json:"origin,omitempty"`
+		Notes      string      `json:"notes,omitempty"`
+		PictureURL string      `json:"picture_url,omitempty"`
+		Date       time.Time   `json:"date"`
+		LogDate    time.Time   `json:"log_date"`
+		Visibility string      `json:"visibility,omitempty" enum:"public,followers,private"`
+	}
+
+	apiPicture struct {
+		Data        []byte `json:"data"`
+		Filename    string `json:"filename"`
+		ContentType string `json:"content_type"`
+	}
+
+	apiActivityInput struct {
+		Drink      string      `json:"drink"`
+		Homebrew   bool        `json:"homebrew"`
+		Method     string      `json:"method,omitempty"`
+		Amount     *apiAmount  `json:"amount,omitempty"`
+		Roaster    string      `json:"roaster,omitempty"`
+		Origin     string      `json:"origin,omitempty"`
+		Notes      string      `json:"notes,omitempty"`
+		Date       *time.Time  `json:"date,omitempty"`
+		Picture    *apiPicture `json:"picture,omitempty"`
+		Visibility string      `json:"visibility,omitempty" enum:"public,followers,private"`
+	}
+
+	apiActivityList struct {
+		Activities    []apiActivity `json:"activities"`
+		NextPageToken string        `json:"next_page_token,omitempty"`
+	}
+
+	apiRoasterList struct {
+		Roasters      []apiRoaster `json:"roasters"`
+		NextPageToken string       `json:"next_page_token,omitempty"`
+	}
+
+	apiStats struct {
+		UserID     string         `json:"user_id"`
+		Activities int            `json:"activities"`
+		Homebrew   int            `json:"homebrew"`
+		Shots      int64          `json:"shots"`
+		Ounces     int64          `json:"ounces"`
+		Drinks     map[string]int `json:"drinks"`
+		Methods    map[string]int `json:"methods"`
+		First      *time.Time     `json:"first,omitempty"`
+		Last       *time.Time     `json:"last,omitempty"`
+		Partial    bool           `json:"partial,omitempty"` // only the latest statsMaxActivities are counted
+	}
+
+	apiErrorBody struct {
+		Error apiErrorDetail `json:"error"`
+	}
+
+	apiErrorDetail struct {
+		Code    int               `json:"code"`
+		Status  string            `json:"status"`
+		Message string            `json:"message"`
+		Fields  validation.Errors `json:"fields,omitempty"`
+	}
+)
+
+var amountUnits = map[pb.Activity_DrinkAmount_CaffeineUnit]string{
+	pb.Activity_DrinkAmount_SHOTS:  "shots",
+	pb.Activity_DrinkAmount_OUNCES: "oz",
+}
+
+// visibilities are the names of the visibilities other than the default.
+var visibilities = map[pb.Visibility]string{
+	pb.Visibility_PUBLIC:    "public",
+	pb.Visibility_FOLLOWERS: "followers",
+	pb.Visibility_PRIVATE:   "private",
+}
+
+func toAPIUser(u *pb.User) *apiUser {
+	if u == nil {
+		return nil
+	}
+	return &apiUser{ID: u.GetID(), DisplayName: u.GetDisplayName(), Username: u.GetUsername(),
+		Picture: u.GetPicture(), Bio: u.GetBio()}
+}
+
+func toAPIActivity(a *pb.Activity) apiActivity {
+	v := apiActivity{
+		ID:         a.GetID(),
+		User:       toAPIUser(a.GetUser()),
+		Homebrew:   a.GetHomebrew(),
+		Drink:      a.GetDrink(),
+		Method:     a.GetMethod(),
+		Origin:     a.GetOrigin(),
+		Notes:      a.GetNotes(),
+		PictureURL: a.GetPictureURL(),
+		Visibility: visibilities[a.GetVisibility()],
+	}
+	if amt := a.GetAmount(); amt != nil && amt.GetUnit() != pb.Activity_DrinkAmount_UNSPECIFIED {
+		v.Amount = &apiAmount{N: amt.GetN(), Unit: amountUnits[amt.GetUnit()]}
+	}
+	if ro := a.GetRoaster(); ro.GetName() != "" {
+		v.Roaster = &apiRoaster{ID: ro.GetID(), Name: ro.GetName()}
+	}
+	if t, err := ptypes.Timestamp(a.GetDate()); err == nil {
+		v.Date = t
+	}
+	if t, err := ptypes.Timestamp(a.GetLogDate()); err == nil {
+		v.LogDate = t
+	}
+	return v
+}
+
+// apiViewer authenticates the request for an API handler that can also be
+// used anonymously, in which case the user is nil. If it returns false, an
+// error response has already been written.
+func (s *server) apiViewer(w http.ResponseWriter, r *http.Request) (*pb.User, bool) {
+	user, errF, err := s.authUser(r.Context(), r)
+	if err != nil {
+		errF(w, r, err) // responds with the JSON error envelope under the api prefix
+		return nil, false
+	}
+	return user, true
+}
+
+// apiAuthUser authenticates the request for an API handler. If it returns
+// false, an error response has already been written.
+func (s *server) apiAuthUser(w http.ResponseWriter, r *http.Request) (*pb.User, bool) {
+	user, ok := s.apiViewer(w, r)
+	if !ok {
+		return nil, false
+	}
+	if user == nil {
+		apiErrorCode(w, r, http.StatusUnauthorized, publicError("authentication required"))
+		return nil, false
+	}
+	return user, true
+}
+
+func (s *server) apiMe(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.apiAuthUser(w, r)
+	if !ok {
+		return
+	}
+	apiRespond(w, http.StatusOK, toAPIUser(user))
+}
+
+func (s *server) apiGetUser(w http.ResponseWriter, r *http.Request) {
+	me, ok := s.apiViewer(w, r)
+	if !ok {
+		return
+	}
+	user, found, err := s.visibleUser(r.Context(), mux.Vars(r)["id"], me.GetID())
+	if err != nil {
+		apiServerError(w, r, err)
+		return
+	} else if !found {
+		apiErrorCode(w, r, http.StatusNotFound, publicError("user not found"))
+		return
+	}
+	apiRespond(w, http.StatusOK, toAPIUser(user))
+}
+
+// visibleUser returns the user, or false if the user does not exist or the
+// viewer is not allowed to see their profile, which is the case when they
+// cannot list the activities of the user.
+func (s *server) visibleUser(ctx context.Context, userID, viewerID string) (*pb.User, bool, error) {
+	userResp, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to look up the user")
+	} else if !userResp.GetFound() {
+		return nil, false, nil
+	} else if userID == viewerID {
+		return userResp.GetUser(), true, nil
+	}
+
+	cs := trace.FromContext(ctx).NewChild("check_visibility")
+	defer cs.Finish()
+	cs.SetLabel("user/id", userID)
+	_, err = s.activitySvc.GetUserActivities(ctx, &pb.UserActivitiesRequest{
+		UserID:   userID,
+		ViewerID: viewerID,
+		PageSize: 1})
+	if grpc.Code(err) == codes.NotFound {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, errors.Wrap(err, "failed to check the visibility of the user")
+	}
+	return userResp.GetUser(), true, nil
+}
+
+// userActivities returns the page of the activities of a user the viewer
+// can see, or false if the user does not exist or is not visible to the
+// viewer. Without a page size all of the activities are returned.
+func (s *server) userActivities(ctx context.Context, userID, viewerID string, pg page) (*pb.UserActivitiesResponse, bool, error) {
+	userResp, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to look up the user")
+	} else if !userResp.GetFound() {
+		return nil, false, nil
+	}
+
+	cs := trace.FromContext(ctx).NewChild("get_activities")
+	defer cs.Finish()
+	cs.SetLabel("user/id", userID)
+	ar, err := s.activitySvc.GetUserActivities(ctx, &pb.UserActivitiesRequest{
+		UserID:    userID,
+		ViewerID:  viewerID,
+		PageSize:  pg.size,
+		PageToken: pg.token})
+	if grpc.Code(err) == codes.NotFound {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, true, errors.Wrap(err, "failed to query activities")
+	}
+	return ar, true, nil
+}
+
+func (s *server) apiListUserActivities(w http.ResponseWriter, r *http.Request) {
+	pg, err := parsePage(r)
+	if err != nil {
+		apiErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+	me, ok := s.apiViewer(w, r)
+	if !ok {
+		return
+	}
+	ar, found, err := s.userActivities(r.Context(), mux.Vars(r)["id"], me.GetID(), pg)
+	if err != nil {
+		apiServerError(w, r, err)
+		return
+	} else if !found {
+		apiErrorCode(w, r, http.StatusNotFound, publicError("user not found"))
+		return
+	}
+
+	resp := apiActivityList{Activities: []apiActivity{}, NextPageToken: ar.GetNextPageToken()}
+	for _, a := range ar.GetActivities() {
+		resp.Activities = append(resp.Activities, toAPIActivity(a))
+	}
+	apiRespond(w, http.StatusOK, resp)
+}
+
+const (
+	// statsMaxActivities is the most activities the statistics of a user are
+	// computed from, read statsPageSize at a time.
+	statsMaxActivities = 1000
+	statsPageSize      = 100
+)
+
+func (s *server) apiUserStats(w http.ResponseWriter, r *http.Request) {
+	me, ok := s.apiViewer(w, r)
+	if !ok {
+		return
+	}
+	userID := mux.Vars(r)["id"]
+	var (
+		activities []*pb.Activity
+		pg         = page{size: statsPageSize}
+		partial    bool
+	)
+	for {
+		ar, found, err := s.userActivities(r.Context(), userID, me.GetID(), pg)
+		if err != nil {
+			apiServerError(w, r, err)
+			return
+		} else if !found {
+			apiErrorCode(w, r, http.StatusNotFound, publicError("user not found"))
+			return
+		}
+		activities = append(activities, ar.GetActivities()...)
+		if pg.token = ar.GetNextPageToken(); pg.token == "" {
+			break
+		} else if len(activities) >= statsMaxActivities {
+			partial = true
+			break
+		}
+	}
+
+	st := apiStats{
+		UserID:     userID,
+		Activities: len(activities),
+		Drinks:     make(map[string]int),
+		Methods:    make(map[string]int),
+		Partial:    partial}
+	for _, a := range activities {
+		v := toAPIActivity(a)
+		if v.Homebrew {
+			st.Homebrew++
+		}
+		if v.Drink != "" {
+			st.Drinks[v.Drink]++
+		}
+		if v.Method != "" {
+			st.Methods[v.Method]++
+		}
+		switch a.GetAmount().GetUnit() {
+		case pb.Activity_DrinkAmount_SHOTS:
+			st.Shots += int64(a.GetAmount().GetN())
+		case pb.Activity_DrinkAmount_OUNCES:
+			st.Ounces += int64(a.GetAmount().GetN())
+		}
+		if d := v.Date; !d.IsZero() {
+			if st.First == nil || d.Before(*st.First) {
+				st.First = &d
+			}
+			if st.Last == nil || d.After(*st.Last) {
+				st.Last = &d
+			}
+		}
+	}
+	apiRespond(w, http.StatusOK, st)
+}
+
+func (s *server) apiGetActivity(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apiErrorCode(w, r, http.StatusBadRequest, publicWrap(err, "bad activity id"))
+		return
+	}
+	me, ok := s.apiViewer(w, r)
+	if !ok {
+		return
+	}
+	a, err := s.activitySvc.GetActivity(r.Context(), &pb.ActivityRequest{ID: id, ViewerID: me.GetID()})
+	if grpc.Code(err) == codes.NotFound {
+		apiErrorCode(w, r, http.StatusNotFound, publicError("activity not found"))
+		return
+	} else if err != nil {
+		apiServerError(w, r, errors.Wrap(err, "cannot get activity"))
+		return
+	}
+	apiRespond(w, http.StatusOK, toAPIActivity(a))
+}
+
+func (s *server) apiPostActivity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logging.FromContext(ctx)
+	user, ok := s.apiAuthUser(w, r)
+	if !ok {
+		return
+	}
+
+	var in apiActivityInput
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 16*1024*1024)).Decode(&in); err != nil {
+		apiErrorCode(w, r, http.StatusBadRequest, publicWrap(err, "failed to parse request body"))
+		return
+	}
+	date := time.Now()
+	if in.Date != nil {
+		date = *in.Date
+	}
+	ts, err := ptypes.TimestampProto(date)
+	if err != nil {
+		apiErrorCode(w, r, http.StatusBadRequest, publicWrap(err, "invalid date"))
+		return
+	}
+
+	errs := make(validation.Errors)
+	req := &pb.PostActivityRequest{
+		UserID:      user.GetID(),
+		Date:        ts,
+		Drink:       in.Drink,
+		Homebrew:    in.Homebrew,
+		Method:      in.Method,
+		RoasterName: in.Roaster,
+		Origin:      in.Origin,
+		Notes:       in.Notes,
+	}
+	if in.Amount != nil {
+		req.Amount = &pb.Activity_DrinkAmount{N: in.Amount.N}
+		switch in.Amount.Unit {
+		case "shots":
+			req.Amount.Unit = pb.Activity_DrinkAmount_SHOTS
+		case "oz":
+			req.Amount.Unit = pb.Activity_DrinkAmount_OUNCES
+		default:
+			errs.Add("amount", "unknown amount unit %q", in.Amount.Unit)
+		}
+	}
+	if in.Visibility != "" {
+		for v, name := range visibilities {
+			if name == in.Visibility {
+				req.Visibility = v
+			}
+		}
+		if req.Visibility == pb.Visibility_DEFAULT {
+			errs.Add("visibility", "unknown visibility %q", in.Visibility)
+		}
+	}
+	if p := in.Picture; p != nil && len(p.Data) > 0 {
+		req.Picture = &pb.PostActivityRequest_File{
+			Data:        p.Data,
+			Filename:    p.Filename,
+			ContentType: p.ContentType}
+	}
+	if validation.Activity(req, errs); len(errs) > 0 {
+		apiErrorCode(w, r, http.StatusBadRequest, errs)
+		return
+	}
+
+	resp, err := s.activitySvc.PostActivity(ctx, req)
+	if err != nil {
+		apiServerError(w, r, errors.Wrap(err, "failed to save activity"))
+		return
+	}
+	log.WithFields(logrus.Fields{
+		"id":      resp.GetID(),
+		"user.id": user.GetID()}).Info("activity posted through api")
+
+	a, err := s.activitySvc.GetActivity(ctx, &pb.ActivityRequest{ID: resp.GetID(), ViewerID: user.GetID()})
+	if err != nil {
+		apiServerError(w, r, errors.Wrap(err, "cannot get saved activity"))
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("%s/activities/%d", apiPrefix, resp.GetID()))
+	apiRespond(w, http.StatusCreated, toAPIActivity(a))
+}
+
+func (s *server) apiListRoasters(w http.ResponseWriter, r *http.Request) {
+	pg, err := parsePage(r)
+	if err != nil {
+		apiErrorCode(w, r, http.StatusBadRequest, err)
+		return
+	}
+	q := r.URL.Query().Get("q")
+	if len(q) > 100 {
+		apiErrorCode(w, r, http.StatusBadRequest, publicError("query too long"))
+		return
+	}
+
+	resp, err := s.roasterSvc.ListRoasters(r.Context(), &pb.RoastersRequest{
+		PageSize:  pg.size,
+		PageToken: pg.token,
+		Query:     q})
+	if err != nil {
+		apiServerError(w, r, errors.Wrap(err, "failed to query the roasters"))
+		return
+	}
+	out := apiRoasterList{Roasters: []apiRoaster{}, NextPageToken: resp.GetNextPageToken()}
+	for _, ro := range resp.GetResults() {
+		out.Roasters = append(out.Roasters, apiRoaster{ID: ro.GetID(), Name: ro.GetName()})
+	}
+	apiRespond(w, http.StatusOK, out)
+}
+
+func (s *server) apiGetRoaster(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		apiErrorCode(w, r, http.StatusBadRequest, publicWrap(err, "bad roaster id"))
+		return
+	}
+	resp, err := s.roasterSvc.GetRoaster(r.Context(), &pb.RoasterRequest{Query: &pb.RoasterRequest_ID{ID: id}})
+	if err != nil {
+		apiServerError(w, r, errors.Wrap(err, "failed to query the roaster"))
+		return
+	} else if !resp.GetFound() {
+		apiErrorCode(w, r, http.StatusNotFound, publicError("roaster not found"))
+		return
+	}
+	apiRespond(w, http.StatusOK, apiRoaster{ID: resp.GetRoaster().GetID(), Name: resp.GetRoaster().GetName()})
+}
+
+// page is a parsed pagination request. Page tokens are opaque to clients;
+// they are the datastore cursors the directories return.
+type page struct {
+	size  int32
+	token string
+}
+
+func parsePage(r *http.Request) (page, error) {
+	p := page{size: defaultPageSize, token: r.URL.Query().Get("page_token")}
+	if v := r.URL.Query().Get("page_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return p, publicError("page_size must be a positive integer")
+		}
+		if n > maxPageSize {
+			n = maxPageSize
+		}
+		p.size = int32(n)
+	}
+	return p, nil
+}
+
+func apiRespond(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.WithField("error", err).Warn("failed to encode api response")
+	}
+}
+
+// apiErrorCode logs the error and writes it in the JSON error envelope of the
+// API, with only the message the user can be shown.
+func apiErrorCode(w http.ResponseWriter, r *http.Request, code int, err error) {
+	logging.FromContext(r.Context()).WithField("http.status", code).WithField("error", err).Warn("api error")
+	fields, _ := errors.Cause(err).(validation.Errors)
+	apiRespond(w, code, apiErrorBody{Error: apiErrorDetail{
+		Code:    code,
+		Status:  strings.ToUpper(strings.Replace(http.StatusText(code), " ", "_", -1)),
+		Message: publicMessage(code, err),
+		Fields:  fields}})
+}
+
+// apiServerError writes the error of a failed call to a backend with the
+// HTTP status of its status code, 500 if it has none.
+func apiServerError(w http.ResponseWriter, r *http.Request, err error) {
+	apiErrorCode(w, r, rpcerror.HTTPStatus(rpcerror.Code(err)), err)
+}
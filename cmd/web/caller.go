@@ -0,0 +1,94 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/ahmetb/coffeelog/caller"
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/logging"
+	"google.golang.org/grpc"
+)
+
+// callerSlot holds the user the request is authenticated as. The handlers
+// authenticate the user after their context is created, so the slot is put
+// in the context first and filled in by authSession and authToken. It also
+// keeps the user authUser found, so that the rate limits and the handler do
+// not both look it up.
+type callerSlot struct {
+	mu     sync.Mutex
+	userID string
+	user   *pb.User
+}
+
+type callerSlotKey struct{}
+
+// withCallerSlot adds an empty callerSlot to the context of the requests.
+func withCallerSlot(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), callerSlotKey{}, &callerSlot{})))
+	})
+}
+
+// setCaller records that the request is made by the user, so that the RPCs
+// made with ctx are made on behalf of the user and it is logged with them.
+func setCaller(ctx context.Context, userID string) {
+	logging.AddField(ctx, logging.UserIDField, userID)
+	if s, ok := ctx.Value(callerSlotKey{}).(*callerSlot); ok {
+		s.mu.Lock()
+		s.userID = userID
+		s.mu.Unlock()
+	}
+}
+
+// cacheUser keeps the user the request is authenticated as for authUser.
+func cacheUser(ctx context.Context, user *pb.User) {
+	if s, ok := ctx.Value(callerSlotKey{}).(*callerSlot); ok {
+		s.mu.Lock()
+		s.user = user
+		s.mu.Unlock()
+	}
+}
+
+// cachedUser returns the user kept by cacheUser, nil if there is none.
+func cachedUser(ctx context.Context) *pb.User {
+	if s, ok := ctx.Value(callerSlotKey{}).(*callerSlot); ok {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.user
+	}
+	return nil
+}
+
+// callerInterceptor attaches the identity token of the user the request is
+// authenticated as to the RPCs, unless the RPC is made on behalf of another
+// user given with caller.NewContext, such as the user logging in.
+func callerInterceptor(keys caller.Keys) grpc.UnaryClientInterceptor {
+	attach := caller.UnaryClientInterceptor(keys)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if s, ok := ctx.Value(callerSlotKey{}).(*callerSlot); ok && caller.FromContext(ctx) == "" {
+			s.mu.Lock()
+			userID := s.userID
+			s.mu.Unlock()
+			if userID != "" {
+				ctx = caller.NewContext(ctx, userID)
+			}
+		}
+		return attach(ctx, method, req, reply, cc, invoker, opts...)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// proxies are the load balancers and proxies in front of the server, whose
+// X-Forwarded-For header is trusted.
+var proxies []*net.IPNet
+
+// parseProxies parses a comma-separated list of IP addresses and CIDR ranges.
+func parseProxies(v string) ([]*net.IPNet, error) {
+	var out []*net.IPNet
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		cidr := s
+		if !strings.Contains(s, "/") {
+			if strings.Contains(s, ":") {
+				cidr += "/128"
+			} else {
+				cidr += "/32"
+			}
+		}
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errors.Errorf("invalid proxy address or range %q", s)
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+func isProxy(ip net.IP) bool {
+	for _, n := range proxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// fromProxy reports whether the connection comes from one of the proxies,
+// whose X-Forwarded-* headers are then trusted.
+func fromProxy(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && isProxy(ip)
+}
+
+// clientIP returns the address of the client. When the connection comes from
+// one of the proxies, it is the right-most address in X-Forwarded-For that is
+// not a proxy: the proxies append the address they got the request from, so
+// the entries left of it could have been made up by the client.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !fromProxy(r) {
+		return host
+	}
+	var hops []string
+	for _, v := range r.Header["X-Forwarded-For"] {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break // not written by a proxy
+		} else if !isProxy(ip) {
+			return ip.String()
+		}
+	}
+	return host
+}
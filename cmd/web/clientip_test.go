@@ -0,0 +1,110 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	var err error
+	if proxies, err = parseProxies("10.0.0.0/8, 35.191.0.0/16,2001:db8::1"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { proxies = nil }()
+
+	tests := []struct {
+		name   string
+		remote string
+		xff    []string
+		want   string
+	}{
+		{name: "direct connection",
+			remote: "203.0.113.7:5555", want: "203.0.113.7"},
+		{name: "forwarded header from a client",
+			remote: "203.0.113.7:5555", xff: []string{"198.51.100.1"}, want: "203.0.113.7"},
+		{name: "behind a proxy",
+			remote: "10.1.2.3:5555", xff: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		{name: "spoofed entries left of the client",
+			remote: "10.1.2.3:5555", xff: []string{"1.2.3.4, 198.51.100.1"}, want: "198.51.100.1"},
+		{name: "chain of proxies",
+			remote: "10.1.2.3:5555", xff: []string{"1.2.3.4, 198.51.100.1, 35.191.4.5", "10.9.9.9"}, want: "198.51.100.1"},
+		{name: "ipv6 proxy",
+			remote: "[2001:db8::1]:5555", xff: []string{"2001:db8::2"}, want: "2001:db8::2"},
+		{name: "only proxies",
+			remote: "10.1.2.3:5555", xff: []string{"10.4.5.6"}, want: "10.1.2.3"},
+		{name: "malformed entry",
+			remote: "10.1.2.3:5555", xff: []string{"198.51.100.1, bogus"}, want: "10.1.2.3"},
+		{name: "behind a proxy without the header",
+			remote: "10.1.2.3:5555", want: "10.1.2.3"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tt.remote
+		for _, v := range tt.xff {
+			r.Header.Add("X-Forwarded-For", v)
+		}
+		if got := clientIP(r); got != tt.want {
+			t.Errorf("%s: got %s, expected %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseProxies(t *testing.T) {
+	for _, v := range []string{"10.0.0.0/33", "not-an-ip", "10.0.0.1,,fe80::/10x"} {
+		if _, err := parseProxies(v); err == nil {
+			t.Errorf("expected an error for %q", v)
+		}
+	}
+	if p, err := parseProxies(""); err != nil || len(p) != 0 {
+		t.Errorf("empty list: got %v, %v", p, err)
+	}
+}
+
+func TestIsHTTPS(t *testing.T) {
+	var err error
+	if proxies, err = parseProxies("10.0.0.0/8"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { proxies = nil }()
+
+	tests := []struct {
+		name   string
+		remote string
+		proto  string
+		want   bool
+	}{
+		{name: "plain connection",
+			remote: "203.0.113.7:5555", want: false},
+		{name: "forwarded header from a client",
+			remote: "203.0.113.7:5555", proto: "https", want: false},
+		{name: "behind a proxy over https",
+			remote: "10.1.2.3:5555", proto: "https", want: true},
+		{name: "behind a proxy over http",
+			remote: "10.1.2.3:5555", proto: "http", want: false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tt.remote
+		if tt.proto != "" {
+			r.Header.Set("X-Forwarded-Proto", tt.proto)
+		}
+		if got := isHTTPS(r); got != tt.want {
+			t.Errorf("%s: got %v, expected %v", tt.name, got, tt.want)
+		}
+	}
+}
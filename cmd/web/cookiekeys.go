@@ -0,0 +1,124 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gorilla/securecookie"
+	"github.com/pkg/errors"
+)
+
+const cookieKeysEnv = "COOKIE_KEYS"
+
+var (
+	// insecure keys only allowed in -dev mode
+	devHashKey  = []byte("very-secret")
+	devBlockKey = []byte("a-lot-secret-key")
+
+	// cookieCodecs are used to sign and encrypt cookies. The first codec is
+	// used for encoding, all of them are tried for decoding so that the keys
+	// can be rotated without invalidating the existing cookies.
+	cookieCodecs []securecookie.Codec
+)
+
+// loadCookieCodecs reads the cookie key pairs from the file at path, or from
+// the COOKIE_KEYS environment variable if path is empty.
+//
+// Each non-empty line that does not start with "#" holds a base64-encoded
+// hash key (32 or 64 bytes) and block key (16, 24 or 32 bytes) separated by
+// whitespace. The first pair is the current one, the rest are only accepted
+// when decoding. To rotate the keys, prepend a new pair and remove the oldest
+// one once the cookies signed with it have expired.
+func loadCookieCodecs(path string, dev bool) ([]securecookie.Codec, error) {
+	var (
+		pairs [][]byte
+		err   error
+	)
+	if path != "" {
+		f, ferr := os.Open(path)
+		if ferr != nil {
+			return nil, errors.Wrap(ferr, "failed to open cookie keys file")
+		}
+		defer f.Close()
+		pairs, err = parseCookieKeys(f)
+	} else if v := os.Getenv(cookieKeysEnv); v != "" {
+		pairs, err = parseCookieKeys(strings.NewReader(v))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(pairs) == 0 {
+		if !dev {
+			return nil, errors.Errorf("no cookie keys configured (use -cookie-keys-file or %s), refusing to use the insecure default keys outside -dev mode", cookieKeysEnv)
+		}
+		log.Warn("using insecure default cookie keys, do not use -dev mode in production")
+		pairs = [][]byte{devHashKey, devBlockKey}
+	}
+
+	codecs := securecookie.CodecsFromPairs(pairs...)
+	for _, c := range codecs {
+		c.(*securecookie.SecureCookie).SetSerializer(securecookie.JSONEncoder{})
+	}
+	return codecs, nil
+}
+
+func parseCookieKeys(r io.Reader) ([][]byte, error) {
+	var pairs [][]byte
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		f := strings.Fields(line)
+		if len(f) != 2 {
+			return nil, errors.Errorf("cookie keys line %d: expected a hash key and a block key", n)
+		}
+		hashKey, err := base64.StdEncoding.DecodeString(f[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "cookie keys line %d: invalid hash key", n)
+		} else if l := len(hashKey); l != 32 && l != 64 {
+			return nil, errors.Errorf("cookie keys line %d: hash key must be 32 or 64 bytes, got %d", n, l)
+		}
+		blockKey, err := base64.StdEncoding.DecodeString(f[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "cookie keys line %d: invalid block key", n)
+		} else if l := len(blockKey); l != 16 && l != 24 && l != 32 {
+			return nil, errors.Errorf("cookie keys line %d: block key must be 16, 24 or 32 bytes, got %d", n, l)
+		}
+		pairs = append(pairs, hashKey, blockKey)
+	}
+	return pairs, errors.Wrap(sc.Err(), "failed to read cookie keys")
+}
+
+// setCookieMaxAge sets the maximum age of the cookies accepted by the codecs.
+func setCookieMaxAge(seconds int) {
+	for _, c := range cookieCodecs {
+		c.(*securecookie.SecureCookie).MaxAge(seconds)
+	}
+}
+
+func encodeCookie(name string, value interface{}) (string, error) {
+	return securecookie.EncodeMulti(name, value, cookieCodecs...)
+}
+
+func decodeCookie(name, value string, dst interface{}) error {
+	return securecookie.DecodeMulti(name, value, dst, cookieCodecs...)
+}
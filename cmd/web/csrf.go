@@ -0,0 +1,163 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	csrfCookie    = "csrf"
+	csrfFormField = "csrf_token"
+	csrfHeader    = "X-CSRF-Token"
+	csrfSecretLen = 32
+)
+
+type csrfContextKey struct{}
+
+// csrfProtect rejects state-changing requests that do not carry the token
+// matching the secret in the CSRF cookie (synchronizer token pattern).
+// Requests authenticated with a bearer token are not exposed to CSRF, as the
+// browser does not attach the token by itself, so they are let through.
+//
+// The token of the request is available to templates as {{ csrfField }}, and
+// to scripts in the X-CSRF-Token response header.
+func csrfProtect(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret, err := csrfSecret(r)
+		if err != nil || secret == nil {
+			if secret, err = newCSRFSecret(w, r); err != nil {
+				serverError(w, r, err)
+				return
+			}
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		default:
+			if bearerToken(r) != "" {
+				break
+			}
+			if err := verifyCSRF(r, secret); err != nil {
+				log.WithField("path", r.URL.Path).Warn("rejected request without valid csrf token")
+				if strings.HasPrefix(r.URL.Path, apiPrefix+"/") {
+					apiErrorCode(w, r, http.StatusForbidden, err)
+				} else {
+					errorCode(w, r, http.StatusForbidden, "forbidden", err)
+				}
+				return
+			}
+		}
+
+		tok := maskCSRFToken(secret)
+		w.Header().Set(csrfHeader, tok)
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), csrfContextKey{}, tok)))
+	})
+}
+
+func csrfSecret(r *http.Request) ([]byte, error) {
+	c, err := r.Cookie(csrfCookie)
+	if err == http.ErrNoCookie {
+		return nil, nil
+	}
+	var v []byte
+	if err := decodeCookie(csrfCookie, c.Value, &v); err != nil {
+		return nil, errors.Wrap(err, "failed to decode csrf cookie")
+	} else if len(v) != csrfSecretLen {
+		return nil, errors.New("invalid csrf secret")
+	}
+	return v, nil
+}
+
+func newCSRFSecret(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	v := make([]byte, csrfSecretLen)
+	if _, err := rand.Read(v); err != nil {
+		return nil, errors.Wrap(err, "failed to generate csrf secret")
+	}
+	co, err := encodeCookie(csrfCookie, v)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode csrf cookie")
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookie,
+		Path:     "/",
+		Value:    co,
+		Secure:   isHTTPS(r),
+		HttpOnly: true,
+	})
+	return v, nil
+}
+
+// maskCSRFToken returns the secret XORed with a random pad, prefixed with the
+// pad, so that the token in the page changes on every response (BREACH).
+func maskCSRFToken(secret []byte) string {
+	b := make([]byte, 2*len(secret))
+	if _, err := rand.Read(b[:len(secret)]); err != nil {
+		panic(err) // crypto/rand does not fail on supported platforms
+	}
+	for i := range secret {
+		b[len(secret)+i] = b[i] ^ secret[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func verifyCSRF(r *http.Request, secret []byte) error {
+	tok := r.Header.Get(csrfHeader)
+	if tok == "" {
+		tok = r.FormValue(csrfFormField)
+	}
+	if tok == "" {
+		return publicError("missing csrf token")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(tok)
+	if err != nil || len(b) != 2*len(secret) {
+		return publicError("malformed csrf token")
+	}
+	v := make([]byte, len(secret))
+	for i := range v {
+		v[i] = b[i] ^ b[len(secret)+i]
+	}
+	if subtle.ConstantTimeCompare(v, secret) != 1 {
+		return publicError("invalid csrf token")
+	}
+	return nil
+}
+
+// pageTemplate parses the page template with the layout, making the CSRF
+// token of the request available to the forms, along with the helpers to
+// show amounts and times the way the user prefers.
+func pageTemplate(r *http.Request, page string) (*template.Template, error) {
+	tok, _ := r.Context().Value(csrfContextKey{}).(string)
+	return template.New("layout.html").Funcs(template.FuncMap{
+		"csrfField": func() template.HTML {
+			return template.HTML(`<input type="hidden" name="` + csrfFormField + `" value="` + template.HTMLEscapeString(tok) + `"/>`)
+		},
+		"csrfToken":  func() string { return tok },
+		"amount":     formatAmount,
+		"localTime":  localTime,
+		"profileURL": profileURL,
+	}).ParseFiles(
+		filepath.Join("static", "template", "layout.html"),
+		filepath.Join("static", "template", page))
+}
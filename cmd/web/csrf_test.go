@@ -0,0 +1,139 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// csrfClient is a browser with its own CSRF cookie and token.
+type csrfClient struct {
+	cookie *http.Cookie
+	token  string
+}
+
+// newCSRFClient makes a GET request to get a CSRF cookie and token.
+func newCSRFClient(t *testing.T, h http.Handler) csrfClient {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	var c csrfClient
+	for _, co := range w.Result().Cookies() {
+		if co.Name == csrfCookie {
+			c.cookie = co
+		}
+	}
+	if c.cookie == nil {
+		t.Fatal("no csrf cookie set")
+	}
+	if c.token = w.Header().Get(csrfHeader); c.token == "" {
+		t.Fatal("no csrf token in the response")
+	}
+	return c
+}
+
+func TestCSRFProtect(t *testing.T) {
+	h := csrfProtect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	victim := newCSRFClient(t, h)
+	attacker := newCSRFClient(t, h)
+
+	tests := []struct {
+		name   string
+		cookie *http.Cookie
+		form   url.Values
+		header http.Header
+		want   int
+	}{
+		{name: "token in form",
+			cookie: victim.cookie, form: url.Values{csrfFormField: {victim.token}},
+			want: http.StatusNoContent},
+		{name: "token in header",
+			cookie: victim.cookie, header: http.Header{csrfHeader: {victim.token}},
+			want: http.StatusNoContent},
+		{name: "other token of the same cookie",
+			cookie: victim.cookie, form: url.Values{csrfFormField: {maskCSRFToken(mustCSRFSecret(t, victim.cookie))}},
+			want: http.StatusNoContent},
+		{name: "missing token",
+			cookie: victim.cookie,
+			want:   http.StatusForbidden},
+		{name: "token of another browser",
+			cookie: victim.cookie, form: url.Values{csrfFormField: {attacker.token}},
+			want: http.StatusForbidden},
+		{name: "token without the cookie",
+			form: url.Values{csrfFormField: {victim.token}},
+			want: http.StatusForbidden},
+		{name: "malformed token",
+			cookie: victim.cookie, form: url.Values{csrfFormField: {"not-a-token"}},
+			want: http.StatusForbidden},
+		{name: "bearer token",
+			header: http.Header{"Authorization": {"Bearer clt_x"}},
+			want:   http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/coffee", strings.NewReader(tt.form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		for k, v := range tt.header {
+			r.Header.Set(k, v[0])
+		}
+		if tt.cookie != nil {
+			r.AddCookie(tt.cookie)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("%s: got status %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
+
+func TestCSRFProtectAPI(t *testing.T) {
+	h := csrfProtect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	c := newCSRFClient(t, h)
+
+	r := httptest.NewRequest(http.MethodPost, apiPrefix+"/activities", strings.NewReader(`{}`))
+	r.AddCookie(c.cookie)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("got content type %q, want the json error envelope", ct)
+	}
+}
+
+func TestCSRFTokenMasked(t *testing.T) {
+	secret := make([]byte, csrfSecretLen)
+	if a, b := maskCSRFToken(secret), maskCSRFToken(secret); a == b {
+		t.Errorf("tokens of the same secret are the same: %s", a)
+	}
+}
+
+func mustCSRFSecret(t *testing.T, c *http.Cookie) []byte {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(c)
+	v, err := csrfSecret(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
@@ -0,0 +1,149 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"time"
+
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+const (
+	// reauthMaxAge is how recently the user must have logged in to delete
+	// the account, so that an unattended browser cannot be used for it.
+	reauthMaxAge = 10 * time.Minute
+
+	// deletionCookie holds the ID of the deletion job the browser requested,
+	// as the status of a job is only shown to the browser that requested it.
+	deletionCookie       = "deletion"
+	deletionCookieMaxAge = 30 * 24 * time.Hour
+)
+
+// recentSession returns the user of the request if the session was started
+// within reauthMaxAge, or nil if the user has to log in again.
+func (s *server) recentSession(w http.ResponseWriter, r *http.Request) (*pb.User, bool) {
+	if bearerToken(r) != "" {
+		errorCode(w, r, http.StatusForbidden, "forbidden", publicError("access tokens cannot be used to delete the account"))
+		return nil, false
+	}
+	sess, errF, err := s.authSession(r.Context(), r)
+	if err != nil {
+		errF(w, r, err)
+		return nil, false
+	} else if sess == nil {
+		unauthorized(w, r, publicError("required user to log in"))
+		return nil, false
+	}
+	created, err := ptypes.Timestamp(sess.GetSession().GetCreated())
+	if err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to parse session timestamp"))
+		return nil, false
+	}
+	return sess.GetUser(), time.Since(created) < reauthMaxAge
+}
+
+func (s *server) deleteAccountForm(w http.ResponseWriter, r *http.Request) {
+	user, recent := s.recentSession(w, r)
+	if user == nil {
+		return
+	}
+	render(w, r, http.StatusOK, "delete.html", map[string]interface{}{
+		"me":     user,
+		"reauth": !recent})
+}
+
+func (s *server) deleteAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logging.FromContext(ctx)
+	user, recent := s.recentSession(w, r)
+	if user == nil {
+		return
+	} else if !recent {
+		render(w, r, http.StatusForbidden, "delete.html", map[string]interface{}{
+			"me":     user,
+			"reauth": true})
+		return
+	}
+	if r.PostFormValue("confirm") != "on" {
+		render(w, r, http.StatusBadRequest, "delete.html", map[string]interface{}{
+			"me":    user,
+			"error": "Check the box to confirm that you want to delete your account."})
+		return
+	}
+
+	d, err := s.userSvc.DeleteUser(ctx, &pb.DeleteUserRequest{UserID: user.GetID()})
+	if err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to delete account"))
+		return
+	}
+	log.WithField("user.id", user.GetID()).Info("account deletion requested")
+	co, err := encodeCookie(deletionCookie, d.GetID())
+	if err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to encode the deletion cookie"))
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     deletionCookie,
+		Path:     "/deletion/",
+		Value:    co,
+		MaxAge:   int(deletionCookieMaxAge.Seconds()),
+		Secure:   isHTTPS(r),
+		HttpOnly: true,
+	})
+	// the session is no longer valid, as the account is not found anymore
+	clearSessionCookie(w, r)
+	w.Header().Set("Location", "/deletion/"+d.GetID())
+	w.WriteHeader(http.StatusSeeOther)
+}
+
+// deletionStatus shows the progress of an account deletion. The user is
+// logged out by then, so the job is only shown to the browser carrying the
+// deletion cookie set when it was requested.
+func (s *server) deletionStatus(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	var requestedID string
+	if c, err := r.Cookie(deletionCookie); err == nil {
+		if err := decodeCookie(deletionCookie, c.Value, &requestedID); err != nil {
+			logging.FromContext(r.Context()).WithField("error", err).Debug("failed to decode the deletion cookie")
+		}
+	}
+	if requestedID == "" || requestedID != id {
+		errorCode(w, r, http.StatusNotFound, "not found", publicError("deletion not found"))
+		return
+	}
+	resp, err := s.userSvc.GetDeletion(r.Context(), &pb.GetDeletionRequest{ID: id})
+	if err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to look up the deletion"))
+		return
+	} else if !resp.GetFound() {
+		errorCode(w, r, http.StatusNotFound, "not found", publicError("deletion not found"))
+		return
+	}
+	d := resp.GetDeletion()
+	if !d.GetDone() {
+		w.Header().Set("Refresh", "5")
+	}
+	requested, _ := ptypes.Timestamp(d.GetRequested())
+	updated, _ := ptypes.Timestamp(d.GetUpdated())
+	render(w, r, http.StatusOK, "deletion.html", map[string]interface{}{
+		"deletion":  d,
+		"requested": requested,
+		"updated":   updated})
+}
@@ -0,0 +1,99 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/rpcerror"
+	"github.com/ahmetb/coffeelog/validation"
+	"github.com/pkg/errors"
+)
+
+// errorCode logs the error and responds with an error page showing only a
+// message safe to show to the user, or with the JSON error envelope if the
+// request was made to the API or asks for JSON.
+func errorCode(w http.ResponseWriter, r *http.Request, code int, msg string, err error) {
+	if wantsJSON(r) {
+		apiErrorCode(w, r, code, err)
+		return
+	}
+	logging.FromContext(r.Context()).WithField("http.status", code).WithField("error", err).Warn(msg)
+	render(w, r, code, "error.html", map[string]interface{}{
+		"title":   http.StatusText(code),
+		"message": publicMessage(code, err)})
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	errorCode(w, r, http.StatusUnauthorized, "unauthorized", err)
+}
+
+func badRequest(w http.ResponseWriter, r *http.Request, err error) {
+	errorCode(w, r, http.StatusBadRequest, "bad request", err)
+}
+
+// serverError responds with the HTTP status of the error's gRPC status code,
+// so that errors from the backends such as NotFound are not reported as 500.
+func serverError(w http.ResponseWriter, r *http.Request, err error) {
+	errorCode(w, r, rpcerror.HTTPStatus(rpcerror.Code(err)), "server error", err)
+}
+
+// wantsJSON reports whether the error response should be in JSON.
+func wantsJSON(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, apiPrefix+"/") ||
+		strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+// publicError is an error of the handlers with a message written for the
+// user. The messages of the other errors are only logged, as they may have
+// details such as the addresses of the backends.
+type publicError string
+
+func (e publicError) Error() string { return string(e) }
+
+// publicWrap returns an error that is shown to the user as msg and logged
+// with the message of err too.
+func publicWrap(err error, msg string) error {
+	return &wrappedPublicError{msg: publicError(msg), err: err}
+}
+
+type wrappedPublicError struct {
+	msg publicError
+	err error
+}
+
+func (e *wrappedPublicError) Error() string { return string(e.msg) + ": " + e.err.Error() }
+
+// publicMessage returns the message to show the user for err: the message of
+// a public gRPC status or of a public error of the handlers if the request
+// was at fault, otherwise only the status text of the code.
+func publicMessage(code int, err error) string {
+	if code < http.StatusInternalServerError {
+		if msg, ok := rpcerror.Public(err); ok {
+			return msg
+		}
+		switch e := errors.Cause(err).(type) {
+		case publicError:
+			return string(e)
+		case *wrappedPublicError:
+			return string(e.msg)
+		case validation.Errors:
+			return e.Error()
+		}
+	}
+	return http.StatusText(code)
+}
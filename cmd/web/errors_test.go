@@ -0,0 +1,54 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ahmetb/coffeelog/validation"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestPublicMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		code int
+		err  error
+		want string
+	}{
+		{"internal error of the frontend", http.StatusBadRequest,
+			errors.New("dial tcp 10.0.0.1:8000: connection refused"), "Bad Request"},
+		{"public error", http.StatusNotFound,
+			errors.Wrap(publicError("user not found"), "failed to look up"), "user not found"},
+		{"public error with a cause", http.StatusBadRequest,
+			publicWrap(errors.New(`strconv.ParseInt: parsing "x": invalid syntax`), "bad activity id"), "bad activity id"},
+		{"validation errors", http.StatusBadRequest,
+			validation.Errors{"drink": "drink is required"}, "drink is required"},
+		{"public status", http.StatusNotFound,
+			status.Error(codes.NotFound, "activity not found"), "activity not found"},
+		{"internal status", http.StatusBadRequest,
+			status.Error(codes.Internal, "datastore: no such entity"), "Bad Request"},
+		{"public error of a failure", http.StatusInternalServerError,
+			publicError("user not found"), "Internal Server Error"},
+	}
+	for _, tt := range tests {
+		if got := publicMessage(tt.code, tt.err); got != tt.want {
+			t.Errorf("%s: got %q, expected %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"time"
+
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+func (s *server) follow(w http.ResponseWriter, r *http.Request) {
+	me := s.cookieUser(w, r)
+	if me == nil {
+		return
+	}
+	userResp, err := s.getUser(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to look up the user"))
+		return
+	} else if !userResp.GetFound() {
+		errorCode(w, r, http.StatusNotFound, "not found", publicError("user not found"))
+		return
+	}
+	if _, err := s.userSvc.Follow(r.Context(), &pb.FollowRequest{
+		UserID:     userResp.GetUser().GetID(),
+		FollowerID: me.GetID()}); err != nil {
+		badRequest(w, r, errors.Wrap(err, "failed to follow"))
+		return
+	}
+	w.Header().Set("Location", profileURL(userResp.GetUser()))
+	w.WriteHeader(http.StatusFound)
+}
+
+func (s *server) unfollow(w http.ResponseWriter, r *http.Request) {
+	me := s.cookieUser(w, r)
+	if me == nil {
+		return
+	}
+	userResp, err := s.getUser(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to look up the user"))
+		return
+	} else if !userResp.GetFound() {
+		errorCode(w, r, http.StatusNotFound, "not found", publicError("user not found"))
+		return
+	}
+	if _, err := s.userSvc.Unfollow(r.Context(), &pb.FollowRequest{
+		UserID:     userResp.GetUser().GetID(),
+		FollowerID: me.GetID()}); err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to unfollow"))
+		return
+	}
+	w.Header().Set("Location", profileURL(userResp.GetUser()))
+	w.WriteHeader(http.StatusFound)
+}
+
+type followerView struct {
+	User    *pb.User
+	Pending bool
+	Since   time.Time
+}
+
+func (s *server) followers(w http.ResponseWriter, r *http.Request) {
+	me := s.cookieUser(w, r)
+	if me == nil {
+		return
+	}
+	resp, err := s.userSvc.ListFollowers(r.Context(), &pb.ListFollowersRequest{UserID: me.GetID()})
+	if err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to list followers"))
+		return
+	}
+	var v []followerView
+	for _, f := range resp.GetFollowers() {
+		fv := followerView{User: f.GetUser(), Pending: f.GetPending()}
+		fv.Since, _ = ptypes.Timestamp(f.GetSince())
+		v = append(v, fv)
+	}
+	render(w, r, http.StatusOK, "followers.html", map[string]interface{}{
+		"me":        me,
+		"followers": v})
+}
+
+func (s *server) approveFollower(w http.ResponseWriter, r *http.Request) {
+	me := s.cookieUser(w, r)
+	if me == nil {
+		return
+	}
+	if _, err := s.userSvc.ApproveFollower(r.Context(), &pb.FollowRequest{
+		UserID:     me.GetID(),
+		FollowerID: mux.Vars(r)["id"]}); err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to approve follower"))
+		return
+	}
+	w.Header().Set("Location", "/followers")
+	w.WriteHeader(http.StatusFound)
+}
+
+func (s *server) removeFollower(w http.ResponseWriter, r *http.Request) {
+	me := s.cookieUser(w, r)
+	if me == nil {
+		return
+	}
+	if _, err := s.userSvc.Unfollow(r.Context(), &pb.FollowRequest{
+		UserID:     me.GetID(),
+		FollowerID: mux.Vars(r)["id"]}); err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to remove follower"))
+		return
+	}
+	w.Header().Set("Location", "/followers")
+	w.WriteHeader(http.StatusFound)
+}
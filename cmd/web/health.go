@@ -0,0 +1,47 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// healthz reports that the server is running.
+func (s *server) healthz(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+// readyz reports whether the server takes new requests, which it stops doing
+// when it is shutting down or cannot reach the backends, followed by the
+// status of each backend.
+func (s *server) readyz(w http.ResponseWriter, r *http.Request) {
+	code, msg := http.StatusOK, "ok"
+	if s.sd.Draining() {
+		code, msg = http.StatusServiceUnavailable, "shutting down"
+	} else if !s.health.Serving() {
+		code, msg = http.StatusServiceUnavailable, "unavailable"
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(code)
+	fmt.Fprintln(w, msg)
+	for _, d := range s.health.Dependencies() {
+		status := "ok"
+		if d.Err != nil {
+			status = "unavailable"
+		}
+		fmt.Fprintf(w, "%s: %s\n", d.Name, status)
+	}
+}
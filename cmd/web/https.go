@@ -0,0 +1,182 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// certReloadInterval is how often the certificate files are checked for
+// changes.
+const certReloadInterval = 10 * time.Second
+
+// httpsConfig returns the tls config to serve https with, which gets the
+// certificate either from the -https-cert/-https-key files or from Let's
+// Encrypt for the -autocert-domains. It returns nil if neither is given, for
+// which plain http is served.
+func httpsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	switch {
+	case *autocertDomains != "" && (*httpsCert != "" || *httpsKey != ""):
+		return nil, errors.New("autocert domains and https certificate files cannot be used together")
+	case *autocertDomains != "":
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(strings.Split(*autocertDomains, ",")...),
+			Cache:      autocert.DirCache(*autocertCacheDir),
+			Email:      *autocertEmail}
+		cfg.GetCertificate = m.GetCertificate
+	case *httpsCert == "" && *httpsKey == "":
+		return nil, nil
+	case *httpsCert == "" || *httpsKey == "":
+		return nil, errors.New("https certificate and key files must be given together")
+	default:
+		c, err := loadCert(*httpsCert, *httpsKey)
+		if err != nil {
+			return nil, err
+		}
+		cfg.GetCertificate = c.GetCertificate
+	}
+	return cfg, nil
+}
+
+// certReloader serves the certificate in the files, and reloads them when
+// they change so that the certificate can be renewed without a restart.
+type certReloader struct {
+	certFile, keyFile string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	modTimes [2]time.Time
+}
+
+func loadCert(certFile, keyFile string) (*certReloader, error) {
+	c := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	go c.watch()
+	return c, nil
+}
+
+func (c *certReloader) load() error {
+	modTimes, err := c.stat()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to load https certificate")
+	}
+	c.mu.Lock()
+	c.cert, c.modTimes = &cert, modTimes
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *certReloader) stat() ([2]time.Time, error) {
+	var v [2]time.Time
+	for i, f := range []string{c.certFile, c.keyFile} {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return v, errors.Wrap(err, "failed to stat https certificate file")
+		}
+		v[i] = fi.ModTime()
+	}
+	return v, nil
+}
+
+// watch reloads the files when they change. The current certificate is kept
+// if the new files fail to load, as they may be in the middle of being
+// replaced.
+func (c *certReloader) watch() {
+	log := log.WithField("facility", "https")
+	for range time.Tick(certReloadInterval) {
+		v, err := c.stat()
+		if err != nil {
+			log.WithField("error", err).Warn("failed to check https certificate for changes")
+			continue
+		}
+		c.mu.RLock()
+		changed := v != c.modTimes
+		c.mu.RUnlock()
+		if !changed {
+			continue
+		}
+		if err := c.load(); err != nil {
+			log.WithField("error", err).Error("failed to reload https certificate, keeping the current one")
+			continue
+		}
+		log.Info("reloaded https certificate")
+	}
+}
+
+func (c *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, nil
+}
+
+// redirectHTTPS redirects the requests to the same URL over https, on the
+// port of httpsAddr.
+func redirectHTTPS(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		u := *r.URL
+		u.Scheme, u.Host = "https", host
+		http.Redirect(w, r, u.String(), http.StatusMovedPermanently)
+	})
+}
+
+// hsts tells the browsers to only use https for the site from then on, for
+// the requests served over https.
+func hsts(maxAge time.Duration, h http.Handler) http.Handler {
+	if maxAge <= 0 {
+		return h
+	}
+	v := fmt.Sprintf("max-age=%d", int64(maxAge.Seconds()))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			w.Header().Set("Strict-Transport-Security", v)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// listenAndServe serves srv over https if it has a tls config, and over plain
+// http otherwise.
+func listenAndServe(srv *http.Server) error {
+	if srv.TLSConfig != nil {
+		return srv.ListenAndServeTLS("", "")
+	}
+	return srv.ListenAndServe()
+}
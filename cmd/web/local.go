@@ -0,0 +1,259 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+const minPasswordLength = 10 // should match the user directory
+
+// render executes the page template with the layout.
+func render(w http.ResponseWriter, r *http.Request, status int, page string, data map[string]interface{}) {
+	log := logging.FromContext(r.Context())
+	tmpl := template.Must(pageTemplate(r, page))
+	w.WriteHeader(status)
+	if err := tmpl.Execute(w, data); err != nil {
+		// the response is already under way, usually to a client that left
+		log.WithField("error", err).Warn("failed to render the page")
+	}
+}
+
+func (s *server) renderLogin(w http.ResponseWriter, r *http.Request, status int, data map[string]interface{}) {
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	data["providers"] = s.providerList()
+	data["local"] = *localAccounts
+	if _, ok := data["next"]; !ok {
+		data["next"] = loginRedirectTarget(r)
+	}
+	render(w, r, status, "login.html", data)
+}
+
+// parseExternalURL checks the -external-url flag and returns it without a
+// trailing slash.
+func parseExternalURL(v string) (string, error) {
+	u, err := url.Parse(v)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid -external-url")
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return "", errors.Errorf("invalid -external-url %q, want a url such as https://coffeelog.example.com", v)
+	}
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
+
+// baseURL returns the URL of the site for the links in emails. It is not
+// taken from the Host header of the request, which the client can set to
+// have the links point to a site of its own.
+func baseURL() string {
+	return *externalURL
+}
+
+func (s *server) sendVerification(r *http.Request, email, token string) error {
+	if token == "" {
+		return nil
+	}
+	return s.mail.send(email, "Verify your Coffee Log account",
+		"Open the link below to verify your email address:\n\n"+
+			baseURL()+"/verify?token="+url.QueryEscape(token)+"\n\n"+
+			"If you did not sign up for Coffee Log, you can ignore this email.\n")
+}
+
+func (s *server) registerForm(w http.ResponseWriter, r *http.Request) {
+	render(w, r, http.StatusOK, "register.html", nil)
+}
+
+func (s *server) register(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	email := strings.TrimSpace(r.PostFormValue("email"))
+	form := map[string]interface{}{
+		"email": email,
+		"name":  r.PostFormValue("name")}
+	if r.PostFormValue("password") != r.PostFormValue("password2") {
+		form["error"] = "The passwords do not match."
+		render(w, r, http.StatusBadRequest, "register.html", form)
+		return
+	} else if len(r.PostFormValue("password")) < minPasswordLength {
+		form["error"] = "The password is too short."
+		render(w, r, http.StatusBadRequest, "register.html", form)
+		return
+	}
+
+	resp, err := s.userSvc.Register(r.Context(), &pb.RegisterRequest{
+		Email:       email,
+		Password:    r.PostFormValue("password"),
+		DisplayName: r.PostFormValue("name")})
+	if err != nil {
+		log.WithField("error", err).Debug("registration failed")
+		form["error"] = "Registration failed: " + grpc.ErrorDesc(err)
+		render(w, r, http.StatusBadRequest, "register.html", form)
+		return
+	}
+	if err := s.sendVerification(r, email, resp.GetToken()); err != nil {
+		serverError(w, r, err)
+		return
+	}
+	// same response whether or not the email was already registered
+	render(w, r, http.StatusOK, "message.html", map[string]interface{}{
+		"title":   "Check your email",
+		"message": "We sent a link to " + email + " to verify your email address."})
+}
+
+func (s *server) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.userSvc.VerifyEmail(r.Context(), &pb.AccountTokenRequest{Token: r.URL.Query().Get("token")})
+	if err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to verify email"))
+		return
+	} else if !resp.GetFound() {
+		render(w, r, http.StatusNotFound, "message.html", map[string]interface{}{
+			"title":   "Invalid link",
+			"message": "The verification link is invalid or expired. Log in to get a new one."})
+		return
+	}
+	s.renderLogin(w, r, http.StatusOK, map[string]interface{}{
+		"message": "Your email address is verified, you can log in now.",
+		"next":    "/"})
+}
+
+func (s *server) resendVerification(w http.ResponseWriter, r *http.Request) {
+	email := strings.TrimSpace(r.PostFormValue("email"))
+	resp, err := s.userSvc.RequestEmailVerification(r.Context(), &pb.EmailRequest{Email: email})
+	if err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to request verification"))
+		return
+	}
+	if err := s.sendVerification(r, email, resp.GetToken()); err != nil {
+		serverError(w, r, err)
+		return
+	}
+	render(w, r, http.StatusOK, "message.html", map[string]interface{}{
+		"title":   "Check your email",
+		"message": "If " + email + " has an unverified account, we sent a new verification link to it."})
+}
+
+func (s *server) passwordLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logging.FromContext(ctx)
+	email := strings.TrimSpace(r.PostFormValue("email"))
+	next := localRedirect(r.PostFormValue("next"))
+	resp, err := s.userSvc.PasswordLogin(ctx, &pb.PasswordLoginRequest{
+		Email:    email,
+		Password: r.PostFormValue("password"),
+		IP:       clientIP(r)})
+	if err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to log in"))
+		return
+	}
+	form := map[string]interface{}{"email": email, "next": next}
+	switch resp.GetStatus() {
+	case pb.PasswordLoginResponse_OK:
+	case pb.PasswordLoginResponse_THROTTLED:
+		form["error"] = "Too many failed login attempts, try again later."
+		s.renderLogin(w, r, http.StatusTooManyRequests, form)
+		return
+	case pb.PasswordLoginResponse_UNVERIFIED:
+		form["unverified"] = true
+		s.renderLogin(w, r, http.StatusForbidden, form)
+		return
+	default:
+		form["error"] = "Invalid email or password."
+		s.renderLogin(w, r, http.StatusUnauthorized, form)
+		return
+	}
+
+	if err := s.startSession(ctx, w, r, resp.GetUser().GetID()); err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to start session"))
+		return
+	}
+	logins.Inc("password")
+	log.WithField("user.id", resp.GetUser().GetID()).Info("authenticated user")
+	w.Header().Set("Location", next)
+	w.WriteHeader(http.StatusFound)
+}
+
+func (s *server) forgotForm(w http.ResponseWriter, r *http.Request) {
+	render(w, r, http.StatusOK, "forgot.html", nil)
+}
+
+func (s *server) forgotPassword(w http.ResponseWriter, r *http.Request) {
+	email := strings.TrimSpace(r.PostFormValue("email"))
+	resp, err := s.userSvc.RequestPasswordReset(r.Context(), &pb.EmailRequest{Email: email})
+	if err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to request password reset"))
+		return
+	}
+	if resp.GetToken() != "" {
+		if err := s.mail.send(email, "Reset your Coffee Log password",
+			"Open the link below within an hour to choose a new password:\n\n"+
+				baseURL()+"/reset?token="+url.QueryEscape(resp.GetToken())+"\n\n"+
+				"If you did not ask to reset your password, you can ignore this email.\n"); err != nil {
+			serverError(w, r, err)
+			return
+		}
+	}
+	render(w, r, http.StatusOK, "message.html", map[string]interface{}{
+		"title":   "Check your email",
+		"message": "If " + email + " has an account, we sent a link to reset the password to it."})
+}
+
+func (s *server) resetForm(w http.ResponseWriter, r *http.Request) {
+	// keep the token out of the Referer of the scripts and styles loaded
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	render(w, r, http.StatusOK, "reset.html", map[string]interface{}{"token": r.URL.Query().Get("token")})
+}
+
+func (s *server) resetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	form := map[string]interface{}{"token": r.PostFormValue("token")}
+	if r.PostFormValue("password") != r.PostFormValue("password2") {
+		form["error"] = "The passwords do not match."
+		render(w, r, http.StatusBadRequest, "reset.html", form)
+		return
+	} else if len(r.PostFormValue("password")) < minPasswordLength {
+		form["error"] = "The password is too short."
+		render(w, r, http.StatusBadRequest, "reset.html", form)
+		return
+	}
+	resp, err := s.userSvc.ResetPassword(ctx, &pb.ResetPasswordRequest{
+		Token:    r.PostFormValue("token"),
+		Password: r.PostFormValue("password")})
+	if err != nil {
+		form["error"] = "Failed to reset the password: " + grpc.ErrorDesc(err)
+		render(w, r, http.StatusBadRequest, "reset.html", form)
+		return
+	} else if !resp.GetFound() {
+		render(w, r, http.StatusNotFound, "message.html", map[string]interface{}{
+			"title":   "Invalid link",
+			"message": "The password reset link is invalid or expired."})
+		return
+	}
+	if err := s.startSession(ctx, w, r, resp.GetUser().GetID()); err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to start session"))
+		return
+	}
+	logins.Inc("password_reset")
+	w.Header().Set("Location", "/")
+	w.WriteHeader(http.StatusFound)
+}
@@ -0,0 +1,68 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const smtpPasswordEnv = "SMTP_PASSWORD"
+
+// mailer sends plain text emails through an SMTP server. Without a server
+// configured, the emails are only logged, which is useful in development.
+type mailer struct {
+	addr     string // host:port
+	from     string
+	username string
+	password string
+}
+
+func (m *mailer) send(to, subject, body string) error {
+	if strings.ContainsAny(to, "\r\n") {
+		return errors.New("invalid recipient address")
+	}
+	if m.addr == "" {
+		log.WithFields(logrus.Fields{
+			"to":      to,
+			"subject": subject,
+			"body":    body}).Warn("smtp server not configured, not sending email")
+		return nil
+	}
+
+	var auth smtp.Auth
+	if m.username != "" {
+		host, _, err := net.SplitHostPort(m.addr)
+		if err != nil {
+			return errors.Wrap(err, "invalid smtp address")
+		}
+		auth = smtp.PlainAuth("", m.username, m.password, host)
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", m.from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+	return errors.Wrap(smtp.SendMail(m.addr, auth, m.from, []string{to}, b.Bytes()), "failed to send email")
+}
@@ -19,100 +19,186 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"html/template"
 	"io/ioutil"
 	"net/http"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
-	"cloud.google.com/go/trace"
+	"github.com/ahmetb/coffeelog/caller"
 	pb "github.com/ahmetb/coffeelog/coffeelog"
-	"github.com/ahmetb/coffeelog/version"
+	"github.com/ahmetb/coffeelog/health"
+	"github.com/ahmetb/coffeelog/interceptor"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/metrics"
+	"github.com/ahmetb/coffeelog/mtls"
+	"github.com/ahmetb/coffeelog/ratelimit"
+	"github.com/ahmetb/coffeelog/shutdown"
+	"github.com/ahmetb/coffeelog/trace"
+	"github.com/ahmetb/coffeelog/validation"
 	"github.com/golang/protobuf/ptypes"
-	plus "github.com/google/google-api-go-client/plus/v1"
 	"github.com/gorilla/mux"
-	"github.com/gorilla/securecookie"
 	"github.com/pkg/errors"
 	logrus "github.com/sirupsen/logrus"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/grpclog"
 )
 
 type server struct {
-	cfg         *oauth2.Config
+	providers   map[string]*loginProvider
+	mail        *mailer
 	userSvc     pb.UserDirectoryClient
 	roasterSvc  pb.RoasterDirectoryClient
 	activitySvc pb.ActivityDirectoryClient
 	tc          *trace.Client
+	sd          *shutdown.Watcher
+	health      *health.Server
+
+	postLimiter    *limiter // posting activities
+	roasterLimiter *limiter // searching the roasters
+	statsLimiter   *limiter // computing the statistics of users
 }
 
 var (
-	projectID              = flag.String("google-project-id", "", "google cloud project id")
 	addr                   = flag.String("addr", ":8000", "[host]:port to listen")
 	oauthConfig            = flag.String("google-oauth2-config", "", "path to oauth2 config json")
+	oidcIssuer             = flag.String("oidc-issuer", googleIssuer, "OpenID Connect issuer used to log in users with the google-oauth2-config client")
+	providersConfig        = flag.String("auth-providers-config", "", "path to json file with additional identity providers")
+	localAccounts          = flag.Bool("local-accounts", false, "allow users to register with email and password")
+	trustedProxies         = flag.String("trusted-proxies", "", "comma-separated ip addresses or cidr ranges of the load balancers whose X-Forwarded-For and X-Forwarded-Proto headers are trusted for the client address and scheme (default: none)")
+	externalURL            = flag.String("external-url", "", "url the site is served on, such as https://coffeelog.example.com, for the links in emails and the oauth2 redirects (required with -local-accounts)")
+	smtpAddr               = flag.String("smtp-addr", "", "host:port of the smtp server to send emails with (default: log the emails)")
+	smtpFrom               = flag.String("smtp-from", "coffeelog@localhost", "sender address of the emails")
+	smtpUsername           = flag.String("smtp-username", "", "smtp username, the password is read from $"+smtpPasswordEnv)
 	userDirectoryBackend   = flag.String("user-directory-addr", "", "address of user directory backend")
 	coffeeDirectoryBackend = flag.String("coffee-directory-addr", "", "address of coffee directory backend")
-
-	hashKey  = []byte("very-secret")      // TODO extract to env
-	blockKey = []byte("a-lot-secret-key") // TODO extract to env
-	sc       = securecookie.New(hashKey, blockKey)
+	sessionCookieMaxAge    = flag.Duration("session-cookie-max-age", 90*24*time.Hour, "lifetime of the session cookie, should match the session max age of user directory")
+	identityKeysFile       = flag.String("identity-keys-file", "", "path to the file with the keys to sign the identity of the users to the backends with (default: $"+caller.KeysEnv+")")
+	cookieKeysFile         = flag.String("cookie-keys-file", "", "path to the file with cookie hash/block key pairs (default: $"+cookieKeysEnv+")")
+	devMode                = flag.Bool("dev", false, "development mode, allows insecure defaults")
+	tlsCert                = flag.String("tls-cert", "", "path to the tls certificate of the service, for mutual tls with the backends")
+	tlsKey                 = flag.String("tls-key", "", "path to the private key of the tls certificate")
+	tlsCA                  = flag.String("tls-ca", "", "path to the ca certificates to verify the backends with")
+	httpsAddr              = flag.String("https-addr", ":8443", "[host]:port to serve https on when a certificate is configured, -addr then redirects to it")
+	httpsCert              = flag.String("https-cert", "", "path to the tls certificate to serve https with, reloaded when it changes")
+	httpsKey               = flag.String("https-key", "", "path to the private key of the https certificate")
+	autocertDomains        = flag.String("autocert-domains", "", "comma-separated domain names to get https certificates for from Let's Encrypt, instead of -https-cert")
+	autocertCacheDir       = flag.String("autocert-cache-dir", "autocert-cache", "directory to store the certificates from Let's Encrypt in")
+	autocertEmail          = flag.String("autocert-email", "", "contact email for the Let's Encrypt account")
+	hstsMaxAge             = flag.Duration("hsts-max-age", 365*24*time.Hour, "max-age of the Strict-Transport-Security header sent over https, 0 to disable")
+	shutdownDelay          = flag.Duration("shutdown-delay", 0, "how long to fail the readiness checks for on SIGTERM before stopping")
+	shutdownTimeout        = flag.Duration("shutdown-timeout", 20*time.Second, "how long to wait for the requests in flight to finish on SIGTERM")
+	metricsAddr            = flag.String("metrics-addr", "", "[host]:port to serve prometheus metrics on at /metrics (default: disabled)")
+	logLevel               = flag.String("log-level", "info", "least severe level to log: debug, info, warn or error")
+	logFormat              = flag.String("log-format", "json", "format of the logs: json or text")
+	traceExporter          = flag.String("trace-exporter", "none", "where to send the traces: otlp, stdout or none")
+	traceEndpoint          = flag.String("trace-otlp-endpoint", "", "base url of the otlp/http receiver for -trace-exporter=otlp, such as http://otel-collector:4318")
+	traceSampleRatio       = flag.Float64("trace-sample-ratio", 1.0, "fraction of the new traces to sample")
+	traceMaxPerSecond      = flag.Float64("trace-max-per-second", 5, "most new traces to sample per second, 0 for no limit")
+	rateLimitPostUser      = flag.String("rate-limit-post-per-user", "10/m", "activities a user can post, as N/s, N/m or N/h, 0 for no limit")
+	rateLimitPostIP        = flag.String("rate-limit-post-per-ip", "30/m", "activities that can be posted from an ip address, 0 for no limit")
+	rateLimitRoastersUser  = flag.String("rate-limit-roasters-per-user", "0", "roaster searches a user can make, 0 for no limit")
+	rateLimitRoastersIP    = flag.String("rate-limit-roasters-per-ip", "120/m", "roaster searches that can be made from an ip address, 0 for no limit")
+	rateLimitStatsUser     = flag.String("rate-limit-stats-per-user", "30/m", "user statistics a user can request, 0 for no limit")
+	rateLimitStatsIP       = flag.String("rate-limit-stats-per-ip", "30/m", "user statistics that can be requested from an ip address, 0 for no limit")
 )
 
 var log *logrus.Entry
 
 func main() {
 	flag.Parse()
-	host, err := os.Hostname()
+	var err error
+	log, err = logging.New("web", *logLevel, *logFormat)
 	if err != nil {
-		log.Fatal(errors.Wrap(err, "cannot get hostname"))
-	}
-	logrus.SetLevel(logrus.DebugLevel)
-	logrus.SetFormatter(&logrus.JSONFormatter{FieldMap: logrus.FieldMap{logrus.FieldKeyLevel: "severity"}})
-	log = logrus.WithFields(logrus.Fields{
-		"service": "web",
-		"host":    host,
-		"v":       version.Version(),
-	})
+		logrus.Fatal(err)
+	}
 	grpclog.SetLogger(log.WithField("facility", "grpc"))
-	sc.SetSerializer(securecookie.JSONEncoder{})
+
+	if cookieCodecs, err = loadCookieCodecs(*cookieKeysFile, *devMode); err != nil {
+		log.Fatal(errors.Wrap(err, "failed to load cookie keys"))
+	}
+	setCookieMaxAge(int(sessionCookieMaxAge.Seconds()))
+	idKeys, err := caller.LoadKeys(*identityKeysFile)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "failed to load identity keys"))
+	}
 
 	if env := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); env == "" {
 		log.Fatal("GOOGLE_APPLICATION_CREDENTIALS environment variable is not set")
 	}
-	if *projectID == "" {
-		log.Fatal("google cloud project id flag not specified")
-	}
 	if *userDirectoryBackend == "" {
 		log.Fatal("user directory address flag not specified")
 	}
 	if *coffeeDirectoryBackend == "" {
 		log.Fatal("user directory address flag not specified")
 	}
-	if *oauthConfig == "" {
-		log.Fatal("google oauth2 config flag not specified")
+	if *oauthConfig == "" && *providersConfig == "" && !*localAccounts {
+		log.Fatal("no login method configured, specify google oauth2 config, auth providers config or local accounts flag")
+	}
+	if *externalURL != "" {
+		if *externalURL, err = parseExternalURL(*externalURL); err != nil {
+			log.Fatal(err)
+		}
+	} else if *localAccounts {
+		log.Fatal("-external-url is required with -local-accounts, to link to the site in emails")
 	}
 
-	b, err := ioutil.ReadFile(*oauthConfig)
-	if err != nil {
-		log.Fatal(errors.Wrap(err, "failed to parse config file"))
+	if proxies, err = parseProxies(*trustedProxies); err != nil {
+		log.Fatal(errors.Wrap(err, "invalid -trusted-proxies"))
 	}
-	authConf, err := google.ConfigFromJSON(b)
-	if err != nil {
-		log.Fatal(errors.Wrap(err, "failed to parse config file"))
+
+	providers := make(map[string]*loginProvider)
+	if *oauthConfig != "" {
+		b, err := ioutil.ReadFile(*oauthConfig)
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "failed to parse config file"))
+		}
+		authConf, err := google.ConfigFromJSON(b)
+		if err != nil {
+			log.Fatal(errors.Wrap(err, "failed to parse config file"))
+		}
+		providers["google"] = &loginProvider{
+			Name:  "google",
+			Title: "Google",
+			cfg:   authConf,
+			oidc:  newOIDCProvider(*oidcIssuer, authConf.ClientID)}
+	}
+	if *providersConfig != "" {
+		v, err := loadProviders(*providersConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, p := range v {
+			if _, ok := providers[p.Name]; ok {
+				log.Fatalf("duplicate auth provider %q", p.Name)
+			}
+			providers[p.Name] = p
+		}
 	}
 
-	tc, err := trace.NewClient(context.Background(), *projectID)
+	tc, err := trace.NewClient(trace.Config{
+		Service:      "web",
+		Exporter:     *traceExporter,
+		Endpoint:     *traceEndpoint,
+		SampleRatio:  *traceSampleRatio,
+		MaxPerSecond: *traceMaxPerSecond}, log)
 	if err != nil {
 		log.Fatal(errors.Wrap(err, "failed to initialize trace client"))
 	}
+	tr, err := mtls.Load(mtls.Config{CertFile: *tlsCert, KeyFile: *tlsKey, CAFile: *tlsCA}, log)
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "failed to load tls files"))
+	}
 	userSvcConn, err := grpc.Dial(*userDirectoryBackend,
-		grpc.WithInsecure(),
-		grpc.WithUnaryInterceptor(tc.GRPCClientInterceptor()))
+		tr.DialOption(),
+		grpc.WithUnaryInterceptor(interceptor.ChainUnaryClient(
+			tc.GRPCClientInterceptor(),
+			metrics.UnaryClientInterceptor(),
+			callerInterceptor(idKeys))))
 	if err != nil {
 		log.Fatal(errors.Wrap(err, "cannot connect user service"))
 	}
@@ -121,8 +207,11 @@ func main() {
 		userSvcConn.Close()
 	}()
 	coffeeSvcConn, err := grpc.Dial(*coffeeDirectoryBackend,
-		grpc.WithInsecure(),
-		grpc.WithUnaryInterceptor(tc.GRPCClientInterceptor()))
+		tr.DialOption(),
+		grpc.WithUnaryInterceptor(interceptor.ChainUnaryClient(
+			tc.GRPCClientInterceptor(),
+			metrics.UnaryClientInterceptor(),
+			callerInterceptor(idKeys))))
 	if err != nil {
 		log.Fatal(errors.Wrap(err, "cannot connect coffee service"))
 	}
@@ -130,41 +219,130 @@ func main() {
 		log.Info("closing connection to user directory")
 		coffeeSvcConn.Close()
 	}()
-	sp, err := trace.NewLimitedSampler(1.0, 5)
+	limitStore := ratelimit.NewMemoryStore(ratelimit.DefaultMaxKeys)
+	postLimiter, err := newLimiter("post", limitStore, *rateLimitPostUser, *rateLimitPostIP)
 	if err != nil {
-		log.Fatal(errors.Wrap(err, "failed to create sampling policy"))
+		log.Fatal(err)
+	}
+	roasterLimiter, err := newLimiter("roasters", limitStore, *rateLimitRoastersUser, *rateLimitRoastersIP)
+	if err != nil {
+		log.Fatal(err)
+	}
+	statsLimiter, err := newLimiter("stats", limitStore, *rateLimitStatsUser, *rateLimitStatsIP)
+	if err != nil {
+		log.Fatal(err)
 	}
-	tc.SetSamplingPolicy(sp)
 
 	s := &server{
-		tc:          tc,
-		cfg:         authConf,
+		tc:        tc,
+		sd:        shutdown.Watch(log, *shutdownDelay, *shutdownTimeout),
+		providers: providers,
+		mail: &mailer{
+			addr:     *smtpAddr,
+			from:     *smtpFrom,
+			username: *smtpUsername,
+			password: os.Getenv(smtpPasswordEnv)},
 		userSvc:     pb.NewUserDirectoryClient(userSvcConn),
 		activitySvc: pb.NewActivityDirectoryClient(coffeeSvcConn),
 		roasterSvc:  pb.NewRoasterDirectoryClient(coffeeSvcConn),
+
+		postLimiter:    postLimiter,
+		roasterLimiter: roasterLimiter,
+		statsLimiter:   statsLimiter,
 	}
 
+	s.health = health.NewServer(log, s.sd.Draining)
+	s.health.AddCheck("userdirectory", health.PeerCheck(userSvcConn))
+	s.health.AddCheck("coffeedirectory", health.PeerCheck(coffeeSvcConn))
+	s.health.Start(context.Background())
+
 	// set up server
 	r := mux.NewRouter()
 	r.PathPrefix("/static/").HandlerFunc(http.StripPrefix("/static/", http.FileServer(http.Dir("static"))).ServeHTTP)
+	r.HandleFunc("/healthz", s.healthz).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", s.readyz).Methods(http.MethodGet)
 	r.Handle("/", s.traceHandler(logHandler(s.home))).Methods(http.MethodGet)
 	r.Handle("/login", s.traceHandler(logHandler(s.login))).Methods(http.MethodGet)
-	r.Handle("/logout", s.traceHandler(logHandler(s.logout))).Methods(http.MethodGet)
+	r.Handle("/login/{provider}", s.traceHandler(logHandler(s.loginWith))).Methods(http.MethodGet)
+	if *localAccounts {
+		r.Handle("/login", s.traceHandler(logHandler(s.passwordLogin))).Methods(http.MethodPost)
+		r.Handle("/register", s.traceHandler(logHandler(s.registerForm))).Methods(http.MethodGet)
+		r.Handle("/register", s.traceHandler(logHandler(s.register))).Methods(http.MethodPost)
+		r.Handle("/verify", s.traceHandler(logHandler(s.verifyEmail))).Methods(http.MethodGet)
+		r.Handle("/verify", s.traceHandler(logHandler(s.resendVerification))).Methods(http.MethodPost)
+		r.Handle("/forgot", s.traceHandler(logHandler(s.forgotForm))).Methods(http.MethodGet)
+		r.Handle("/forgot", s.traceHandler(logHandler(s.forgotPassword))).Methods(http.MethodPost)
+		r.Handle("/reset", s.traceHandler(logHandler(s.resetForm))).Methods(http.MethodGet)
+		r.Handle("/reset", s.traceHandler(logHandler(s.resetPassword))).Methods(http.MethodPost)
+	}
+	r.Handle("/logout", s.traceHandler(logHandler(s.logout))).Methods(http.MethodPost)
 	r.Handle("/oauth2callback", s.traceHandler(logHandler(s.oauth2Callback))).Methods(http.MethodGet)
-	r.Handle("/coffee", s.traceHandler(logHandler(s.logCoffee))).Methods(http.MethodPost)
+	r.Handle("/coffee", s.traceHandler(logHandler(s.rateLimited(s.postLimiter, s.logCoffee)))).Methods(http.MethodPost)
 	r.Handle("/a/{id:[0-9]+}", s.traceHandler(logHandler(s.activity))).Methods(http.MethodGet)
 	r.Handle("/u/{id:[0-9]+}", s.traceHandler(logHandler(s.userProfile))).Methods(http.MethodGet)
-	r.Handle("/autocomplete/roaster", s.traceHandler(logHandler(s.autocompleteRoaster))).Methods(http.MethodGet)
-	srv := http.Server{
-		Addr:    *addr, // TODO make configurable
-		Handler: r}
-	log.WithFields(logrus.Fields{"addr": *addr,
+	r.Handle("/u/{id:[0-9]+}/follow", s.traceHandler(logHandler(s.follow))).Methods(http.MethodPost)
+	r.Handle("/u/{id:[0-9]+}/unfollow", s.traceHandler(logHandler(s.unfollow))).Methods(http.MethodPost)
+	r.Handle("/followers", s.traceHandler(logHandler(s.followers))).Methods(http.MethodGet)
+	r.Handle("/followers/{id:[0-9]+}/approve", s.traceHandler(logHandler(s.approveFollower))).Methods(http.MethodPost)
+	r.Handle("/followers/{id:[0-9]+}/remove", s.traceHandler(logHandler(s.removeFollower))).Methods(http.MethodPost)
+	r.Handle("/@{username:[A-Za-z0-9_]+}", s.traceHandler(logHandler(s.usernameProfile))).Methods(http.MethodGet)
+	r.Handle("/autocomplete/roaster", s.traceHandler(logHandler(s.rateLimited(s.roasterLimiter, s.autocompleteRoaster)))).Methods(http.MethodGet)
+	r.Handle("/tokens", s.traceHandler(logHandler(s.tokens))).Methods(http.MethodGet)
+	r.Handle("/tokens", s.traceHandler(logHandler(s.createToken))).Methods(http.MethodPost)
+	r.Handle("/tokens/{id:[0-9]+}/revoke", s.traceHandler(logHandler(s.revokeToken))).Methods(http.MethodPost)
+	r.Handle("/sessions", s.traceHandler(logHandler(s.sessions))).Methods(http.MethodGet)
+	r.Handle("/sessions/{id:[0-9a-f]+}/revoke", s.traceHandler(logHandler(s.revokeSession))).Methods(http.MethodPost)
+	r.Handle("/sessions/revoke-all", s.traceHandler(logHandler(s.revokeAllSessions))).Methods(http.MethodPost)
+	r.Handle("/settings", s.traceHandler(logHandler(s.settings))).Methods(http.MethodGet)
+	r.Handle("/settings", s.traceHandler(logHandler(s.updateSettings))).Methods(http.MethodPost)
+	r.Handle("/settings/delete", s.traceHandler(logHandler(s.deleteAccountForm))).Methods(http.MethodGet)
+	r.Handle("/settings/delete", s.traceHandler(logHandler(s.deleteAccount))).Methods(http.MethodPost)
+	r.Handle("/deletion/{id:[0-9]+}", s.traceHandler(logHandler(s.deletionStatus))).Methods(http.MethodGet)
+	r.Handle("/identities", s.traceHandler(logHandler(s.identities))).Methods(http.MethodGet)
+	r.Handle("/identities/{provider}/unlink", s.traceHandler(logHandler(s.unlinkIdentity))).Methods(http.MethodPost)
+	s.registerAPI(r)
+	srv := &http.Server{
+		Addr:    *addr,
+		Handler: metrics.InstrumentHTTP(withCallerSlot(csrfProtect(r)), routeTemplate(r))}
+	tlsConfig, err := httpsConfig()
+	if err != nil {
+		log.Fatal(errors.Wrap(err, "failed to configure https"))
+	}
+	servers := []*http.Server{srv}
+	if tlsConfig != nil {
+		log.WithField("addr", *addr).Info("redirecting http to https")
+		servers = append(servers, &http.Server{Addr: *addr, Handler: redirectHTTPS(*httpsAddr)})
+		srv.Addr, srv.Handler, srv.TLSConfig = *httpsAddr, hsts(*hstsMaxAge, srv.Handler), tlsConfig
+	}
+	log.WithFields(logrus.Fields{"addr": srv.Addr,
+		"https":           tlsConfig != nil,
 		"userdirectory":   *userDirectoryBackend,
-		"coffeedirectory": *coffeeDirectoryBackend}).Info("starting to listen on http")
-	log.Fatal(errors.Wrap(srv.ListenAndServe(), "failed to listen/serve"))
+		"coffeedirectory": *coffeeDirectoryBackend}).Info("starting to listen")
+	if *metricsAddr != "" {
+		go func() {
+			log.WithField("addr", *metricsAddr).Info("serving metrics")
+			log.Fatal(errors.Wrap(metrics.ListenAndServe(*metricsAddr), "failed to serve metrics"))
+		}()
+	}
+	for _, hs := range servers {
+		go func(hs *http.Server) {
+			if err := listenAndServe(hs); err != nil && !s.sd.Draining() {
+				log.Fatal(errors.Wrap(err, "failed to listen/serve"))
+			}
+		}(hs)
+	}
+
+	ctx, cancel := s.sd.Wait()
+	defer cancel()
+	for _, hs := range servers {
+		s.sd.StopHTTP(ctx, hs)
+	}
+	s.sd.FlushTraces(s.tc.Shutdown)
 }
 
-type httpErrorWriter func(http.ResponseWriter, error)
+// httpErrorWriter responds to the request with the error, such as
+// badRequest or serverError.
+type httpErrorWriter func(http.ResponseWriter, *http.Request, error)
 
 func (s *server) getUser(ctx context.Context, id string) (*pb.UserResponse, error) {
 	span := trace.FromContext(ctx).NewChild("get_user")
@@ -178,26 +356,25 @@ func (s *server) getUser(ctx context.Context, id string) (*pb.UserResponse, erro
 }
 
 func (s *server) authUser(ctx context.Context, r *http.Request) (user *pb.User, errFunc httpErrorWriter, err error) {
+	if u := cachedUser(ctx); u != nil {
+		return u, nil, nil
+	}
 	span := trace.FromContext(ctx).NewChild("authorize_user")
 	defer span.Finish()
 
-	c, err := r.Cookie("user")
-	if err == http.ErrNoCookie {
-		return nil, nil, nil
-	}
-	log.Debug("auth cookie found")
-	var userID string
-	if err := sc.Decode("user", c.Value, &userID); err != nil {
-		return nil, badRequest, errors.Wrap(err, "failed to decode cookie")
+	if tok := bearerToken(r); tok != "" {
+		user, errFunc, err = s.authToken(ctx, tok)
+	} else {
+		sess, errF, err := s.authSession(ctx, r)
+		if err != nil || sess == nil {
+			return nil, errF, err
+		}
+		user = sess.GetUser()
 	}
-
-	userResp, err := s.getUser(ctx, userID)
-	if err != nil {
-		return nil, serverError, errors.Wrap(err, "failed to look up the user")
-	} else if !userResp.GetFound() {
-		return nil, badRequest, errors.New("unrecognized user")
+	if user != nil {
+		cacheUser(ctx, user)
 	}
-	return userResp.GetUser(), nil, nil
+	return user, errFunc, err
 }
 
 func (s *server) home(w http.ResponseWriter, r *http.Request) {
@@ -205,42 +382,88 @@ func (s *server) home(w http.ResponseWriter, r *http.Request) {
 
 	user, errF, err := s.authUser(ctx, r)
 	if err != nil {
-		errF(w, err)
+		errF(w, r, err)
 		return
 	}
 
-	log.WithField("logged_in", user != nil).Debug("serving home page")
-	tmpl := template.Must(template.ParseFiles(
-		filepath.Join("static", "template", "layout.html"),
-		filepath.Join("static", "template", "home.html")))
+	logging.FromContext(ctx).WithField("logged_in", user != nil).Debug("serving home page")
+	renderHome(w, r, http.StatusOK, user, nil, nil)
+}
 
-	if err := tmpl.Execute(w, map[string]interface{}{
+// renderHome renders the home page, with the errors of the fields of the
+// activity form and the values to fill it with, if it was rejected.
+func renderHome(w http.ResponseWriter, r *http.Request, status int, user *pb.User, errs validation.Errors, form map[string]string) {
+	render(w, r, status, "home.html", map[string]interface{}{
 		"me":              user,
 		"drinks":          drinks,
 		"methods":         methodsList,
+		"visibilities":    visibilityOptions,
 		"authenticated":   user != nil,
-		"originCountries": originCountries}); err != nil {
-		log.Fatal(err)
-	}
+		"originCountries": validation.Origins,
+		"errors":          errs,
+		"form":            form})
 }
 
+// login shows the login methods to choose from, or goes straight to the only
+// identity provider configured.
 func (s *server) login(w http.ResponseWriter, r *http.Request) {
-	s.cfg.RedirectURL = "http://" + r.Host + "/oauth2callback" // TODO this is hacky
-	s.cfg.Scopes = []string{"profile", "email"}
-	url := s.cfg.AuthCodeURL("todo_rand_state",
-		oauth2.SetAuthURLParam("access_type", "offline"))
-	log.Debug("redirecting user to oauth2 consent page")
+	providers := s.providerList()
+	if len(providers) == 1 && !*localAccounts {
+		u := *r.URL
+		u.Path = "/login/" + providers[0].Name
+		if u.Query().Get("next") == "" {
+			q := u.Query()
+			q.Set("next", loginRedirectTarget(r))
+			u.RawQuery = q.Encode()
+		}
+		w.Header().Set("Location", u.String())
+		w.WriteHeader(http.StatusFound)
+		return
+	}
+	s.renderLogin(w, r, http.StatusOK, nil)
+}
+
+// loginWith starts the login with the provider. With the "link"
+// parameter, the identity is linked to the account of the logged in user
+// instead.
+func (s *server) loginWith(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	p, ok := s.providers[mux.Vars(r)["provider"]]
+	if !ok {
+		errorCode(w, r, http.StatusNotFound, "not found", publicError("unknown identity provider"))
+		return
+	}
+	link := r.URL.Query().Get("link") != ""
+	if link {
+		if user, _, err := s.authUser(r.Context(), r); err != nil || user == nil {
+			unauthorized(w, r, publicError("required user to log in to link an identity"))
+			return
+		}
+	}
+	url, err := startOAuth(w, r, p, link)
+	if err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to start oauth2 flow"))
+		return
+	}
+	log.WithField("provider", p.Name).Debug("redirecting user to oauth2 consent page")
 	w.Header().Set("Location", url)
 	w.WriteHeader(http.StatusFound)
 }
 
 func (s *server) logout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logging.FromContext(ctx)
 	log.Debug("logout requested")
-	for _, c := range r.Cookies() {
-		c.Expires = time.Unix(1, 0)
-		http.SetCookie(w, c)
-		log.WithField("key", c.Name).Debug("cleared cookie")
+	if sess, _, err := s.authSession(ctx, r); err == nil && sess != nil {
+		if _, err := s.userSvc.RevokeSession(ctx, &pb.RevokeSessionRequest{
+			UserID: sess.GetSession().GetUserID(),
+			ID:     sess.GetSession().GetID()}); err != nil {
+			serverError(w, r, errors.Wrap(err, "failed to revoke the session"))
+			return
+		}
+		log.WithField("user.id", sess.GetUser().GetID()).Debug("revoked session")
 	}
+	clearSessionCookie(w, r)
 	w.Header().Set("Location", "/")
 	w.WriteHeader(http.StatusFound)
 }
@@ -248,70 +471,80 @@ func (s *server) logout(w http.ResponseWriter, r *http.Request) {
 func (s *server) oauth2Callback(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	span := trace.FromContext(ctx)
-	if state := r.URL.Query().Get("state"); state != "todo_rand_state" {
-		badRequest(w, errors.New("wrong oauth2 state"))
+	st, err := verifyOAuthState(w, r)
+	if err != nil {
+		badRequest(w, r, err)
 		return
 	}
 
 	code := r.URL.Query().Get("code")
 	if code == "" {
-		badRequest(w, errors.New("missing oauth2 grant code"))
+		badRequest(w, r, publicError("missing oauth2 grant code"))
 		return
 	}
 
+	p, ok := s.providers[st.Provider]
+	if !ok {
+		badRequest(w, r, publicError("unknown identity provider"))
+		return
+	}
+	cfg, err := p.config(r)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
 	cs := span.NewChild("oauth2/exchange_token")
-	tok, err := s.cfg.Exchange(ctx, code)
+	tok, err := cfg.Exchange(ctx, code,
+		oauth2.SetAuthURLParam("code_verifier", st.Verifier))
 	if err != nil {
-		serverError(w, errors.Wrap(err, "oauth2 token exchange failed"))
+		serverError(w, r, errors.Wrap(err, "oauth2 token exchange failed"))
 		return
 	}
 	cs.Finish()
 
-	cs = span.NewChild("gplus/get/me")
-	svc, err := plus.New(oauth2.NewClient(ctx, s.cfg.TokenSource(ctx, tok)))
+	ext, err := p.identity(ctx, cfg, tok, st.Nonce)
 	if err != nil {
-		serverError(w, errors.Wrap(err, "failed to construct g+ client"))
+		unauthorized(w, r, err)
 		return
 	}
-	me, err := plus.NewPeopleService(svc).Get("me").Do()
+	ext.Provider = p.Name
+	log := logging.FromContext(r.Context()).WithFields(logrus.Fields{"provider": p.Name, "subject": ext.GetSubject()})
+	log.Debug("retrieved external identity")
+
+	if st.Link {
+		cur, errF, err := s.authUser(ctx, r)
+		if err != nil {
+			errF(w, r, err)
+			return
+		} else if cur == nil {
+			unauthorized(w, r, publicError("required user to log in to link an identity"))
+			return
+		}
+		ext.LinkToUserID = cur.GetID()
+	}
+
+	cs = span.NewChild("authorize_external")
+	user, err := s.userSvc.AuthorizeExternal(ctx, ext)
 	if err != nil {
-		serverError(w, errors.Wrap(err, "failed to query user g+ profile"))
+		serverError(w, r, errors.Wrap(err, "failed to log in the user"))
 		return
 	}
 	cs.Finish()
-	log.WithField("google.id", me.Id).Debug("retrieved google user")
-
-	cs = span.NewChild("authorize_google")
-	user, err := s.userSvc.AuthorizeGoogle(ctx,
-		&pb.GoogleUser{
-			ID:          me.Id,
-			Email:       me.Emails[0].Value,
-			DisplayName: me.DisplayName,
-			PictureURL:  me.Image.Url,
-		})
-	if err != nil {
-		serverError(w, errors.Wrap(err, "failed to log in the user"))
+	if st.Link {
+		log.WithField("user.id", user.GetID()).Info("linked identity")
+		w.Header().Set("Location", "/identities")
+		w.WriteHeader(http.StatusFound)
 		return
 	}
-	log.WithField("id", user.ID).Info("authenticated user with google")
-	cs.Finish()
 
-	// save the user id to cookies
-	// TODO implement as sessions
-	co, err := sc.Encode("user", user.ID)
-	if err != nil {
-		serverError(w, errors.Wrap(err, "failed to encode the token"))
+	if err := s.startSession(ctx, w, r, user.GetID()); err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to start session"))
 		return
 	}
+	logins.Inc(p.Name)
 
-	http.SetCookie(w, &http.Cookie{
-		Name:  "user",
-		Path:  "/",
-		Value: co,
-	})
-
-	log.WithField("user.id", me.Id).Info("authenticated user")
-	w.Header().Set("Location", "/")
+	log.WithField("user.id", user.GetID()).Info("authenticated user")
+	w.Header().Set("Location", st.Next)
 	w.WriteHeader(http.StatusFound)
 }
 
@@ -320,126 +553,78 @@ func (s *server) logCoffee(w http.ResponseWriter, r *http.Request) {
 
 	user, errF, err := s.authUser(ctx, r)
 	if err != nil {
-		errF(w, err)
+		errF(w, r, err)
 		return
 	}
 	if user == nil {
-		badRequest(w, errors.New("required user to log in to post activity"))
+		badRequest(w, r, publicError("required user to log in to post activity"))
 		return
 	}
+	log := logging.FromContext(ctx)
 
 	if err := r.ParseMultipartForm(16 * 1024 * 1024); err != nil { // max 16 mb memory
-		badRequest(w, errors.Wrap(err, "failed to parse request"))
+		badRequest(w, r, publicWrap(err, "failed to parse request"))
 		return
 	}
 
-	// parse picture
-	var picture []byte
-
-	f, h, err := r.FormFile("picture")
-	if err == http.ErrMissingFile {
-		log.Debug("no file was uploaded")
-	} else if err != nil {
-		badRequest(w, errors.Wrap(err, "failed to parse form file"))
+	picture, errF, err := formFile(r, "picture")
+	if err != nil {
+		errF(w, r, err)
 		return
-	} else {
-		defer f.Close()
-
-		ct := h.Header.Get("Content-Type")
-		entry := log.WithField("content-type", ct).WithField("name", h.Filename)
-		entry.Debug("upload received")
-		if !strings.HasPrefix(ct, "image/") {
-			badRequest(w, errors.New("uploaded file is not a photo"))
-			return
-		}
+	}
 
-		picture, err = ioutil.ReadAll(f)
-		if err != nil {
-			serverError(w, errors.Wrap(err, "failed to read file"))
-			return
-		}
-		entry.WithField("size", len(picture)).Debug("uploaded file is read")
-	}
-
-	var (
-		drink         = r.FormValue("drink")
-		homebrew      = r.FormValue("homebrew") == "on"
-		amount        = r.FormValue("amount")
-		amountUnitStr = r.FormValue("amount_unit")
-		roasterName   = r.FormValue("roaster")
-		origin        = r.FormValue("origin")
-		method        = r.FormValue("brew-method")
-		notes         = r.FormValue("notes")
-	)
-
-	amountN, _ := strconv.ParseInt(amount, 10, 32)
-	var amountU pb.Activity_DrinkAmount_CaffeineUnit
-	switch amountUnitStr {
-	case "oz":
-		amountU = pb.Activity_DrinkAmount_OUNCES
-	case "shots":
-		amountU = pb.Activity_DrinkAmount_SHOTS
-	default:
-		amountU = pb.Activity_DrinkAmount_UNSPECIFIED
+	ts, err := ptypes.TimestampProto(time.Now())
+	if err != nil {
+		serverError(w, r, errors.Wrap(err, "cannot convert timestamp to proto"))
+		return
 	}
+	req, errs := parseActivityForm(r)
+	req.UserID = user.GetID()
+	req.Date = ts
+	req.Picture = picture
 
 	log.WithFields(logrus.Fields{
-		"user":          user.GetID(),
-		"drink":         drink,
-		"homebrew":      homebrew,
-		"roasterName":   roasterName,
-		"origin":        origin,
-		"method":        method,
-		"picture_bytes": len(picture),
-		"amount":        fmt.Sprintf("%d %s", amountN, amountU),
-		"notes":         notes,
+		"drink":         req.GetDrink(),
+		"homebrew":      req.GetHomebrew(),
+		"roasterName":   req.GetRoasterName(),
+		"origin":        req.GetOrigin(),
+		"method":        req.GetMethod(),
+		"picture_bytes": len(picture.GetData()),
+		"amount":        fmt.Sprintf("%d %s", req.GetAmount().GetN(), req.GetAmount().GetUnit()),
+		"notes":         req.GetNotes(),
 	}).Info("received form")
 
-	var pFile *pb.PostActivityRequest_File
-	if len(picture) > 0 {
-		pFile = &pb.PostActivityRequest_File{
-			Data:        picture,
-			ContentType: h.Header.Get("Content-Type"),
-			Filename:    h.Filename,
-		}
+	if validation.Activity(req, errs); len(errs) > 0 {
+		log.WithField("error", errs).Debug("invalid activity form")
+		renderHome(w, r, http.StatusBadRequest, user, errs, map[string]string{
+			"drink":   r.FormValue("drink"),
+			"roaster": r.FormValue("roaster"),
+			"notes":   r.FormValue("notes")})
+		return
 	}
 
-	ts, err := ptypes.TimestampProto(time.Now())
-	if err != nil {
-		serverError(w, errors.Wrap(err, "cannot convert timestamp to proto"))
-	}
-	resp, err := s.activitySvc.PostActivity(ctx, &pb.PostActivityRequest{
-		UserID: user.GetID(),
-		Date:   ts,
-		Amount: &pb.Activity_DrinkAmount{
-			N:    int32(amountN),
-			Unit: amountU,
-		},
-		Drink:       drink,
-		Origin:      origin,
-		RoasterName: roasterName,
-		Homebrew:    homebrew,
-		Method:      method,
-		Picture:     pFile,
-		Notes:       notes,
-	})
+	resp, err := s.activitySvc.PostActivity(ctx, req)
 	if err != nil {
-		serverError(w, errors.Wrap(err, "failed to save activity"))
+		serverError(w, r, errors.Wrap(err, "failed to save activity"))
 		return
 	}
 	log.WithField("id", resp.GetID()).Info("activity posted")
 
-	w.Header().Set("Location", fmt.Sprintf("/u/%s", user.GetID()))
+	w.Header().Set("Location", profileURL(user))
 	w.WriteHeader(http.StatusFound)
 }
 
+// autocompleteSize is the most roasters suggested to the user.
+const autocompleteSize = 10
+
 func (s *server) autocompleteRoaster(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	log := logging.FromContext(ctx)
 	span := trace.FromContext(ctx)
 
 	q := r.URL.Query().Get("data")
 	if len(q) > 100 {
-		badRequest(w, errors.New("request too long"))
+		badRequest(w, r, publicError("request too long"))
 		return
 	}
 	span.SetLabel("q", q)
@@ -448,23 +633,20 @@ func (s *server) autocompleteRoaster(w http.ResponseWriter, r *http.Request) {
 		Value string `json:"value"`
 	}
 
-	resp, err := s.roasterSvc.ListRoasters(ctx, new(pb.RoastersRequest))
+	resp, err := s.roasterSvc.ListRoasters(ctx, &pb.RoastersRequest{
+		Query:    q,
+		PageSize: autocompleteSize})
 	if err != nil {
-		serverError(w, errors.Wrap(err, "failed to query the roasters"))
+		serverError(w, r, errors.Wrap(err, "failed to query the roasters"))
 		return
 	}
 
-	cs := span.NewChild("filter")
-	defer cs.Finish()
 	var v []result
-	q = strings.ToLower(q)
 	for _, r := range resp.GetResults() {
-		if strings.Contains(strings.ToLower(r.GetName()), q) {
-			v = append(v, result{r.GetName()})
-		}
+		v = append(v, result{r.GetName()})
 	}
 	if err := json.NewEncoder(w).Encode(v); err != nil {
-		serverError(w, errors.Wrap(err, "failed to encode the response"))
+		serverError(w, r, errors.Wrap(err, "failed to encode the response"))
 		return
 	}
 	log.WithFields(logrus.Fields{
@@ -478,14 +660,15 @@ func (s *server) activity(w http.ResponseWriter, r *http.Request) {
 
 	user, ef, err := s.authUser(ctx, r)
 	if err != nil {
-		ef(w, err)
+		ef(w, r, err)
 		return
 	}
+	log := logging.FromContext(ctx)
 
 	idS := mux.Vars(r)["id"]
 	id, err := strconv.ParseInt(idS, 10, 64)
 	if err != nil {
-		badRequest(w, errors.Wrap(err, "bad activity id"))
+		badRequest(w, r, publicWrap(err, "bad activity id"))
 		return
 	}
 
@@ -494,83 +677,184 @@ func (s *server) activity(w http.ResponseWriter, r *http.Request) {
 
 	cs := span.NewChild("get_activity")
 	cs.SetLabel("id", idS)
-	ar, err := s.activitySvc.GetActivity(ctx, &pb.ActivityRequest{ID: id})
-	if err != nil {
-		serverError(w, errors.Wrap(err, "cannot get activity"))
+	ar, err := s.activitySvc.GetActivity(ctx, &pb.ActivityRequest{ID: id, ViewerID: user.GetID()})
+	if grpc.Code(err) == codes.NotFound {
+		errorCode(w, r, http.StatusNotFound, "not found", err)
+		return
+	} else if err != nil {
+		serverError(w, r, errors.Wrap(err, "cannot get activity"))
 		return
 	}
 	e.WithField("user.id", ar.GetUser().GetID()).Debug("retrieved activity")
 	cs.Finish()
 
-	tmpl := template.Must(template.ParseFiles(
-		filepath.Join("static", "template", "layout.html"),
-		filepath.Join("static", "template", "activity.html")))
-
-	if err := tmpl.Execute(w, map[string]interface{}{
+	render(w, r, http.StatusOK, "activity.html", map[string]interface{}{
 		"activity": ar,
-		"me":       user}); err != nil {
-		log.Fatal(err)
+		"me":       user})
+}
+
+// profileURL returns the path of the profile page of the user.
+func profileURL(u *pb.User) string {
+	if u.GetUsername() != "" {
+		return "/@" + u.GetUsername()
 	}
+	return "/u/" + u.GetID()
 }
 
+// userProfile serves the profile page at the numeric URL, which redirects to
+// the username URL once the user has a username.
 func (s *server) userProfile(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	span := trace.FromContext(ctx)
-
 	userID := mux.Vars(r)["id"]
-	span.SetLabel("user/id", userID)
+	trace.FromContext(ctx).SetLabel("user/id", userID)
 
+	// the redirect would tell the username of a user whose profile is
+	// hidden from the viewer
 	me, ef, err := s.authUser(ctx, r)
 	if err != nil {
-		ef(w, err)
+		ef(w, r, err)
 		return
 	}
+	user, found, err := s.visibleUser(ctx, userID, me.GetID())
+	if err != nil {
+		serverError(w, r, err)
+		return
+	} else if !found {
+		errorCode(w, r, http.StatusNotFound, "not found", publicError("user not found"))
+		return
+	} else if user.GetUsername() != "" {
+		http.Redirect(w, r, profileURL(user), http.StatusMovedPermanently)
+		return
+	}
+	s.renderProfile(w, r, user)
+}
 
-	userResp, err := s.getUser(ctx, userID)
+// usernameProfile serves the profile page at /@{username}, redirecting to the
+// URL with the username spelled as the user chose.
+func (s *server) usernameProfile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := mux.Vars(r)["username"]
+
+	cs := trace.FromContext(ctx).NewChild("rpc.Sent/GetUserByUsername")
+	userResp, err := s.userSvc.GetUserByUsername(ctx, &pb.UsernameRequest{Username: name})
+	cs.Finish()
 	if err != nil {
-		serverError(w, errors.Wrap(err, "failed to look up the user"))
+		serverError(w, r, errors.Wrap(err, "failed to look up the user"))
 		return
 	} else if !userResp.GetFound() {
-		errorCode(w, http.StatusNotFound, "not found", errors.New("user not found"))
+		errorCode(w, r, http.StatusNotFound, "not found", publicError("user not found"))
+		return
+	}
+
+	// the redirect would tell that a user whose profile is hidden from the
+	// viewer has the username
+	me, ef, err := s.authUser(ctx, r)
+	if err != nil {
+		ef(w, r, err)
+		return
+	}
+	user, found, err := s.visibleUser(ctx, userResp.GetUser().GetID(), me.GetID())
+	if err != nil {
+		serverError(w, r, err)
+		return
+	} else if !found {
+		errorCode(w, r, http.StatusNotFound, "not found", publicError("user not found"))
+		return
+	} else if user.GetUsername() != name {
+		http.Redirect(w, r, profileURL(user), http.StatusMovedPermanently)
+		return
+	}
+	s.renderProfile(w, r, user)
+}
+
+func (s *server) renderProfile(w http.ResponseWriter, r *http.Request, user *pb.User) {
+	ctx := r.Context()
+	span := trace.FromContext(ctx)
+
+	me, ef, err := s.authUser(ctx, r)
+	if err != nil {
+		ef(w, r, err)
 		return
 	}
 
 	cs := span.NewChild("get_activities")
-	cs.SetLabel("user/id", userID)
+	cs.SetLabel("user/id", user.GetID())
 	ar, err := s.activitySvc.GetUserActivities(ctx,
-		&pb.UserActivitiesRequest{UserID: userID})
-	if err != nil {
-		serverError(w, errors.Wrap(err, "failed to query activities"))
+		&pb.UserActivitiesRequest{UserID: user.GetID(), ViewerID: me.GetID()})
+	if grpc.Code(err) == codes.NotFound {
+		errorCode(w, r, http.StatusNotFound, "not found", err)
+		return
+	} else if err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to query activities"))
 		return
 	}
 	cs.Finish()
 
-	tmpl := template.Must(template.ParseFiles(
-		filepath.Join("static", "template", "layout.html"),
-		filepath.Join("static", "template", "profile.html")))
-	if err := tmpl.Execute(w, map[string]interface{}{
+	follow := new(pb.FollowResponse)
+	if me != nil && me.GetID() != user.GetID() {
+		follow, err = s.userSvc.GetFollow(ctx, &pb.FollowRequest{UserID: user.GetID(), FollowerID: me.GetID()})
+		if err != nil {
+			serverError(w, r, errors.Wrap(err, "failed to look up follow"))
+			return
+		}
+	}
+
+	render(w, r, http.StatusOK, "profile.html", map[string]interface{}{
 		"me":         me,
-		"user":       userResp.GetUser(),
+		"user":       user,
+		"follow":     follow,
 		"activities": ar.GetActivities(),
 		"methods":    methodIcons,
-		"drinks":     drinks}); err != nil {
-		log.Fatal(err)
-	}
+		"drinks":     drinks})
 }
 
-func errorCode(w http.ResponseWriter, code int, msg string, err error) {
-	log.WithField("http.status", code).WithField("error", err).Warn(msg)
-	w.WriteHeader(code)
-	fmt.Fprint(w, errors.Wrap(err, msg))
-}
+// parseActivityForm reads the activity from the fields of the form, with
+// the errors of the fields that cannot be parsed.
+func parseActivityForm(r *http.Request) (*pb.PostActivityRequest, validation.Errors) {
+	errs := make(validation.Errors)
+	req := &pb.PostActivityRequest{
+		Drink:       strings.TrimSpace(r.FormValue("drink")),
+		Homebrew:    r.FormValue("homebrew") == "on",
+		RoasterName: strings.TrimSpace(r.FormValue("roaster")),
+		Method:      r.FormValue("brew-method"),
+		Origin:      r.FormValue("origin"),
+		Notes:       r.FormValue("notes"),
+	}
+	if v := r.FormValue("visibility"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 32)
+		if _, ok := pb.Visibility_name[int32(n)]; err != nil || !ok {
+			errs.Add("visibility", "unknown visibility")
+		} else {
+			req.Visibility = pb.Visibility(n)
+		}
+	}
 
-var (
-	originCountries = map[string][]string{
-		"Africa":   {"Kenya", "Ethiophia", "Nigeria", "Burundi", "Rwanda"},
-		"Americas": {"Colombia", "Venezuela", "Brazil", "Peru", "Cuba", "Ecuador", "Honduras", "Mexico", "Costa Rica"},
-		"Asia":     {"Indonesia", "India", "Vietnam"},
+	// the amount is only sent with a unit for the drinks the form knows
+	unit := r.FormValue("amount_unit")
+	if unit == "" {
+		return req, errs
+	}
+	n, err := strconv.ParseInt(r.FormValue("amount"), 10, 32)
+	if err != nil {
+		errs.Add("amount", "amount must be a number")
+		return req, errs
 	}
+	req.Amount = &pb.Activity_DrinkAmount{N: int32(n)}
+	switch unit {
+	case "oz":
+		req.Amount.Unit = pb.Activity_DrinkAmount_OUNCES
+	case "ml": // from users who prefer metric units, stored in ounces
+		req.Amount.N = int32(mlToOunces(n))
+		req.Amount.Unit = pb.Activity_DrinkAmount_OUNCES
+	case "shots":
+		req.Amount.Unit = pb.Activity_DrinkAmount_SHOTS
+	default:
+		errs.Add("amount", "unknown amount unit %q", unit)
+	}
+	return req, errs
+}
 
+var (
 	drinks = map[string]bool{
 		// espresso-based:
 		"Latte":          true,
@@ -607,27 +891,11 @@ var (
 		"Moka Pot":       "moka.png",
 		"Turkish coffee": "turkish.png",
 	}
-	methodsList = []struct{ Name, Icon string }{
-		{"Espresso", methodIcons["Espresso"]},
-		{"Chemex", methodIcons["Chemex"]},
-		{"Aeropress", methodIcons["Aeropress"]},
-		{"Hario V60", methodIcons["Hario V60"]},
-		{"French press", methodIcons["French press"]},
-		{"Dripper", methodIcons["Dripper"]},
-		{"Kyoto Dripper", methodIcons["Kyoto Dripper"]},
-		{"Moka Pot", methodIcons["Moka Pot"]},
-		{"Turkish coffee", methodIcons["Turkish coffee"]},
-	}
+	methodsList = func() []struct{ Name, Icon string } {
+		var l []struct{ Name, Icon string }
+		for _, m := range validation.Methods {
+			l = append(l, struct{ Name, Icon string }{m, methodIcons[m]})
+		}
+		return l
+	}()
 )
-
-func unauthorized(w http.ResponseWriter, err error) {
-	errorCode(w, http.StatusUnauthorized, "unauthorized", err)
-}
-
-func badRequest(w http.ResponseWriter, err error) {
-	errorCode(w, http.StatusBadRequest, "bad request", err)
-}
-
-func serverError(w http.ResponseWriter, err error) {
-	errorCode(w, http.StatusInternalServerError, "server error", err)
-}
@@ -0,0 +1,67 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMain(m *testing.M) {
+	logrus.SetOutput(ioutil.Discard)
+	log = logrus.NewEntry(logrus.StandardLogger())
+
+	var err error
+	if cookieCodecs, err = loadCookieCodecs("", true); err != nil {
+		log.Fatal(err)
+	}
+	os.Exit(m.Run())
+}
+
+func TestParseActivityFormVisibility(t *testing.T) {
+	tests := []struct {
+		v       string
+		want    pb.Visibility
+		wantErr bool
+	}{
+		{v: "", want: pb.Visibility_DEFAULT},
+		{v: "1", want: pb.Visibility_PUBLIC},
+		{v: "3", want: pb.Visibility_PRIVATE},
+		{v: "4", wantErr: true},
+		{v: "-1", wantErr: true},
+		{v: "public", wantErr: true},
+		{v: "99999999999", wantErr: true},
+	}
+	for _, tt := range tests {
+		f := url.Values{"visibility": {tt.v}}
+		r := httptest.NewRequest(http.MethodPost, "/coffee", strings.NewReader(f.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req, errs := parseActivityForm(r)
+		if _, gotErr := errs["visibility"]; gotErr != tt.wantErr {
+			t.Errorf("visibility %q: got error %v, expected %v", tt.v, gotErr, tt.wantErr)
+		}
+		if req.GetVisibility() != tt.want {
+			t.Errorf("visibility %q: got %v, expected %v", tt.v, req.GetVisibility(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+
+	"github.com/ahmetb/coffeelog/metrics"
+	"github.com/gorilla/mux"
+)
+
+var logins = metrics.NewCounter("coffeelog_logins_total",
+	"Number of logins, by method.", "method")
+
+// routeTemplate returns the path template of the route the request matches,
+// such as "/u/{id:[0-9]+}", so that the metrics are not split by ids.
+func routeTemplate(router *mux.Router) func(*http.Request) string {
+	return func(r *http.Request) string {
+		var m mux.RouteMatch
+		if !router.Match(r, &m) {
+			return "unmatched"
+		}
+		t, err := m.Route.GetPathTemplate()
+		if err != nil {
+			return "unknown"
+		}
+		return t
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+const (
+	oauthStateCookie = "oauth2_state"
+	oauthStateMaxAge = 10 * time.Minute
+)
+
+// oauthState is kept in a signed cookie for the duration of a login, so that
+// the callback can verify it belongs to a login started by the same browser.
+type oauthState struct {
+	State    string    `json:"s"`
+	Verifier string    `json:"v"` // PKCE code verifier
+	Nonce    string    `json:"o"` // OIDC nonce, bound to the ID token
+	Provider string    `json:"p"`
+	Link     bool      `json:"l"` // link the identity to the logged in user
+	Next     string    `json:"n"`
+	Expires  time.Time `json:"e"`
+}
+
+// randomString returns n random bytes encoded as unpadded base64url.
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to read random bytes")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// pkceChallenge returns the S256 code challenge of the verifier (RFC 7636).
+func pkceChallenge(verifier string) string {
+	h := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
+
+// localRedirect returns v if it is a path on this site, and "/" otherwise,
+// so that the login flow cannot be used as an open redirector.
+func localRedirect(v string) string {
+	u, err := url.Parse(v)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.User != nil ||
+		!strings.HasPrefix(u.Path, "/") || strings.HasPrefix(v, "//") || strings.HasPrefix(v, "/\\") {
+		return "/"
+	}
+	if strings.HasPrefix(u.Path, "/login/") {
+		return "/"
+	}
+	switch u.Path {
+	case "/login", "/logout", "/oauth2callback", "/register", "/verify", "/forgot", "/reset":
+		return "/"
+	}
+	return u.RequestURI()
+}
+
+// loginRedirectTarget picks the page to return to after logging in, from the
+// "next" parameter or the page the user came from.
+func loginRedirectTarget(r *http.Request) string {
+	if v := r.URL.Query().Get("next"); v != "" {
+		return localRedirect(v)
+	}
+	ref, err := url.Parse(r.Referer())
+	if err != nil || ref.Host != r.Host {
+		return "/"
+	}
+	return localRedirect(ref.RequestURI())
+}
+
+// startOAuth generates the state, PKCE verifier and nonce of a new login with
+// the provider, stores them in the state cookie and returns the URL of the
+// consent page.
+func startOAuth(w http.ResponseWriter, r *http.Request, p *loginProvider, link bool) (string, error) {
+	cfg, err := p.config(r)
+	if err != nil {
+		return "", err
+	}
+	var rnd [3]string
+	for i := range rnd {
+		if rnd[i], err = randomString(32); err != nil {
+			return "", err
+		}
+	}
+	state, verifier, nonce := rnd[0], rnd[1], rnd[2]
+	v := oauthState{
+		State:    state,
+		Verifier: verifier,
+		Nonce:    nonce,
+		Provider: p.Name,
+		Link:     link,
+		Next:     loginRedirectTarget(r),
+		Expires:  time.Now().Add(oauthStateMaxAge)}
+	co, err := encodeCookie(oauthStateCookie, v)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode the state cookie")
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Path:     "/oauth2callback",
+		Value:    co,
+		MaxAge:   int(oauthStateMaxAge.Seconds()),
+		Secure:   isHTTPS(r),
+		HttpOnly: true,
+	})
+	return cfg.AuthCodeURL(state,
+		oauth2.SetAuthURLParam("nonce", nonce),
+		oauth2.SetAuthURLParam("code_challenge", pkceChallenge(verifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256")), nil
+}
+
+// verifyOAuthState checks the state parameter of the callback against the
+// state cookie, which is cleared so that it cannot be used again.
+func verifyOAuthState(w http.ResponseWriter, r *http.Request) (*oauthState, error) {
+	c, err := r.Cookie(oauthStateCookie)
+	if err == http.ErrNoCookie {
+		return nil, publicError("missing oauth2 state cookie")
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oauthStateCookie,
+		Path:     "/oauth2callback",
+		MaxAge:   -1,
+		Secure:   isHTTPS(r),
+		HttpOnly: true,
+	})
+	var v oauthState
+	if err := decodeCookie(oauthStateCookie, c.Value, &v); err != nil {
+		return nil, errors.Wrap(err, "failed to decode the state cookie")
+	}
+	if time.Now().After(v.Expires) {
+		return nil, publicError("oauth2 state expired")
+	}
+	state := r.URL.Query().Get("state")
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(v.State)) != 1 {
+		return nil, publicError("wrong oauth2 state")
+	}
+	return &v, nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ahmetb/coffeelog/caller"
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc"
+)
+
+// fakeUserDirectory implements the user directory RPCs of the login.
+type fakeUserDirectory struct {
+	pb.UserDirectoryClient
+	authorized []*pb.AuthorizeExternalRequest
+}
+
+func (f *fakeUserDirectory) AuthorizeExternal(ctx context.Context, in *pb.AuthorizeExternalRequest, opts ...grpc.CallOption) (*pb.User, error) {
+	f.authorized = append(f.authorized, in)
+	return &pb.User{ID: "user-1"}, nil
+}
+
+func (f *fakeUserDirectory) CreateSession(ctx context.Context, in *pb.CreateSessionRequest, opts ...grpc.CallOption) (*pb.CreateSessionResponse, error) {
+	if err := caller.Check(ctx, in.GetUserID()); err != nil {
+		return nil, err
+	}
+	return &pb.CreateSessionResponse{
+		Session: &pb.Session{ID: "session-1", UserID: in.GetUserID()},
+		Secret:  "secret"}, nil
+}
+
+// startLogin starts a login with the provider and returns the state cookie
+// and the parameters of the consent page URL.
+func startLogin(t *testing.T, p *loginProvider) (*http.Cookie, url.Values) {
+	w := httptest.NewRecorder()
+	u, err := startOAuth(w, httptest.NewRequest(http.MethodGet, "/login/stub?next=/activity/1", nil), p, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := url.Parse(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range w.Result().Cookies() {
+		if c.Name == oauthStateCookie {
+			return c, v.Query()
+		}
+	}
+	t.Fatal("no state cookie set")
+	return nil, nil
+}
+
+func TestOAuth2Callback(t *testing.T) {
+	stub := newStubOIDC(t)
+	defer stub.Close()
+	p := &loginProvider{
+		Name: "stub",
+		cfg:  &oauth2.Config{ClientID: stubClientID, ClientSecret: "secret"},
+		oidc: newOIDCProvider(stub.URL, stubClientID)}
+
+	tests := []struct {
+		name         string
+		state        func(url.Values) string
+		issue        bool // whether the provider accepts the code
+		noCookie     bool
+		want         int
+		wantLogin    bool
+		wantLocation string
+	}{
+		{name: "success",
+			state: func(v url.Values) string { return v.Get("state") },
+			issue: true,
+			want:  http.StatusFound, wantLogin: true, wantLocation: "/activity/1"},
+		{name: "state mismatch",
+			state: func(url.Values) string { return "forged" },
+			issue: true,
+			want:  http.StatusBadRequest},
+		{name: "no state cookie",
+			state:    func(v url.Values) string { return v.Get("state") },
+			issue:    true,
+			noCookie: true,
+			want:     http.StatusBadRequest},
+		{name: "code exchange failure",
+			state: func(v url.Values) string { return v.Get("state") },
+			want:  http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		users := new(fakeUserDirectory)
+		s := &server{providers: map[string]*loginProvider{p.Name: p}, userSvc: users}
+		cookie, params := startLogin(t, p)
+		if params.Get("code_challenge") == "" || params.Get("nonce") == "" {
+			t.Fatalf("%s: consent page url without pkce challenge or nonce: %v", tt.name, params)
+		}
+		code := "code-" + tt.name
+		if tt.issue {
+			stub.issue(code, params.Get("nonce"))
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/oauth2callback?"+url.Values{
+			"state": {tt.state(params)},
+			"code":  {code}}.Encode(), nil)
+		if !tt.noCookie {
+			r.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		s.oauth2Callback(w, r)
+
+		if w.Code != tt.want {
+			t.Errorf("%s: got status %d, expected %d", tt.name, w.Code, tt.want)
+		}
+		if loc := w.Header().Get("Location"); loc != tt.wantLocation {
+			t.Errorf("%s: redirected to %q, expected %q", tt.name, loc, tt.wantLocation)
+		}
+		var session bool
+		for _, c := range w.Result().Cookies() {
+			session = session || (c.Name == sessionCookie && c.Value != "")
+		}
+		if loggedIn := session && len(users.authorized) == 1; loggedIn != tt.wantLogin {
+			t.Errorf("%s: logged in: %v, expected %v", tt.name, loggedIn, tt.wantLogin)
+		}
+		if tt.wantLogin {
+			if a := users.authorized[0]; a.GetProvider() != "stub" || a.GetSubject() != "12345" ||
+				a.GetProfile().GetEmail() != "jane@example.com" {
+				t.Errorf("%s: wrong external identity: %v", tt.name, a)
+			}
+		}
+	}
+}
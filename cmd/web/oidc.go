@@ -0,0 +1,315 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/trace"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context/ctxhttp"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jws"
+)
+
+const (
+	googleIssuer = "https://accounts.google.com"
+
+	jwksMinRefresh = time.Minute     // keys are refetched at most this often
+	idTokenSkew    = 2 * time.Minute // tolerated clock difference with the issuer
+)
+
+// oidcDiscovery is the part of the OpenID Provider metadata that is used.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcProvider discovers the endpoints of an OpenID Connect provider and
+// verifies the ID tokens it issues.
+type oidcProvider struct {
+	issuer   string
+	clientID string
+	client   *http.Client
+
+	mu          sync.Mutex
+	doc         *oidcDiscovery
+	keys        map[string]*rsa.PublicKey
+	keysFetched time.Time
+}
+
+func newOIDCProvider(issuer, clientID string) *oidcProvider {
+	return &oidcProvider{
+		issuer:   strings.TrimSuffix(issuer, "/"),
+		clientID: clientID,
+		client:   &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (p *oidcProvider) getJSON(ctx context.Context, url string, v interface{}) error {
+	resp, err := ctxhttp.Get(ctx, p.client, url)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get %s", url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status from %s: %s", url, resp.Status)
+	}
+	return errors.Wrapf(json.NewDecoder(resp.Body).Decode(v), "failed to decode %s", url)
+}
+
+// discover returns the provider metadata, fetching it on first use.
+func (p *oidcProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	doc := p.doc
+	p.mu.Unlock()
+	if doc != nil {
+		return doc, nil
+	}
+	return p.fetchDiscovery(ctx)
+}
+
+// fetchDiscovery fetches the provider metadata and caches it. The lock is not
+// held during the request so that a slow provider does not block the logins
+// using the cached metadata.
+func (p *oidcProvider) fetchDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	log := logging.FromContext(ctx)
+	cs := trace.FromContext(ctx).NewChild("oidc/discovery")
+	defer cs.Finish()
+	var doc oidcDiscovery
+	if err := p.getJSON(ctx, p.issuer+"/.well-known/openid-configuration", &doc); err != nil {
+		return nil, err
+	}
+	if doc.Issuer != p.issuer {
+		return nil, errors.Errorf("discovery document issuer %q does not match %q", doc.Issuer, p.issuer)
+	} else if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, errors.New("discovery document is missing endpoints")
+	}
+	log.WithField("issuer", doc.Issuer).Debug("discovered oidc provider")
+	p.mu.Lock()
+	p.doc = &doc
+	p.mu.Unlock()
+	return &doc, nil
+}
+
+// endpoint returns the OAuth2 endpoints of the provider.
+func (p *oidcProvider) endpoint(ctx context.Context) (oauth2.Endpoint, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return oauth2.Endpoint{}, err
+	}
+	return oauth2.Endpoint{AuthURL: doc.AuthorizationEndpoint, TokenURL: doc.TokenEndpoint}, nil
+}
+
+// publicKey returns the signing key with the given ID, refetching the key set
+// if the key is not known yet (i.e. the provider rotated its keys).
+func (p *oidcProvider) publicKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	log := logging.FromContext(ctx)
+	p.mu.Lock()
+	k, ok := p.keys[kid]
+	fetched, stale := p.keys != nil, time.Since(p.keysFetched) >= jwksMinRefresh
+	p.mu.Unlock()
+	if ok {
+		return k, nil
+	} else if !stale {
+		return nil, errors.Errorf("unknown signing key %q", kid)
+	}
+
+	// The provider may have moved its key set along with the rotation, so
+	// the metadata is refreshed too unless it was just fetched for the
+	// first keys.
+	var doc *oidcDiscovery
+	var err error
+	if fetched {
+		doc, err = p.fetchDiscovery(ctx)
+	} else {
+		doc, err = p.discover(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	keys, err := p.fetchKeys(ctx, doc.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.keys, p.keysFetched = keys, time.Now()
+	p.mu.Unlock()
+	log.WithField("count", len(keys)).Debug("fetched oidc signing keys")
+
+	if k, ok = keys[kid]; !ok {
+		return nil, errors.Errorf("unknown signing key %q", kid)
+	}
+	return k, nil
+}
+
+// fetchKeys fetches the RSA signing keys in the key set at url.
+func (p *oidcProvider) fetchKeys(ctx context.Context, url string) (map[string]*rsa.PublicKey, error) {
+	cs := trace.FromContext(ctx).NewChild("oidc/jwks")
+	defer cs.Finish()
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(ctx, url, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid modulus of key %q", k.Kid)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid exponent of key %q", k.Kid)
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	return keys, nil
+}
+
+// audience is the "aud" claim, which is either a string or an array.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = audience{s}
+		return nil
+	}
+	var v []string
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+func (a audience) contains(v string) bool {
+	for _, s := range a {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// flexBool is a boolean claim that some providers encode as a string.
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(v []byte) error {
+	*b = flexBool(string(v) == "true" || string(v) == `"true"`)
+	return nil
+}
+
+type idTokenClaims struct {
+	Issuer        string   `json:"iss"`
+	Subject       string   `json:"sub"`
+	Audience      audience `json:"aud"`
+	Expiry        int64    `json:"exp"`
+	IssuedAt      int64    `json:"iat"`
+	Nonce         string   `json:"nonce"`
+	Email         string   `json:"email"`
+	EmailVerified flexBool `json:"email_verified"`
+	Name          string   `json:"name"`
+	Picture       string   `json:"picture"`
+}
+
+// verify checks the signature, issuer, audience, expiry and nonce of the ID
+// token and returns its claims.
+func (p *oidcProvider) verify(ctx context.Context, token, nonce string) (*idTokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed id token")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed id token header")
+	}
+	var hdr struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(b, &hdr); err != nil {
+		return nil, errors.Wrap(err, "malformed id token header")
+	} else if hdr.Alg != "RS256" {
+		return nil, errors.Errorf("unsupported id token algorithm %q", hdr.Alg)
+	}
+	key, err := p.publicKey(ctx, hdr.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := jws.Verify(token, key); err != nil {
+		return nil, errors.Wrap(err, "invalid id token signature")
+	}
+
+	if b, err = base64.RawURLEncoding.DecodeString(parts[1]); err != nil {
+		return nil, errors.Wrap(err, "malformed id token payload")
+	}
+	var c idTokenClaims
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, errors.Wrap(err, "malformed id token payload")
+	}
+	now := time.Now()
+	if c.Issuer != p.issuer && !(p.issuer == googleIssuer && c.Issuer == strings.TrimPrefix(googleIssuer, "https://")) {
+		return nil, errors.Errorf("unexpected id token issuer %q", c.Issuer)
+	} else if !c.Audience.contains(p.clientID) {
+		return nil, errors.New("id token was not issued for this client")
+	} else if now.After(time.Unix(c.Expiry, 0).Add(idTokenSkew)) {
+		return nil, errors.New("id token expired")
+	} else if now.Add(idTokenSkew).Before(time.Unix(c.IssuedAt, 0)) {
+		return nil, errors.New("id token issued in the future")
+	} else if nonce == "" || c.Nonce != nonce {
+		return nil, errors.New("id token nonce does not match")
+	} else if c.Subject == "" {
+		return nil, errors.New("id token has no subject")
+	}
+	return &c, nil
+}
+
+// profile maps the ID token claims to the user directory representation.
+func (c *idTokenClaims) profile() *pb.ExternalProfile {
+	p := &pb.ExternalProfile{
+		DisplayName:   c.Name,
+		PictureURL:    c.Picture,
+		Email:         c.Email,
+		EmailVerified: bool(c.EmailVerified)}
+	if p.DisplayName == "" && c.Email != "" {
+		p.DisplayName = strings.SplitN(c.Email, "@", 2)[0]
+	}
+	return p
+}
@@ -0,0 +1,303 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2/jws"
+)
+
+const stubClientID = "coffeelog-test"
+
+// stubOIDC is an OpenID Connect provider that issues ID tokens for the grant
+// codes registered with issue.
+type stubOIDC struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	key         *rsa.PrivateKey
+	kid         string
+	rotations   int
+	codes       map[string]string // grant code to nonce
+	discoveries int
+	keySets     int
+}
+
+func newStubOIDC(t *testing.T) *stubOIDC {
+	s := &stubOIDC{codes: make(map[string]string)}
+	s.rotate(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/jwks", s.jwks)
+	mux.HandleFunc("/token", s.token)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// rotate replaces the signing key with a new one with a new ID.
+func (s *stubOIDC) rotate(t *testing.T) {
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rotations++
+	s.key, s.kid = k, "key-"+strconv.Itoa(s.rotations)
+}
+
+// issue makes the token endpoint accept code and put nonce in the ID token.
+func (s *stubOIDC) issue(code, nonce string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.codes[code] = nonce
+}
+
+// claims returns the claims of a valid ID token.
+func (s *stubOIDC) claims(nonce string) *jws.ClaimSet {
+	now := time.Now()
+	return &jws.ClaimSet{
+		Iss: s.URL,
+		Aud: stubClientID,
+		Sub: "12345",
+		Iat: now.Unix(),
+		Exp: now.Add(time.Hour).Unix(),
+		PrivateClaims: map[string]interface{}{
+			"nonce":          nonce,
+			"email":          "jane@example.com",
+			"email_verified": true,
+			"name":           "Jane Doe"}}
+}
+
+// sign signs the claims with the current key of the provider.
+func (s *stubOIDC) sign(c *jws.ClaimSet) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return jws.Encode(&jws.Header{Algorithm: "RS256", Typ: "JWT", KeyID: s.kid}, c, s.key)
+}
+
+func (s *stubOIDC) idToken(t *testing.T, c *jws.ClaimSet) string {
+	tok, err := s.sign(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tok
+}
+
+func signIDToken(t *testing.T, key *rsa.PrivateKey, kid string, c *jws.ClaimSet) string {
+	tok, err := jws.Encode(&jws.Header{Algorithm: "RS256", Typ: "JWT", KeyID: kid}, c, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tok
+}
+
+func (s *stubOIDC) discovery(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.discoveries++
+	s.mu.Unlock()
+	json.NewEncoder(w).Encode(oidcDiscovery{
+		Issuer:                s.URL,
+		AuthorizationEndpoint: s.URL + "/authorize",
+		TokenEndpoint:         s.URL + "/token",
+		JWKSURI:               s.URL + "/jwks"})
+}
+
+func (s *stubOIDC) jwks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keySets++
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": s.kid,
+			"n":   base64.RawURLEncoding.EncodeToString(s.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.key.E)).Bytes()),
+		}}})
+}
+
+func (s *stubOIDC) token(w http.ResponseWriter, r *http.Request) {
+	code := r.FormValue("code")
+	s.mu.Lock()
+	nonce, ok := s.codes[code]
+	delete(s.codes, code)
+	s.mu.Unlock()
+	if !ok || r.FormValue("code_verifier") == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+	tok, err := s.sign(s.claims(nonce))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access-" + code,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     tok})
+}
+
+func TestOIDCDiscovery(t *testing.T) {
+	stub := newStubOIDC(t)
+	defer stub.Close()
+	p := newOIDCProvider(stub.URL+"/", stubClientID)
+
+	for i := 0; i < 2; i++ {
+		ep, err := p.endpoint(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ep.AuthURL != stub.URL+"/authorize" || ep.TokenURL != stub.URL+"/token" {
+			t.Fatalf("wrong endpoints: %+v", ep)
+		}
+	}
+	if stub.discoveries != 1 {
+		t.Fatalf("discovery document fetched %d times, expected 1", stub.discoveries)
+	}
+
+	if _, err := newOIDCProvider(stub.URL+"/other", stubClientID).endpoint(context.Background()); err == nil {
+		t.Fatal("expected an error for an issuer without a discovery document")
+	}
+}
+
+func TestOIDCVerify(t *testing.T) {
+	stub := newStubOIDC(t)
+	defer stub.Close()
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const nonce = "n0nce"
+
+	tests := []struct {
+		name  string
+		token func() string
+		err   string // expected in the error, empty if the token is valid
+	}{
+		{name: "valid",
+			token: func() string { return stub.idToken(t, stub.claims(nonce)) }},
+		{name: "signed with another key",
+			token: func() string { return signIDToken(t, other, stub.kid, stub.claims(nonce)) },
+			err:   "signature"},
+		{name: "unknown key",
+			token: func() string { return signIDToken(t, other, "unknown", stub.claims(nonce)) },
+			err:   "unknown signing key"},
+		{name: "not signed",
+			token: func() string {
+				tok := stub.idToken(t, stub.claims(nonce))
+				return base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) +
+					tok[strings.Index(tok, "."):strings.LastIndex(tok, ".")+1]
+			},
+			err: "algorithm"},
+		{name: "another issuer",
+			token: func() string {
+				c := stub.claims(nonce)
+				c.Iss = "https://evil.example.com"
+				return stub.idToken(t, c)
+			},
+			err: "issuer"},
+		{name: "another client",
+			token: func() string {
+				c := stub.claims(nonce)
+				c.Aud = "another-client"
+				return stub.idToken(t, c)
+			},
+			err: "not issued for this client"},
+		{name: "expired",
+			token: func() string {
+				c := stub.claims(nonce)
+				c.Iat = time.Now().Add(-2 * time.Hour).Unix()
+				c.Exp = time.Now().Add(-time.Hour).Unix()
+				return stub.idToken(t, c)
+			},
+			err: "expired"},
+		{name: "expired within the clock skew",
+			token: func() string {
+				c := stub.claims(nonce)
+				c.Iat = time.Now().Add(-time.Hour).Unix()
+				c.Exp = time.Now().Add(-idTokenSkew / 2).Unix()
+				return stub.idToken(t, c)
+			}},
+		{name: "another login",
+			token: func() string { return stub.idToken(t, stub.claims("other")) },
+			err:   "nonce"},
+	}
+	for _, tt := range tests {
+		// a new provider each time, so that the unknown key does not
+		// depend on the refresh interval of the key set
+		p := newOIDCProvider(stub.URL, stubClientID)
+		c, err := p.verify(context.Background(), tt.token(), nonce)
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.name, err)
+			} else if c.Subject != "12345" || c.Email != "jane@example.com" || !bool(c.EmailVerified) {
+				t.Errorf("%s: wrong claims: %+v", tt.name, c)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: expected an error with %q, got: %v", tt.name, tt.err, err)
+		}
+	}
+}
+
+func TestOIDCKeyRotation(t *testing.T) {
+	stub := newStubOIDC(t)
+	defer stub.Close()
+	p := newOIDCProvider(stub.URL, stubClientID)
+	ctx := context.Background()
+
+	if _, err := p.verify(ctx, stub.idToken(t, stub.claims("n")), "n"); err != nil {
+		t.Fatal(err)
+	}
+	if stub.discoveries != 1 || stub.keySets != 1 {
+		t.Fatalf("expected one fetch of each document, got %d discoveries and %d key sets", stub.discoveries, stub.keySets)
+	}
+
+	stub.rotate(t)
+	tok := stub.idToken(t, stub.claims("n"))
+	if _, err := p.verify(ctx, tok, "n"); err == nil {
+		t.Fatal("expected the new key to be unknown until the key set can be refreshed")
+	} else if stub.keySets != 1 {
+		t.Fatalf("key set refetched within %v", jwksMinRefresh)
+	}
+
+	p.mu.Lock()
+	p.keysFetched = time.Now().Add(-jwksMinRefresh)
+	p.mu.Unlock()
+	if _, err := p.verify(ctx, tok, "n"); err != nil {
+		t.Fatalf("token signed with the rotated key: %v", err)
+	}
+	if stub.discoveries != 2 || stub.keySets != 2 {
+		t.Fatalf("expected the documents to be refreshed, got %d discoveries and %d key sets", stub.discoveries, stub.keySets)
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmetb/coffeelog/version"
+)
+
+type jsonObject map[string]interface{}
+
+var (
+	muxVarPattern = regexp.MustCompile(`\{([^}:]+)(:[^}]+)?\}`)
+	timeType      = reflect.TypeOf(time.Time{})
+)
+
+func (s *server) apiOpenAPI(w http.ResponseWriter, r *http.Request) {
+	apiRespond(w, http.StatusOK, openAPIDocument(s.apiRoutes()))
+}
+
+// openAPIDocument generates an OpenAPI 3 document describing the given API
+// routes. Schemas are derived from the Go types of the request and response
+// bodies.
+func openAPIDocument(routes []apiRoute) jsonObject {
+	schemas := jsonObject{}
+	paths := jsonObject{}
+	for _, rt := range routes {
+		path := muxVarPattern.ReplaceAllString(apiPrefix+rt.path, "{$1}")
+		item, ok := paths[path].(jsonObject)
+		if !ok {
+			item = jsonObject{}
+			paths[path] = item
+		}
+
+		var params []jsonObject
+		for _, m := range muxVarPattern.FindAllStringSubmatch(rt.path, -1) {
+			params = append(params, jsonObject{
+				"name": m[1], "in": "path", "required": true,
+				"schema": jsonObject{"type": "string"}})
+		}
+		if rt.paginated {
+			params = append(params,
+				jsonObject{"name": "page_size", "in": "query",
+					"description": "maximum number of results to return (default " + strconv.Itoa(defaultPageSize) + ", max " + strconv.Itoa(maxPageSize) + ")",
+					"schema":      jsonObject{"type": "integer"}},
+				jsonObject{"name": "page_token", "in": "query",
+					"description": "next_page_token of the previous page",
+					"schema":      jsonObject{"type": "string"}})
+		}
+		for _, q := range rt.query {
+			params = append(params, jsonObject{"name": q.name, "in": "query",
+				"description": q.description, "schema": jsonObject{"type": "string"}})
+		}
+
+		status := rt.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := jsonObject{"description": http.StatusText(status)}
+		if rt.response != nil {
+			success["content"] = jsonObject{"application/json": jsonObject{
+				"schema": schemaRef(reflect.TypeOf(rt.response), schemas)}}
+		}
+		op := jsonObject{
+			"summary":     rt.summary,
+			"operationId": operationID(rt),
+			"responses": jsonObject{
+				strconv.Itoa(status): success,
+				"default": jsonObject{
+					"description": "error",
+					"content": jsonObject{"application/json": jsonObject{
+						"schema": schemaRef(reflect.TypeOf(apiErrorBody{}), schemas)}}},
+			},
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+		if rt.body != nil {
+			op["requestBody"] = jsonObject{
+				"required": true,
+				"content": jsonObject{"application/json": jsonObject{
+					"schema": schemaRef(reflect.TypeOf(rt.body), schemas)}}}
+		}
+		if rt.auth {
+			op["security"] = []jsonObject{{"cookie": []string{}}, {"bearer": []string{}}}
+		}
+		item[strings.ToLower(rt.method)] = op
+	}
+
+	return jsonObject{
+		"openapi": "3.0.0",
+		"info": jsonObject{
+			"title":   "Coffee Log API",
+			"version": "v1 (" + version.Version() + ")"},
+		"servers": []jsonObject{{"url": "/"}},
+		"paths":   paths,
+		"components": jsonObject{
+			"schemas": schemas,
+			"securitySchemes": jsonObject{
+				"cookie": jsonObject{"type": "apiKey", "in": "cookie", "name": sessionCookie},
+				"bearer": jsonObject{"type": "http", "scheme": "bearer",
+					"description": "personal access token"}}},
+	}
+}
+
+// operationID derives a stable operation name such as "getUsersActivities"
+// from the route.
+func operationID(rt apiRoute) string {
+	id := strings.ToLower(rt.method)
+	for _, p := range strings.Split(muxVarPattern.ReplaceAllString(rt.path, ""), "/") {
+		if p != "" {
+			id += strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return id
+}
+
+// schemaRef returns the schema of t, registering named struct types as
+// components and referring to them.
+func schemaRef(t reflect.Type, components jsonObject) jsonObject {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return schemaOf(t, components)
+	}
+	name := strings.TrimPrefix(t.Name(), "api")
+	if _, ok := components[name]; !ok {
+		components[name] = jsonObject{} // placeholder for recursive types
+		components[name] = schemaOf(t, components)
+	}
+	return jsonObject{"$ref": "#/components/schemas/" + name}
+}
+
+func schemaOf(t reflect.Type, components jsonObject) jsonObject {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaOf(t.Elem(), components)
+	case reflect.Bool:
+		return jsonObject{"type": "boolean"}
+	case reflect.Int, reflect.Int32:
+		return jsonObject{"type": "integer", "format": "int32"}
+	case reflect.Int64:
+		return jsonObject{"type": "integer", "format": "int64"}
+	case reflect.String:
+		return jsonObject{"type": "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return jsonObject{"type": "string", "format": "byte"}
+		}
+		return jsonObject{"type": "array", "items": schemaRef(t.Elem(), components)}
+	case reflect.Map:
+		return jsonObject{"type": "object", "additionalProperties": schemaRef(t.Elem(), components)}
+	case reflect.Struct:
+		if t == timeType {
+			return jsonObject{"type": "string", "format": "date-time"}
+		}
+		props := jsonObject{}
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := strings.Split(f.Tag.Get("json"), ",")
+			if tag[0] == "-" || f.PkgPath != "" {
+				continue
+			}
+			name := tag[0]
+			if name == "" {
+				name = f.Name
+			}
+			ps := schemaRef(f.Type, components)
+			if enum := f.Tag.Get("enum"); enum != "" {
+				ps["enum"] = strings.Split(enum, ",")
+			}
+			props[name] = ps
+			if len(tag) == 1 && f.Type.Kind() != reflect.Ptr {
+				required = append(required, name)
+			}
+		}
+		s := jsonObject{"type": "object", "properties": props}
+		if len(required) > 0 {
+			s["required"] = required
+		}
+		return s
+	}
+	return jsonObject{}
+}
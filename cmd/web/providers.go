@@ -0,0 +1,273 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/trace"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context/ctxhttp"
+	"golang.org/x/oauth2"
+)
+
+const (
+	providerTypeOIDC   = "oidc"
+	providerTypeGitHub = "github"
+
+	githubAuthURL  = "https://github.com/login/oauth/authorize"
+	githubTokenURL = "https://github.com/login/oauth/access_token"
+	githubAPIURL   = "https://api.github.com"
+)
+
+var providerNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// providerConfig is an entry of the -auth-providers-config file.
+type providerConfig struct {
+	Name         string `json:"name"`  // used in URLs and to identify linked identities
+	Title        string `json:"title"` // shown on the login page
+	Type         string `json:"type"`  // "oidc" or "github"
+	Issuer       string `json:"issuer"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+}
+
+// loginProvider is an identity provider users can log in with.
+type loginProvider struct {
+	Name  string
+	Title string
+
+	cfg  *oauth2.Config
+	oidc *oidcProvider // nil for GitHub
+}
+
+// loadProviders reads the identity providers from the JSON file at path.
+func loadProviders(path string) ([]*loginProvider, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read auth providers config")
+	}
+	var v []providerConfig
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, errors.Wrap(err, "failed to parse auth providers config")
+	}
+	var out []*loginProvider
+	for _, c := range v {
+		if !providerNamePattern.MatchString(c.Name) {
+			return nil, errors.Errorf("invalid auth provider name %q", c.Name)
+		} else if c.ClientID == "" || c.ClientSecret == "" {
+			return nil, errors.Errorf("auth provider %q: client_id and client_secret are required", c.Name)
+		}
+		p := &loginProvider{
+			Name:  c.Name,
+			Title: c.Title,
+			cfg:   &oauth2.Config{ClientID: c.ClientID, ClientSecret: c.ClientSecret}}
+		if p.Title == "" {
+			p.Title = c.Name
+		}
+		switch c.Type {
+		case providerTypeOIDC:
+			if c.Issuer == "" {
+				return nil, errors.Errorf("auth provider %q: issuer is required", c.Name)
+			}
+			p.oidc = newOIDCProvider(c.Issuer, c.ClientID)
+		case providerTypeGitHub:
+			p.cfg.Endpoint = oauth2.Endpoint{AuthURL: githubAuthURL, TokenURL: githubTokenURL}
+		default:
+			return nil, errors.Errorf("auth provider %q: unknown type %q", c.Name, c.Type)
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// config returns a copy of the OAuth2 configuration of the provider with the
+// redirect URL pointing back to the host the request was made to.
+func (p *loginProvider) config(r *http.Request) (*oauth2.Config, error) {
+	cfg := *p.cfg
+	if p.oidc != nil {
+		ep, err := p.oidc.endpoint(r.Context())
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to discover the oidc provider")
+		}
+		cfg.Endpoint = ep
+		cfg.Scopes = []string{"openid", "profile", "email"}
+	} else {
+		cfg.Scopes = []string{"read:user", "user:email"}
+	}
+	if *externalURL != "" {
+		cfg.RedirectURL = *externalURL + "/oauth2callback"
+	} else {
+		scheme := "http"
+		if isHTTPS(r) {
+			scheme = "https"
+		}
+		cfg.RedirectURL = scheme + "://" + r.Host + "/oauth2callback"
+	}
+	return &cfg, nil
+}
+
+// identity returns the external identity of the user the token was issued
+// to.
+func (p *loginProvider) identity(ctx context.Context, cfg *oauth2.Config, tok *oauth2.Token, nonce string) (*pb.AuthorizeExternalRequest, error) {
+	if p.oidc == nil {
+		return githubIdentity(ctx, oauth2.NewClient(ctx, cfg.TokenSource(ctx, tok)))
+	}
+	rawIDToken, ok := tok.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("no id token in the oauth2 token response")
+	}
+	cs := trace.FromContext(ctx).NewChild("oidc/verify_id_token")
+	claims, err := p.oidc.verify(ctx, rawIDToken, nonce)
+	cs.Finish()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to verify id token")
+	}
+	return &pb.AuthorizeExternalRequest{Subject: claims.Subject, Profile: claims.profile()}, nil
+}
+
+// githubIdentity looks up the GitHub user with the REST API, as GitHub does
+// not support OpenID Connect.
+func githubIdentity(ctx context.Context, hc *http.Client) (*pb.AuthorizeExternalRequest, error) {
+	cs := trace.FromContext(ctx).NewChild("github/get/user")
+	defer cs.Finish()
+
+	var user struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := githubGet(ctx, hc, "/user", &user); err != nil {
+		return nil, err
+	} else if user.ID == 0 {
+		return nil, errors.New("github user has no id")
+	}
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := githubGet(ctx, hc, "/user/emails", &emails); err != nil {
+		return nil, err
+	}
+	profile := &pb.ExternalProfile{
+		DisplayName: user.Name,
+		PictureURL:  user.AvatarURL}
+	if profile.DisplayName == "" {
+		profile.DisplayName = user.Login
+	}
+	for _, e := range emails {
+		if e.Primary && e.Verified {
+			profile.Email, profile.EmailVerified = e.Email, true
+		}
+	}
+	return &pb.AuthorizeExternalRequest{
+		Subject: strconv.FormatInt(user.ID, 10),
+		Profile: profile}, nil
+}
+
+func githubGet(ctx context.Context, hc *http.Client, path string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, githubAPIURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	resp, err := ctxhttp.Do(ctx, hc, req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get github %s", path)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status from github %s: %s", path, resp.Status)
+	}
+	return errors.Wrapf(json.NewDecoder(resp.Body).Decode(v), "failed to decode github %s", path)
+}
+
+// providerList returns the providers sorted by title.
+func (s *server) providerList() []*loginProvider {
+	var out []*loginProvider
+	for _, p := range s.providers {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Title < out[j].Title })
+	return out
+}
+
+type identityView struct {
+	Provider, Title, Subject, Email string
+	Linked                          time.Time
+}
+
+func (s *server) identities(w http.ResponseWriter, r *http.Request) {
+	user := s.cookieUser(w, r)
+	if user == nil {
+		return
+	}
+	resp, err := s.userSvc.ListIdentities(r.Context(), &pb.ListIdentitiesRequest{UserID: user.GetID()})
+	if err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to list identities"))
+		return
+	}
+	var v []identityView
+	for _, i := range resp.GetIdentities() {
+		iv := identityView{
+			Provider: i.GetProvider(),
+			Title:    i.GetProvider(),
+			Subject:  i.GetSubject(),
+			Email:    i.GetEmail()}
+		if p, ok := s.providers[i.GetProvider()]; ok {
+			iv.Title = p.Title
+		}
+		iv.Linked, _ = ptypes.Timestamp(i.GetLinked())
+		v = append(v, iv)
+	}
+
+	render(w, r, http.StatusOK, "identities.html", map[string]interface{}{
+		"me":          user,
+		"identities":  v,
+		"hasPassword": resp.GetHasPassword(),
+		"providers":   s.providerList()})
+}
+
+func (s *server) unlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	user := s.cookieUser(w, r)
+	if user == nil {
+		return
+	}
+	resp, err := s.userSvc.UnlinkIdentity(r.Context(), &pb.UnlinkIdentityRequest{
+		UserID:   user.GetID(),
+		Provider: mux.Vars(r)["provider"],
+		Subject:  r.FormValue("subject")})
+	if err != nil {
+		badRequest(w, r, errors.Wrap(err, "failed to unlink identity"))
+		return
+	} else if !resp.GetFound() {
+		errorCode(w, r, http.StatusNotFound, "not found", publicError("identity not found"))
+		return
+	}
+	w.Header().Set("Location", "/identities")
+	w.WriteHeader(http.StatusFound)
+}
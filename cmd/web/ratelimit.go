@@ -0,0 +1,72 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ahmetb/coffeelog/ratelimit"
+	"github.com/pkg/errors"
+)
+
+// limiter limits the requests to a group of endpoints per user and per
+// client IP address.
+type limiter struct {
+	*ratelimit.Limiter
+	perUser, perIP ratelimit.Limit
+}
+
+// newLimiter returns a limiter with the limits parsed from the flags.
+func newLimiter(name string, store ratelimit.Store, perUser, perIP string) (*limiter, error) {
+	u, err := ratelimit.ParseLimit(perUser)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid per user limit of %s", name)
+	}
+	ip, err := ratelimit.ParseLimit(perIP)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid per ip limit of %s", name)
+	}
+	return &limiter{ratelimit.New(name, store), u, ip}, nil
+}
+
+// rateLimited responds with 429 Too Many Requests to the requests over the
+// limits of l instead of passing them to h. The user is only authenticated,
+// and kept for h, if there is a per user limit.
+func (s *server) rateLimited(l *limiter, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		ok, retry := l.Allow(ctx, "ip", clientIP(r), l.perIP)
+		if ok && !l.perUser.Unlimited() {
+			user, errF, err := s.authUser(ctx, r)
+			if err != nil {
+				errF(w, r, err)
+				return
+			}
+			if user != nil {
+				ok, retry = l.Allow(ctx, "user", user.GetID(), l.perUser)
+			}
+		}
+		if !ok {
+			secs := ratelimit.RetryAfter(retry)
+			w.Header().Set("Retry-After", strconv.Itoa(secs))
+			errorCode(w, r, http.StatusTooManyRequests, "rate limited",
+				publicError(fmt.Sprintf("too many requests, try again in %ds", secs)))
+			return
+		}
+		h(w, r)
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/ahmetb/coffeelog/caller"
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/trace"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+const sessionCookie = "session"
+
+// startSession creates a new login session for the user and sets the session
+// cookie. Any session the request already carries is revoked, so that the
+// session identifier is rotated on every login.
+func (s *server) startSession(ctx context.Context, w http.ResponseWriter, r *http.Request, userID string) error {
+	log := logging.FromContext(ctx)
+	span := trace.FromContext(ctx).NewChild("start_session")
+	defer span.Finish()
+
+	if old, _, err := s.authSession(ctx, r); err == nil && old != nil {
+		if _, err := s.userSvc.RevokeSession(caller.NewContext(ctx, old.GetSession().GetUserID()), &pb.RevokeSessionRequest{
+			UserID: old.GetSession().GetUserID(),
+			ID:     old.GetSession().GetID()}); err != nil {
+			return errors.Wrap(err, "failed to revoke previous session")
+		}
+		log.Debug("revoked previous session")
+	}
+
+	resp, err := s.userSvc.CreateSession(caller.NewContext(ctx, userID), &pb.CreateSessionRequest{
+		UserID:    userID,
+		UserAgent: r.UserAgent(),
+		IP:        clientIP(r)})
+	if err != nil {
+		return errors.Wrap(err, "failed to create session")
+	}
+	co, err := encodeCookie(sessionCookie, resp.GetSecret())
+	if err != nil {
+		return errors.Wrap(err, "failed to encode the session cookie")
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Path:     "/",
+		Value:    co,
+		MaxAge:   int(sessionCookieMaxAge.Seconds()),
+		Secure:   isHTTPS(r),
+		HttpOnly: true,
+	})
+	return nil
+}
+
+// authSession returns the session the request carries, or nil if there is no
+// valid session cookie.
+func (s *server) authSession(ctx context.Context, r *http.Request) (*pb.SessionResponse, httpErrorWriter, error) {
+	log := logging.FromContext(ctx)
+	c, err := r.Cookie(sessionCookie)
+	if err == http.ErrNoCookie {
+		return nil, nil, nil
+	}
+	log.Debug("session cookie found")
+	var secret string
+	if err := decodeCookie(sessionCookie, c.Value, &secret); err != nil {
+		return nil, badRequest, publicWrap(err, "invalid session cookie")
+	}
+
+	cs := trace.FromContext(ctx).NewChild("rpc.Sent/GetSession")
+	defer cs.Finish()
+	resp, err := s.userSvc.GetSession(ctx, &pb.GetSessionRequest{Secret: secret})
+	if err != nil {
+		return nil, serverError, errors.Wrap(err, "failed to look up the session")
+	} else if !resp.GetFound() {
+		// expired or revoked, treat the request as anonymous
+		log.Debug("session not found")
+		return nil, nil, nil
+	}
+	setCaller(ctx, resp.GetUser().GetID())
+	return resp, nil, nil
+}
+
+func clearSessionCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   isHTTPS(r),
+		HttpOnly: true,
+	})
+}
+
+// isHTTPS reports whether the client connected over TLS, either directly or
+// through one of the trusted proxies.
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || (fromProxy(r) && r.Header.Get("X-Forwarded-Proto") == "https")
+}
+
+type sessionView struct {
+	ID, UserAgent, IP string
+	Created, LastSeen time.Time
+	Current           bool
+}
+
+func (s *server) sessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	cur, errF, err := s.authSession(ctx, r)
+	if err != nil {
+		errF(w, r, err)
+		return
+	} else if cur == nil {
+		unauthorized(w, r, publicError("required user to log in"))
+		return
+	}
+
+	resp, err := s.userSvc.ListSessions(ctx, &pb.ListSessionsRequest{UserID: cur.GetUser().GetID()})
+	if err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to list sessions"))
+		return
+	}
+	var v []sessionView
+	for _, ss := range resp.GetSessions() {
+		sv := sessionView{
+			ID:        ss.GetID(),
+			UserAgent: ss.GetUserAgent(),
+			IP:        ss.GetIP(),
+			Current:   ss.GetID() == cur.GetSession().GetID()}
+		sv.Created, _ = ptypes.Timestamp(ss.GetCreated())
+		sv.LastSeen, _ = ptypes.Timestamp(ss.GetLastSeen())
+		v = append(v, sv)
+	}
+
+	render(w, r, http.StatusOK, "sessions.html", map[string]interface{}{
+		"me":       cur.GetUser(),
+		"sessions": v})
+}
+
+func (s *server) revokeSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	cur, errF, err := s.authSession(ctx, r)
+	if err != nil {
+		errF(w, r, err)
+		return
+	} else if cur == nil {
+		unauthorized(w, r, publicError("required user to log in"))
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	resp, err := s.userSvc.RevokeSession(ctx, &pb.RevokeSessionRequest{
+		UserID: cur.GetUser().GetID(),
+		ID:     id})
+	if err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to revoke session"))
+		return
+	} else if resp.GetRevoked() == 0 {
+		errorCode(w, r, http.StatusNotFound, "not found", publicError("session not found"))
+		return
+	}
+	if id == cur.GetSession().GetID() {
+		clearSessionCookie(w, r)
+		w.Header().Set("Location", "/")
+	} else {
+		w.Header().Set("Location", "/sessions")
+	}
+	w.WriteHeader(http.StatusFound)
+}
+
+// revokeAllSessions logs the user out on all devices, including this one.
+func (s *server) revokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	cur, errF, err := s.authSession(ctx, r)
+	if err != nil {
+		errF(w, r, err)
+		return
+	} else if cur == nil {
+		unauthorized(w, r, publicError("required user to log in"))
+		return
+	}
+	log := logging.FromContext(ctx)
+
+	resp, err := s.userSvc.RevokeAllSessions(ctx, &pb.RevokeAllSessionsRequest{UserID: cur.GetUser().GetID()})
+	if err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to revoke sessions"))
+		return
+	}
+	log.WithField("count", resp.GetRevoked()).Info("logged out of all devices")
+	clearSessionCookie(w, r)
+	w.Header().Set("Location", "/")
+	w.WriteHeader(http.StatusFound)
+}
@@ -0,0 +1,174 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+const (
+	mlPerOunce     = 29.5735
+	maxAvatarBytes = 4 * 1024 * 1024
+)
+
+// visibilityOptions are shown on the settings page.
+var visibilityOptions = []struct {
+	Value pb.Visibility
+	Title string
+}{
+	{pb.Visibility_PUBLIC, "Everyone"},
+	{pb.Visibility_FOLLOWERS, "Followers"},
+	{pb.Visibility_PRIVATE, "Only me"},
+}
+
+// formFile reads the picture uploaded in the form field, returning nil if
+// there is none.
+func formFile(r *http.Request, field string) (*pb.PostActivityRequest_File, httpErrorWriter, error) {
+	log := logging.FromContext(r.Context())
+	f, h, err := r.FormFile(field)
+	if err == http.ErrMissingFile {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, badRequest, publicWrap(err, "failed to parse form file")
+	}
+	defer f.Close()
+
+	ct := h.Header.Get("Content-Type")
+	entry := log.WithField("content-type", ct).WithField("name", h.Filename)
+	entry.Debug("upload received")
+	if !strings.HasPrefix(ct, "image/") {
+		return nil, badRequest, publicError("uploaded file is not a photo")
+	}
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, serverError, errors.Wrap(err, "failed to read file")
+	}
+	entry.WithField("size", len(b)).Debug("uploaded file is read")
+	return &pb.PostActivityRequest_File{
+		Data:        b,
+		ContentType: ct,
+		Filename:    h.Filename}, nil, nil
+}
+
+// formatAmount formats the amount in the units the viewer prefers.
+func formatAmount(viewer *pb.User, a *pb.Activity_DrinkAmount) string {
+	switch a.GetUnit() {
+	case pb.Activity_DrinkAmount_SHOTS:
+		return fmt.Sprintf("%d shots", a.GetN())
+	case pb.Activity_DrinkAmount_OUNCES:
+		if viewer.GetUnits() == "ml" {
+			return fmt.Sprintf("%d ml", ouncesToML(a.GetN()))
+		}
+		return fmt.Sprintf("%d oz", a.GetN())
+	default:
+		return fmt.Sprintf("%d ??", a.GetN())
+	}
+}
+
+// ouncesToML converts fluid ounces to milliliters, rounded to 5 ml.
+func ouncesToML(oz int32) int32 { return int32(math.Floor(float64(oz)*mlPerOunce/5+0.5)) * 5 }
+
+// mlToOunces converts milliliters to the nearest fluid ounce.
+func mlToOunces(ml int64) int64 { return int64(math.Floor(float64(ml)/mlPerOunce + 0.5)) }
+
+// localTime returns t in the time zone of the viewer.
+func localTime(viewer *pb.User, t time.Time) time.Time {
+	if tz := viewer.GetTimeZone(); tz != "" {
+		if loc, err := time.LoadLocation(tz); err == nil {
+			return t.In(loc)
+		}
+	}
+	return t
+}
+
+func (s *server) settings(w http.ResponseWriter, r *http.Request) {
+	user := s.cookieUser(w, r)
+	if user == nil {
+		return
+	}
+	s.renderSettings(w, r, http.StatusOK, user, nil)
+}
+
+func (s *server) renderSettings(w http.ResponseWriter, r *http.Request, status int, user *pb.User, data map[string]interface{}) {
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	data["me"] = user
+	data["visibilities"] = visibilityOptions
+	data["maxAvatarMB"] = maxAvatarBytes / 1024 / 1024
+	render(w, r, status, "settings.html", data)
+}
+
+func (s *server) updateSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logging.FromContext(ctx)
+	user := s.cookieUser(w, r)
+	if user == nil {
+		return
+	}
+	if err := r.ParseMultipartForm(16 * 1024 * 1024); err != nil { // max 16 mb memory
+		badRequest(w, r, publicWrap(err, "failed to parse request"))
+		return
+	}
+
+	vis, _ := strconv.ParseInt(r.FormValue("visibility"), 10, 32)
+	upd := &pb.User{
+		ID:          user.GetID(),
+		DisplayName: r.FormValue("name"),
+		Username:    strings.TrimSpace(r.FormValue("username")),
+		Picture:     user.GetPicture(),
+		Bio:         r.FormValue("bio"),
+		Units:       r.FormValue("units"),
+		TimeZone:    strings.TrimSpace(r.FormValue("timezone")),
+		Visibility:  pb.Visibility(vis),
+		SyncProfile: r.FormValue("sync") == "on"}
+
+	pic, errF, err := formFile(r, "avatar")
+	if err != nil {
+		errF(w, r, err)
+		return
+	} else if pic != nil {
+		if len(pic.GetData()) > maxAvatarBytes {
+			badRequest(w, r, publicError(fmt.Sprintf("picture is larger than %d bytes", maxAvatarBytes)))
+			return
+		}
+		resp, err := s.activitySvc.UploadPicture(ctx, pic)
+		if err != nil {
+			serverError(w, r, errors.Wrap(err, "failed to upload picture"))
+			return
+		}
+		upd.Picture = resp.GetURL()
+	}
+
+	updated, err := s.userSvc.UpdateUser(ctx, &pb.UpdateUserRequest{User: upd})
+	if err != nil {
+		s.renderSettings(w, r, http.StatusBadRequest, upd, map[string]interface{}{
+			"error": "Failed to save the settings: " + grpc.ErrorDesc(err)})
+		return
+	}
+	log.WithField("user.id", user.GetID()).Info("updated settings")
+	s.renderSettings(w, r, http.StatusOK, updated, map[string]interface{}{"message": "Your settings are saved."})
+}
@@ -0,0 +1,255 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/trace"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// bearerToken returns the personal access token in the Authorization header
+// of the request, if any.
+func bearerToken(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	if len(h) < 7 || !strings.EqualFold(h[:7], "bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(h[7:])
+}
+
+func (s *server) authToken(ctx context.Context, tok string) (*pb.User, httpErrorWriter, error) {
+	log := logging.FromContext(ctx)
+	cs := trace.FromContext(ctx).NewChild("rpc.Sent/AuthenticateToken")
+	defer cs.Finish()
+
+	resp, err := s.userSvc.AuthenticateToken(ctx, &pb.AuthenticateTokenRequest{Secret: tok})
+	if err != nil {
+		return nil, serverError, errors.Wrap(err, "failed to authenticate the token")
+	} else if !resp.GetFound() {
+		return nil, unauthorized, publicError("invalid access token")
+	}
+	log.WithField("user.id", resp.GetUser().GetID()).Debug("authenticated with access token")
+	setCaller(ctx, resp.GetUser().GetID())
+	return resp.GetUser(), nil, nil
+}
+
+// cookieUser authenticates a request that must carry the login cookie, such
+// as the ones managing the access tokens themselves.
+func (s *server) cookieUser(w http.ResponseWriter, r *http.Request) *pb.User {
+	if bearerToken(r) != "" {
+		errorCode(w, r, http.StatusForbidden, "forbidden", publicError("access tokens cannot be used to manage tokens"))
+		return nil
+	}
+	user, errF, err := s.authUser(r.Context(), r)
+	if err != nil {
+		errF(w, r, err)
+		return nil
+	} else if user == nil {
+		unauthorized(w, r, publicError("required user to log in"))
+		return nil
+	}
+	return user
+}
+
+type tokenView struct {
+	ID, Name, Prefix string
+	Created          time.Time
+	LastUsed         *time.Time
+}
+
+func toTokenView(t *pb.Token) tokenView {
+	v := tokenView{ID: t.GetID(), Name: t.GetName(), Prefix: t.GetPrefix()}
+	if ts, err := ptypes.Timestamp(t.GetCreated()); err == nil {
+		v.Created = ts
+	}
+	if t.GetLastUsed() != nil {
+		if ts, err := ptypes.Timestamp(t.GetLastUsed()); err == nil {
+			v.LastUsed = &ts
+		}
+	}
+	return v
+}
+
+func (s *server) listTokens(ctx context.Context, userID string) ([]tokenView, error) {
+	resp, err := s.userSvc.ListTokens(ctx, &pb.ListTokensRequest{UserID: userID})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list tokens")
+	}
+	out := []tokenView{}
+	for _, t := range resp.GetTokens() {
+		out = append(out, toTokenView(t))
+	}
+	return out, nil
+}
+
+func (s *server) renderTokens(w http.ResponseWriter, r *http.Request, user *pb.User, secret string) {
+	tokens, err := s.listTokens(r.Context(), user.GetID())
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+	render(w, r, http.StatusOK, "tokens.html", map[string]interface{}{
+		"me":     user,
+		"tokens": tokens,
+		"secret": secret})
+}
+
+func (s *server) tokens(w http.ResponseWriter, r *http.Request) {
+	user := s.cookieUser(w, r)
+	if user == nil {
+		return
+	}
+	s.renderTokens(w, r, user, "")
+}
+
+func (s *server) createToken(w http.ResponseWriter, r *http.Request) {
+	log := logging.FromContext(r.Context())
+	user := s.cookieUser(w, r)
+	if user == nil {
+		return
+	}
+	resp, err := s.userSvc.CreateToken(r.Context(), &pb.CreateTokenRequest{
+		UserID: user.GetID(),
+		Name:   r.FormValue("name")})
+	if err != nil {
+		badRequest(w, r, errors.Wrap(err, "failed to create token"))
+		return
+	}
+	log.WithField("token.id", resp.GetToken().GetID()).Info("access token created")
+	// the secret is only available now, so render it instead of redirecting
+	s.renderTokens(w, r, user, resp.GetSecret())
+}
+
+func (s *server) revokeToken(w http.ResponseWriter, r *http.Request) {
+	user := s.cookieUser(w, r)
+	if user == nil {
+		return
+	}
+	resp, err := s.userSvc.RevokeToken(r.Context(), &pb.RevokeTokenRequest{
+		UserID: user.GetID(),
+		ID:     mux.Vars(r)["id"]})
+	if err != nil {
+		serverError(w, r, errors.Wrap(err, "failed to revoke token"))
+		return
+	} else if !resp.GetFound() {
+		errorCode(w, r, http.StatusNotFound, "not found", publicError("token not found"))
+		return
+	}
+	w.Header().Set("Location", "/tokens")
+	w.WriteHeader(http.StatusFound)
+}
+
+type (
+	apiToken struct {
+		ID       string     `json:"id"`
+		Name     string     `json:"name"`
+		Prefix   string     `json:"prefix"`
+		Created  time.Time  `json:"created"`
+		LastUsed *time.Time `json:"last_used,omitempty"`
+	}
+
+	apiTokenList struct {
+		Tokens []apiToken `json:"tokens"`
+	}
+
+	apiTokenInput struct {
+		Name string `json:"name"`
+	}
+
+	apiNewToken struct {
+		Token  apiToken `json:"token"`
+		Secret string   `json:"secret"` // only returned once
+	}
+)
+
+func toAPIToken(v tokenView) apiToken {
+	return apiToken{ID: v.ID, Name: v.Name, Prefix: v.Prefix, Created: v.Created, LastUsed: v.LastUsed}
+}
+
+// apiCookieUser is cookieUser for API handlers.
+func (s *server) apiCookieUser(w http.ResponseWriter, r *http.Request) (*pb.User, bool) {
+	if bearerToken(r) != "" {
+		apiErrorCode(w, r, http.StatusForbidden, publicError("access tokens cannot be used to manage tokens"))
+		return nil, false
+	}
+	return s.apiAuthUser(w, r)
+}
+
+func (s *server) apiListTokens(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.apiCookieUser(w, r)
+	if !ok {
+		return
+	}
+	tokens, err := s.listTokens(r.Context(), user.GetID())
+	if err != nil {
+		apiErrorCode(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	resp := apiTokenList{Tokens: []apiToken{}}
+	for _, t := range tokens {
+		resp.Tokens = append(resp.Tokens, toAPIToken(t))
+	}
+	apiRespond(w, http.StatusOK, resp)
+}
+
+func (s *server) apiCreateToken(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.apiCookieUser(w, r)
+	if !ok {
+		return
+	}
+	var in apiTokenInput
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(&in); err != nil {
+		apiErrorCode(w, r, http.StatusBadRequest, publicWrap(err, "failed to parse request body"))
+		return
+	}
+	resp, err := s.userSvc.CreateToken(r.Context(), &pb.CreateTokenRequest{
+		UserID: user.GetID(),
+		Name:   in.Name})
+	if err != nil {
+		apiErrorCode(w, r, http.StatusBadRequest, errors.Wrap(err, "failed to create token"))
+		return
+	}
+	apiRespond(w, http.StatusCreated, apiNewToken{
+		Token:  toAPIToken(toTokenView(resp.GetToken())),
+		Secret: resp.GetSecret()})
+}
+
+func (s *server) apiRevokeToken(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.apiCookieUser(w, r)
+	if !ok {
+		return
+	}
+	resp, err := s.userSvc.RevokeToken(r.Context(), &pb.RevokeTokenRequest{
+		UserID: user.GetID(),
+		ID:     mux.Vars(r)["id"]})
+	if err != nil {
+		apiErrorCode(w, r, http.StatusInternalServerError, errors.Wrap(err, "failed to revoke token"))
+		return
+	} else if !resp.GetFound() {
+		apiErrorCode(w, r, http.StatusNotFound, publicError("token not found"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
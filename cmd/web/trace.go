@@ -3,11 +3,10 @@ package main
 import (
 	"fmt"
 	"net/http"
-	"time"
 
-	"cloud.google.com/go/trace"
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/trace"
 	"github.com/ahmetb/coffeelog/version"
-	"github.com/sirupsen/logrus"
 )
 
 type proxyResponseWriter struct {
@@ -44,27 +43,14 @@ func (s *server) traceHandler(h func(http.ResponseWriter, *http.Request)) http.H
 			span.SetLabel("app/version", version.Version())
 			span.Finish()
 		}()
-		ww.Header().Set("X-Cloud-Trace-Context", span.TraceID())
+		ww.Header().Set("X-Trace-Id", span.TraceID())
 		ww.Header().Set("X-App-Version", version.Version())
 		h(ww, r)
 	}))
 }
 
-// logHandler wraps the HTTP handler with structured logging.
+// logHandler wraps the HTTP handler with structured logging, putting the
+// logger of the request in its context.
 func logHandler(h func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		e := log.WithFields(logrus.Fields{
-			"method":     r.Method,
-			"path":       r.URL.Path,
-			"request.id": trace.FromContext(r.Context()).TraceID(),
-		})
-		e.Debug("request accepted")
-		start := time.Now()
-		defer func() {
-			e.WithFields(logrus.Fields{
-				"elapsed": time.Now().Sub(start).String(),
-			}).Debug("request completed")
-		}()
-		h(w, r)
-	}
+	return logging.HTTPHandler(log, http.HandlerFunc(h)).ServeHTTP
 }
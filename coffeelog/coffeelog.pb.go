@@ -1,18 +1,62 @@
-// Code generated by protoc-gen-go.
+// Code generated by protoc-gen-go. DO NOT EDIT.
 // source: coffeelog.proto
-// DO NOT EDIT!
 
 /*
 Package coffeelog is a generated protocol buffer package.
 
 It is generated from these files:
+
 	coffeelog.proto
 
 It has these top-level messages:
+
 	UserRequest
+	UsernameRequest
 	UserResponse
 	User
+	FollowRequest
+	FollowResponse
+	ListFollowersRequest
+	ListFollowersResponse
+	Follower
+	UpdateUserRequest
 	GoogleUser
+	ExternalProfile
+	AuthorizeExternalRequest
+	Identity
+	ListIdentitiesRequest
+	ListIdentitiesResponse
+	UnlinkIdentityRequest
+	UnlinkIdentityResponse
+	RegisterRequest
+	EmailRequest
+	AccountTokenRequest
+	AccountTokenResponse
+	PasswordLoginRequest
+	PasswordLoginResponse
+	ResetPasswordRequest
+	Token
+	CreateTokenRequest
+	CreateTokenResponse
+	ListTokensRequest
+	ListTokensResponse
+	RevokeTokenRequest
+	RevokeTokenResponse
+	AuthenticateTokenRequest
+	Session
+	CreateSessionRequest
+	CreateSessionResponse
+	GetSessionRequest
+	SessionResponse
+	ListSessionsRequest
+	ListSessionsResponse
+	RevokeSessionRequest
+	RevokeAllSessionsRequest
+	RevokeSessionResponse
+	DeleteUserRequest
+	GetDeletionRequest
+	Deletion
+	DeletionResponse
 	Roaster
 	RoasterRequest
 	RoasterCreateRequest
@@ -20,6 +64,9 @@ It has these top-level messages:
 	RoastersRequest
 	RoastersResponse
 	PostActivityRequest
+	UploadPictureResponse
+	PurgeUserRequest
+	PurgeUserResponse
 	PostActivityResponse
 	Activity
 	ActivityRequest
@@ -31,7 +78,8 @@ package coffeelog
 import proto "github.com/golang/protobuf/proto"
 import fmt "fmt"
 import math "math"
-import google_protobuf "github.com/golang/protobuf/ptypes/timestamp"
+import _ "google.golang.org/genproto/googleapis/api/annotations"
+import google_protobuf1 "github.com/golang/protobuf/ptypes/timestamp"
 
 import (
 	context "golang.org/x/net/context"
@@ -49,6 +97,65 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
+// Visibility controls who can see a profile or an activity, other than its
+// owner. Activities with the DEFAULT visibility have the visibility of their
+// owner's account, and accounts with the DEFAULT visibility are public.
+type Visibility int32
+
+const (
+	Visibility_DEFAULT   Visibility = 0
+	Visibility_PUBLIC    Visibility = 1
+	Visibility_FOLLOWERS Visibility = 2
+	Visibility_PRIVATE   Visibility = 3
+)
+
+var Visibility_name = map[int32]string{
+	0: "DEFAULT",
+	1: "PUBLIC",
+	2: "FOLLOWERS",
+	3: "PRIVATE",
+}
+var Visibility_value = map[string]int32{
+	"DEFAULT":   0,
+	"PUBLIC":    1,
+	"FOLLOWERS": 2,
+	"PRIVATE":   3,
+}
+
+func (x Visibility) String() string {
+	return proto.EnumName(Visibility_name, int32(x))
+}
+func (Visibility) EnumDescriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+type PasswordLoginResponse_Result int32
+
+const (
+	PasswordLoginResponse_OK                  PasswordLoginResponse_Result = 0
+	PasswordLoginResponse_INVALID_CREDENTIALS PasswordLoginResponse_Result = 1
+	PasswordLoginResponse_THROTTLED           PasswordLoginResponse_Result = 2
+	PasswordLoginResponse_UNVERIFIED          PasswordLoginResponse_Result = 3
+)
+
+var PasswordLoginResponse_Result_name = map[int32]string{
+	0: "OK",
+	1: "INVALID_CREDENTIALS",
+	2: "THROTTLED",
+	3: "UNVERIFIED",
+}
+var PasswordLoginResponse_Result_value = map[string]int32{
+	"OK":                  0,
+	"INVALID_CREDENTIALS": 1,
+	"THROTTLED":           2,
+	"UNVERIFIED":          3,
+}
+
+func (x PasswordLoginResponse_Result) String() string {
+	return proto.EnumName(PasswordLoginResponse_Result_name, int32(x))
+}
+func (PasswordLoginResponse_Result) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{23, 0}
+}
+
 type Activity_DrinkAmount_CaffeineUnit int32
 
 const (
@@ -72,7 +179,7 @@ func (x Activity_DrinkAmount_CaffeineUnit) String() string {
 	return proto.EnumName(Activity_DrinkAmount_CaffeineUnit_name, int32(x))
 }
 func (Activity_DrinkAmount_CaffeineUnit) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{12, 1, 0}
+	return fileDescriptor0, []int{58, 1, 0}
 }
 
 type UserRequest struct {
@@ -91,6 +198,22 @@ func (m *UserRequest) GetID() string {
 	return ""
 }
 
+type UsernameRequest struct {
+	Username string `protobuf:"bytes,1,opt,name=Username" json:"Username,omitempty"`
+}
+
+func (m *UsernameRequest) Reset()                    { *m = UsernameRequest{} }
+func (m *UsernameRequest) String() string            { return proto.CompactTextString(m) }
+func (*UsernameRequest) ProtoMessage()               {}
+func (*UsernameRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *UsernameRequest) GetUsername() string {
+	if m != nil {
+		return m.Username
+	}
+	return ""
+}
+
 type UserResponse struct {
 	Found bool  `protobuf:"varint,1,opt,name=Found" json:"Found,omitempty"`
 	User  *User `protobuf:"bytes,2,opt,name=User" json:"User,omitempty"`
@@ -99,7 +222,7 @@ type UserResponse struct {
 func (m *UserResponse) Reset()                    { *m = UserResponse{} }
 func (m *UserResponse) String() string            { return proto.CompactTextString(m) }
 func (*UserResponse) ProtoMessage()               {}
-func (*UserResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+func (*UserResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
 
 func (m *UserResponse) GetFound() bool {
 	if m != nil {
@@ -119,12 +242,19 @@ type User struct {
 	ID          string `protobuf:"bytes,1,opt,name=ID" json:"ID,omitempty"`
 	DisplayName string `protobuf:"bytes,2,opt,name=DisplayName" json:"DisplayName,omitempty"`
 	Picture     string `protobuf:"bytes,3,opt,name=Picture" json:"Picture,omitempty"`
+	Bio         string `protobuf:"bytes,4,opt,name=Bio" json:"Bio,omitempty"`
+	Username    string `protobuf:"bytes,9,opt,name=Username" json:"Username,omitempty"`
+	// Settings, only shown to the user.
+	Units       string     `protobuf:"bytes,5,opt,name=Units" json:"Units,omitempty"`
+	TimeZone    string     `protobuf:"bytes,6,opt,name=TimeZone" json:"TimeZone,omitempty"`
+	Visibility  Visibility `protobuf:"varint,7,opt,name=Visibility,enum=Visibility" json:"Visibility,omitempty"`
+	SyncProfile bool       `protobuf:"varint,8,opt,name=SyncProfile" json:"SyncProfile,omitempty"`
 }
 
 func (m *User) Reset()                    { *m = User{} }
 func (m *User) String() string            { return proto.CompactTextString(m) }
 func (*User) ProtoMessage()               {}
-func (*User) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+func (*User) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
 
 func (m *User) GetID() string {
 	if m != nil {
@@ -147,697 +277,2848 @@ func (m *User) GetPicture() string {
 	return ""
 }
 
-type GoogleUser struct {
-	ID          string `protobuf:"bytes,1,opt,name=ID" json:"ID,omitempty"`
-	DisplayName string `protobuf:"bytes,2,opt,name=DisplayName" json:"DisplayName,omitempty"`
-	PictureURL  string `protobuf:"bytes,3,opt,name=PictureURL" json:"PictureURL,omitempty"`
-	Email       string `protobuf:"bytes,4,opt,name=Email" json:"Email,omitempty"`
-}
-
-func (m *GoogleUser) Reset()                    { *m = GoogleUser{} }
-func (m *GoogleUser) String() string            { return proto.CompactTextString(m) }
-func (*GoogleUser) ProtoMessage()               {}
-func (*GoogleUser) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
-
-func (m *GoogleUser) GetID() string {
+func (m *User) GetBio() string {
 	if m != nil {
-		return m.ID
+		return m.Bio
 	}
 	return ""
 }
 
-func (m *GoogleUser) GetDisplayName() string {
+func (m *User) GetUsername() string {
 	if m != nil {
-		return m.DisplayName
+		return m.Username
 	}
 	return ""
 }
 
-func (m *GoogleUser) GetPictureURL() string {
+func (m *User) GetUnits() string {
 	if m != nil {
-		return m.PictureURL
+		return m.Units
 	}
 	return ""
 }
 
-func (m *GoogleUser) GetEmail() string {
+func (m *User) GetTimeZone() string {
 	if m != nil {
-		return m.Email
+		return m.TimeZone
 	}
 	return ""
 }
 
-type Roaster struct {
-	ID      int64  `protobuf:"varint,1,opt,name=ID" json:"ID,omitempty"`
-	Name    string `protobuf:"bytes,2,opt,name=Name" json:"Name,omitempty"`
-	Picture string `protobuf:"bytes,3,opt,name=Picture" json:"Picture,omitempty"`
+func (m *User) GetVisibility() Visibility {
+	if m != nil {
+		return m.Visibility
+	}
+	return Visibility_DEFAULT
 }
 
-func (m *Roaster) Reset()                    { *m = Roaster{} }
-func (m *Roaster) String() string            { return proto.CompactTextString(m) }
-func (*Roaster) ProtoMessage()               {}
-func (*Roaster) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
-
-func (m *Roaster) GetID() int64 {
+func (m *User) GetSyncProfile() bool {
 	if m != nil {
-		return m.ID
+		return m.SyncProfile
 	}
-	return 0
+	return false
 }
 
-func (m *Roaster) GetName() string {
+type FollowRequest struct {
+	UserID     string `protobuf:"bytes,1,opt,name=UserID" json:"UserID,omitempty"`
+	FollowerID string `protobuf:"bytes,2,opt,name=FollowerID" json:"FollowerID,omitempty"`
+}
+
+func (m *FollowRequest) Reset()                    { *m = FollowRequest{} }
+func (m *FollowRequest) String() string            { return proto.CompactTextString(m) }
+func (*FollowRequest) ProtoMessage()               {}
+func (*FollowRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *FollowRequest) GetUserID() string {
 	if m != nil {
-		return m.Name
+		return m.UserID
 	}
 	return ""
 }
 
-func (m *Roaster) GetPicture() string {
+func (m *FollowRequest) GetFollowerID() string {
 	if m != nil {
-		return m.Picture
+		return m.FollowerID
 	}
 	return ""
 }
 
-type RoasterRequest struct {
-	// Types that are valid to be assigned to Query:
-	//	*RoasterRequest_ID
-	//	*RoasterRequest_Name
-	Query isRoasterRequest_Query `protobuf_oneof:"Query"`
-}
-
-func (m *RoasterRequest) Reset()                    { *m = RoasterRequest{} }
-func (m *RoasterRequest) String() string            { return proto.CompactTextString(m) }
-func (*RoasterRequest) ProtoMessage()               {}
-func (*RoasterRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
-
-type isRoasterRequest_Query interface {
-	isRoasterRequest_Query()
-}
-
-type RoasterRequest_ID struct {
-	ID int64 `protobuf:"varint,1,opt,name=ID,oneof"`
-}
-type RoasterRequest_Name struct {
-	Name string `protobuf:"bytes,2,opt,name=Name,oneof"`
+type FollowResponse struct {
+	Following bool `protobuf:"varint,1,opt,name=Following" json:"Following,omitempty"`
+	Pending   bool `protobuf:"varint,2,opt,name=Pending" json:"Pending,omitempty"`
 }
 
-func (*RoasterRequest_ID) isRoasterRequest_Query()   {}
-func (*RoasterRequest_Name) isRoasterRequest_Query() {}
+func (m *FollowResponse) Reset()                    { *m = FollowResponse{} }
+func (m *FollowResponse) String() string            { return proto.CompactTextString(m) }
+func (*FollowResponse) ProtoMessage()               {}
+func (*FollowResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
 
-func (m *RoasterRequest) GetQuery() isRoasterRequest_Query {
+func (m *FollowResponse) GetFollowing() bool {
 	if m != nil {
-		return m.Query
+		return m.Following
 	}
-	return nil
+	return false
 }
 
-func (m *RoasterRequest) GetID() int64 {
-	if x, ok := m.GetQuery().(*RoasterRequest_ID); ok {
-		return x.ID
+func (m *FollowResponse) GetPending() bool {
+	if m != nil {
+		return m.Pending
 	}
-	return 0
+	return false
 }
 
-func (m *RoasterRequest) GetName() string {
-	if x, ok := m.GetQuery().(*RoasterRequest_Name); ok {
-		return x.Name
-	}
-	return ""
+type ListFollowersRequest struct {
+	UserID string `protobuf:"bytes,1,opt,name=UserID" json:"UserID,omitempty"`
 }
 
-// XXX_OneofFuncs is for the internal use of the proto package.
-func (*RoasterRequest) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
-	return _RoasterRequest_OneofMarshaler, _RoasterRequest_OneofUnmarshaler, _RoasterRequest_OneofSizer, []interface{}{
-		(*RoasterRequest_ID)(nil),
-		(*RoasterRequest_Name)(nil),
-	}
-}
+func (m *ListFollowersRequest) Reset()                    { *m = ListFollowersRequest{} }
+func (m *ListFollowersRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListFollowersRequest) ProtoMessage()               {}
+func (*ListFollowersRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
 
-func _RoasterRequest_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
-	m := msg.(*RoasterRequest)
-	// Query
-	switch x := m.Query.(type) {
-	case *RoasterRequest_ID:
-		b.EncodeVarint(1<<3 | proto.WireVarint)
-		b.EncodeVarint(uint64(x.ID))
-	case *RoasterRequest_Name:
-		b.EncodeVarint(2<<3 | proto.WireBytes)
-		b.EncodeStringBytes(x.Name)
-	case nil:
-	default:
-		return fmt.Errorf("RoasterRequest.Query has unexpected type %T", x)
+func (m *ListFollowersRequest) GetUserID() string {
+	if m != nil {
+		return m.UserID
 	}
-	return nil
+	return ""
 }
 
-func _RoasterRequest_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
-	m := msg.(*RoasterRequest)
-	switch tag {
-	case 1: // Query.ID
-		if wire != proto.WireVarint {
-			return true, proto.ErrInternalBadWireType
-		}
-		x, err := b.DecodeVarint()
-		m.Query = &RoasterRequest_ID{int64(x)}
-		return true, err
-	case 2: // Query.Name
-		if wire != proto.WireBytes {
-			return true, proto.ErrInternalBadWireType
-		}
-		x, err := b.DecodeStringBytes()
-		m.Query = &RoasterRequest_Name{x}
-		return true, err
-	default:
-		return false, nil
-	}
+type ListFollowersResponse struct {
+	Followers []*Follower `protobuf:"bytes,1,rep,name=Followers" json:"Followers,omitempty"`
 }
 
-func _RoasterRequest_OneofSizer(msg proto.Message) (n int) {
-	m := msg.(*RoasterRequest)
-	// Query
-	switch x := m.Query.(type) {
-	case *RoasterRequest_ID:
-		n += proto.SizeVarint(1<<3 | proto.WireVarint)
-		n += proto.SizeVarint(uint64(x.ID))
-	case *RoasterRequest_Name:
-		n += proto.SizeVarint(2<<3 | proto.WireBytes)
-		n += proto.SizeVarint(uint64(len(x.Name)))
-		n += len(x.Name)
-	case nil:
-	default:
-		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+func (m *ListFollowersResponse) Reset()                    { *m = ListFollowersResponse{} }
+func (m *ListFollowersResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListFollowersResponse) ProtoMessage()               {}
+func (*ListFollowersResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *ListFollowersResponse) GetFollowers() []*Follower {
+	if m != nil {
+		return m.Followers
 	}
-	return n
+	return nil
 }
 
-type RoasterCreateRequest struct {
-	Name string `protobuf:"bytes,2,opt,name=Name" json:"Name,omitempty"`
+type Follower struct {
+	User    *User                       `protobuf:"bytes,1,opt,name=User" json:"User,omitempty"`
+	Pending bool                        `protobuf:"varint,2,opt,name=Pending" json:"Pending,omitempty"`
+	Since   *google_protobuf1.Timestamp `protobuf:"bytes,3,opt,name=Since" json:"Since,omitempty"`
 }
 
-func (m *RoasterCreateRequest) Reset()                    { *m = RoasterCreateRequest{} }
-func (m *RoasterCreateRequest) String() string            { return proto.CompactTextString(m) }
-func (*RoasterCreateRequest) ProtoMessage()               {}
-func (*RoasterCreateRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+func (m *Follower) Reset()                    { *m = Follower{} }
+func (m *Follower) String() string            { return proto.CompactTextString(m) }
+func (*Follower) ProtoMessage()               {}
+func (*Follower) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
 
-func (m *RoasterCreateRequest) GetName() string {
+func (m *Follower) GetUser() *User {
 	if m != nil {
-		return m.Name
+		return m.User
 	}
-	return ""
-}
-
-type RoasterResponse struct {
-	Found   bool     `protobuf:"varint,1,opt,name=Found" json:"Found,omitempty"`
-	Roaster *Roaster `protobuf:"bytes,2,opt,name=Roaster" json:"Roaster,omitempty"`
+	return nil
 }
 
-func (m *RoasterResponse) Reset()                    { *m = RoasterResponse{} }
-func (m *RoasterResponse) String() string            { return proto.CompactTextString(m) }
-func (*RoasterResponse) ProtoMessage()               {}
-func (*RoasterResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
-
-func (m *RoasterResponse) GetFound() bool {
+func (m *Follower) GetPending() bool {
 	if m != nil {
-		return m.Found
+		return m.Pending
 	}
 	return false
 }
 
-func (m *RoasterResponse) GetRoaster() *Roaster {
+func (m *Follower) GetSince() *google_protobuf1.Timestamp {
 	if m != nil {
-		return m.Roaster
+		return m.Since
 	}
 	return nil
 }
 
-type RoastersRequest struct {
+type UpdateUserRequest struct {
+	User   *User    `protobuf:"bytes,1,opt,name=User" json:"User,omitempty"`
+	Fields []string `protobuf:"bytes,2,rep,name=Fields" json:"Fields,omitempty"`
 }
 
-func (m *RoastersRequest) Reset()                    { *m = RoastersRequest{} }
-func (m *RoastersRequest) String() string            { return proto.CompactTextString(m) }
-func (*RoastersRequest) ProtoMessage()               {}
-func (*RoastersRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+func (m *UpdateUserRequest) Reset()                    { *m = UpdateUserRequest{} }
+func (m *UpdateUserRequest) String() string            { return proto.CompactTextString(m) }
+func (*UpdateUserRequest) ProtoMessage()               {}
+func (*UpdateUserRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
 
-type RoastersResponse struct {
-	Results []*Roaster `protobuf:"bytes,1,rep,name=Results" json:"Results,omitempty"`
+func (m *UpdateUserRequest) GetUser() *User {
+	if m != nil {
+		return m.User
+	}
+	return nil
 }
 
-func (m *RoastersResponse) Reset()                    { *m = RoastersResponse{} }
-func (m *RoastersResponse) String() string            { return proto.CompactTextString(m) }
-func (*RoastersResponse) ProtoMessage()               {}
-func (*RoastersResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
-
-func (m *RoastersResponse) GetResults() []*Roaster {
+func (m *UpdateUserRequest) GetFields() []string {
 	if m != nil {
-		return m.Results
+		return m.Fields
 	}
 	return nil
 }
 
-type PostActivityRequest struct {
-	UserID      string                     `protobuf:"bytes,1,opt,name=UserID" json:"UserID,omitempty"`
-	Homebrew    bool                       `protobuf:"varint,2,opt,name=Homebrew" json:"Homebrew,omitempty"`
-	Drink       string                     `protobuf:"bytes,6,opt,name=Drink" json:"Drink,omitempty"`
-	Method      string                     `protobuf:"bytes,3,opt,name=Method" json:"Method,omitempty"`
-	Amount      *Activity_DrinkAmount      `protobuf:"bytes,4,opt,name=Amount" json:"Amount,omitempty"`
-	Date        *google_protobuf.Timestamp `protobuf:"bytes,5,opt,name=Date" json:"Date,omitempty"`
-	RoasterName string                     `protobuf:"bytes,8,opt,name=RoasterName" json:"RoasterName,omitempty"`
-	Origin      string                     `protobuf:"bytes,7,opt,name=Origin" json:"Origin,omitempty"`
-	Notes       string                     `protobuf:"bytes,9,opt,name=Notes" json:"Notes,omitempty"`
-	Picture     *PostActivityRequest_File  `protobuf:"bytes,10,opt,name=Picture" json:"Picture,omitempty"`
+type GoogleUser struct {
+	ID          string `protobuf:"bytes,1,opt,name=ID" json:"ID,omitempty"`
+	DisplayName string `protobuf:"bytes,2,opt,name=DisplayName" json:"DisplayName,omitempty"`
+	PictureURL  string `protobuf:"bytes,3,opt,name=PictureURL" json:"PictureURL,omitempty"`
+	Email       string `protobuf:"bytes,4,opt,name=Email" json:"Email,omitempty"`
 }
 
-func (m *PostActivityRequest) Reset()                    { *m = PostActivityRequest{} }
-func (m *PostActivityRequest) String() string            { return proto.CompactTextString(m) }
-func (*PostActivityRequest) ProtoMessage()               {}
-func (*PostActivityRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+func (m *GoogleUser) Reset()                    { *m = GoogleUser{} }
+func (m *GoogleUser) String() string            { return proto.CompactTextString(m) }
+func (*GoogleUser) ProtoMessage()               {}
+func (*GoogleUser) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
 
-func (m *PostActivityRequest) GetUserID() string {
+func (m *GoogleUser) GetID() string {
 	if m != nil {
-		return m.UserID
+		return m.ID
 	}
 	return ""
 }
 
-func (m *PostActivityRequest) GetHomebrew() bool {
+func (m *GoogleUser) GetDisplayName() string {
 	if m != nil {
-		return m.Homebrew
+		return m.DisplayName
 	}
-	return false
+	return ""
 }
 
-func (m *PostActivityRequest) GetDrink() string {
+func (m *GoogleUser) GetPictureURL() string {
 	if m != nil {
-		return m.Drink
+		return m.PictureURL
 	}
 	return ""
 }
 
-func (m *PostActivityRequest) GetMethod() string {
+func (m *GoogleUser) GetEmail() string {
 	if m != nil {
-		return m.Method
+		return m.Email
 	}
 	return ""
 }
 
-func (m *PostActivityRequest) GetAmount() *Activity_DrinkAmount {
-	if m != nil {
-		return m.Amount
-	}
-	return nil
+type ExternalProfile struct {
+	DisplayName   string `protobuf:"bytes,1,opt,name=DisplayName" json:"DisplayName,omitempty"`
+	PictureURL    string `protobuf:"bytes,2,opt,name=PictureURL" json:"PictureURL,omitempty"`
+	Email         string `protobuf:"bytes,3,opt,name=Email" json:"Email,omitempty"`
+	EmailVerified bool   `protobuf:"varint,4,opt,name=EmailVerified" json:"EmailVerified,omitempty"`
 }
 
-func (m *PostActivityRequest) GetDate() *google_protobuf.Timestamp {
+func (m *ExternalProfile) Reset()                    { *m = ExternalProfile{} }
+func (m *ExternalProfile) String() string            { return proto.CompactTextString(m) }
+func (*ExternalProfile) ProtoMessage()               {}
+func (*ExternalProfile) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+func (m *ExternalProfile) GetDisplayName() string {
 	if m != nil {
-		return m.Date
+		return m.DisplayName
 	}
-	return nil
+	return ""
 }
 
-func (m *PostActivityRequest) GetRoasterName() string {
+func (m *ExternalProfile) GetPictureURL() string {
 	if m != nil {
-		return m.RoasterName
+		return m.PictureURL
 	}
 	return ""
 }
 
-func (m *PostActivityRequest) GetOrigin() string {
+func (m *ExternalProfile) GetEmail() string {
 	if m != nil {
-		return m.Origin
+		return m.Email
 	}
 	return ""
 }
 
-func (m *PostActivityRequest) GetNotes() string {
+func (m *ExternalProfile) GetEmailVerified() bool {
 	if m != nil {
-		return m.Notes
+		return m.EmailVerified
 	}
-	return ""
+	return false
 }
 
-func (m *PostActivityRequest) GetPicture() *PostActivityRequest_File {
+type AuthorizeExternalRequest struct {
+	Provider     string           `protobuf:"bytes,1,opt,name=Provider" json:"Provider,omitempty"`
+	Subject      string           `protobuf:"bytes,2,opt,name=Subject" json:"Subject,omitempty"`
+	Profile      *ExternalProfile `protobuf:"bytes,3,opt,name=Profile" json:"Profile,omitempty"`
+	LinkToUserID string           `protobuf:"bytes,4,opt,name=LinkToUserID" json:"LinkToUserID,omitempty"`
+}
+
+func (m *AuthorizeExternalRequest) Reset()                    { *m = AuthorizeExternalRequest{} }
+func (m *AuthorizeExternalRequest) String() string            { return proto.CompactTextString(m) }
+func (*AuthorizeExternalRequest) ProtoMessage()               {}
+func (*AuthorizeExternalRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+func (m *AuthorizeExternalRequest) GetProvider() string {
 	if m != nil {
-		return m.Picture
+		return m.Provider
 	}
-	return nil
+	return ""
 }
 
-type PostActivityRequest_File struct {
-	Data        []byte `protobuf:"bytes,1,opt,name=Data,proto3" json:"Data,omitempty"`
-	Filename    string `protobuf:"bytes,2,opt,name=Filename" json:"Filename,omitempty"`
-	ContentType string `protobuf:"bytes,3,opt,name=ContentType" json:"ContentType,omitempty"`
+func (m *AuthorizeExternalRequest) GetSubject() string {
+	if m != nil {
+		return m.Subject
+	}
+	return ""
 }
 
-func (m *PostActivityRequest_File) Reset()                    { *m = PostActivityRequest_File{} }
-func (m *PostActivityRequest_File) String() string            { return proto.CompactTextString(m) }
-func (*PostActivityRequest_File) ProtoMessage()               {}
-func (*PostActivityRequest_File) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10, 0} }
-
-func (m *PostActivityRequest_File) GetData() []byte {
+func (m *AuthorizeExternalRequest) GetProfile() *ExternalProfile {
 	if m != nil {
-		return m.Data
+		return m.Profile
 	}
 	return nil
 }
 
-func (m *PostActivityRequest_File) GetFilename() string {
+func (m *AuthorizeExternalRequest) GetLinkToUserID() string {
 	if m != nil {
-		return m.Filename
+		return m.LinkToUserID
 	}
 	return ""
 }
 
-func (m *PostActivityRequest_File) GetContentType() string {
+type Identity struct {
+	Provider string                      `protobuf:"bytes,1,opt,name=Provider" json:"Provider,omitempty"`
+	Subject  string                      `protobuf:"bytes,2,opt,name=Subject" json:"Subject,omitempty"`
+	Email    string                      `protobuf:"bytes,3,opt,name=Email" json:"Email,omitempty"`
+	Linked   *google_protobuf1.Timestamp `protobuf:"bytes,4,opt,name=Linked" json:"Linked,omitempty"`
+}
+
+func (m *Identity) Reset()                    { *m = Identity{} }
+func (m *Identity) String() string            { return proto.CompactTextString(m) }
+func (*Identity) ProtoMessage()               {}
+func (*Identity) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *Identity) GetProvider() string {
 	if m != nil {
-		return m.ContentType
+		return m.Provider
 	}
 	return ""
 }
 
-type PostActivityResponse struct {
-	ID int64 `protobuf:"varint,1,opt,name=ID" json:"ID,omitempty"`
+func (m *Identity) GetSubject() string {
+	if m != nil {
+		return m.Subject
+	}
+	return ""
 }
 
-func (m *PostActivityResponse) Reset()                    { *m = PostActivityResponse{} }
-func (m *PostActivityResponse) String() string            { return proto.CompactTextString(m) }
-func (*PostActivityResponse) ProtoMessage()               {}
-func (*PostActivityResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+func (m *Identity) GetEmail() string {
+	if m != nil {
+		return m.Email
+	}
+	return ""
+}
 
-func (m *PostActivityResponse) GetID() int64 {
+func (m *Identity) GetLinked() *google_protobuf1.Timestamp {
 	if m != nil {
-		return m.ID
+		return m.Linked
 	}
-	return 0
+	return nil
 }
 
-type Activity struct {
-	ID         int64                      `protobuf:"varint,1,opt,name=ID" json:"ID,omitempty"`
-	User       *User                      `protobuf:"bytes,2,opt,name=User" json:"User,omitempty"`
-	Homebrew   bool                       `protobuf:"varint,12,opt,name=Homebrew" json:"Homebrew,omitempty"`
-	Drink      string                     `protobuf:"bytes,3,opt,name=Drink" json:"Drink,omitempty"`
-	Method     string                     `protobuf:"bytes,4,opt,name=Method" json:"Method,omitempty"`
-	Amount     *Activity_DrinkAmount      `protobuf:"bytes,5,opt,name=Amount" json:"Amount,omitempty"`
-	Roaster    *Activity_RoasterInfo      `protobuf:"bytes,6,opt,name=Roaster" json:"Roaster,omitempty"`
-	Origin     string                     `protobuf:"bytes,7,opt,name=Origin" json:"Origin,omitempty"`
-	Notes      string                     `protobuf:"bytes,8,opt,name=Notes" json:"Notes,omitempty"`
-	PictureURL string                     `protobuf:"bytes,9,opt,name=PictureURL" json:"PictureURL,omitempty"`
-	Date       *google_protobuf.Timestamp `protobuf:"bytes,10,opt,name=Date" json:"Date,omitempty"`
-	LogDate    *google_protobuf.Timestamp `protobuf:"bytes,11,opt,name=LogDate" json:"LogDate,omitempty"`
+type ListIdentitiesRequest struct {
+	UserID string `protobuf:"bytes,1,opt,name=UserID" json:"UserID,omitempty"`
 }
 
-func (m *Activity) Reset()                    { *m = Activity{} }
-func (m *Activity) String() string            { return proto.CompactTextString(m) }
-func (*Activity) ProtoMessage()               {}
-func (*Activity) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+func (m *ListIdentitiesRequest) Reset()                    { *m = ListIdentitiesRequest{} }
+func (m *ListIdentitiesRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListIdentitiesRequest) ProtoMessage()               {}
+func (*ListIdentitiesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
 
-func (m *Activity) GetID() int64 {
+func (m *ListIdentitiesRequest) GetUserID() string {
 	if m != nil {
-		return m.ID
+		return m.UserID
 	}
-	return 0
+	return ""
 }
 
-func (m *Activity) GetUser() *User {
+type ListIdentitiesResponse struct {
+	Identities []*Identity `protobuf:"bytes,1,rep,name=Identities" json:"Identities,omitempty"`
+	// HasPassword is whether the user can also log in with a password.
+	HasPassword bool `protobuf:"varint,2,opt,name=HasPassword" json:"HasPassword,omitempty"`
+}
+
+func (m *ListIdentitiesResponse) Reset()                    { *m = ListIdentitiesResponse{} }
+func (m *ListIdentitiesResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListIdentitiesResponse) ProtoMessage()               {}
+func (*ListIdentitiesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+func (m *ListIdentitiesResponse) GetIdentities() []*Identity {
 	if m != nil {
-		return m.User
+		return m.Identities
 	}
 	return nil
 }
 
-func (m *Activity) GetHomebrew() bool {
+func (m *ListIdentitiesResponse) GetHasPassword() bool {
 	if m != nil {
-		return m.Homebrew
+		return m.HasPassword
 	}
 	return false
 }
 
-func (m *Activity) GetDrink() string {
+type UnlinkIdentityRequest struct {
+	UserID   string `protobuf:"bytes,1,opt,name=UserID" json:"UserID,omitempty"`
+	Provider string `protobuf:"bytes,2,opt,name=Provider" json:"Provider,omitempty"`
+	Subject  string `protobuf:"bytes,3,opt,name=Subject" json:"Subject,omitempty"`
+}
+
+func (m *UnlinkIdentityRequest) Reset()                    { *m = UnlinkIdentityRequest{} }
+func (m *UnlinkIdentityRequest) String() string            { return proto.CompactTextString(m) }
+func (*UnlinkIdentityRequest) ProtoMessage()               {}
+func (*UnlinkIdentityRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *UnlinkIdentityRequest) GetUserID() string {
 	if m != nil {
-		return m.Drink
+		return m.UserID
 	}
 	return ""
 }
 
-func (m *Activity) GetMethod() string {
+func (m *UnlinkIdentityRequest) GetProvider() string {
 	if m != nil {
-		return m.Method
+		return m.Provider
 	}
 	return ""
 }
 
-func (m *Activity) GetAmount() *Activity_DrinkAmount {
+func (m *UnlinkIdentityRequest) GetSubject() string {
 	if m != nil {
-		return m.Amount
+		return m.Subject
 	}
-	return nil
+	return ""
 }
 
-func (m *Activity) GetRoaster() *Activity_RoasterInfo {
+type UnlinkIdentityResponse struct {
+	Found bool `protobuf:"varint,1,opt,name=Found" json:"Found,omitempty"`
+}
+
+func (m *UnlinkIdentityResponse) Reset()                    { *m = UnlinkIdentityResponse{} }
+func (m *UnlinkIdentityResponse) String() string            { return proto.CompactTextString(m) }
+func (*UnlinkIdentityResponse) ProtoMessage()               {}
+func (*UnlinkIdentityResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *UnlinkIdentityResponse) GetFound() bool {
 	if m != nil {
-		return m.Roaster
+		return m.Found
 	}
-	return nil
+	return false
 }
 
-func (m *Activity) GetOrigin() string {
+type RegisterRequest struct {
+	Email       string `protobuf:"bytes,1,opt,name=Email" json:"Email,omitempty"`
+	Password    string `protobuf:"bytes,2,opt,name=Password" json:"Password,omitempty"`
+	DisplayName string `protobuf:"bytes,3,opt,name=DisplayName" json:"DisplayName,omitempty"`
+}
+
+func (m *RegisterRequest) Reset()                    { *m = RegisterRequest{} }
+func (m *RegisterRequest) String() string            { return proto.CompactTextString(m) }
+func (*RegisterRequest) ProtoMessage()               {}
+func (*RegisterRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *RegisterRequest) GetEmail() string {
 	if m != nil {
-		return m.Origin
+		return m.Email
 	}
 	return ""
 }
 
-func (m *Activity) GetNotes() string {
+func (m *RegisterRequest) GetPassword() string {
 	if m != nil {
-		return m.Notes
+		return m.Password
 	}
 	return ""
 }
 
-func (m *Activity) GetPictureURL() string {
+func (m *RegisterRequest) GetDisplayName() string {
 	if m != nil {
-		return m.PictureURL
+		return m.DisplayName
 	}
 	return ""
 }
 
-func (m *Activity) GetDate() *google_protobuf.Timestamp {
+type EmailRequest struct {
+	Email string `protobuf:"bytes,1,opt,name=Email" json:"Email,omitempty"`
+}
+
+func (m *EmailRequest) Reset()                    { *m = EmailRequest{} }
+func (m *EmailRequest) String() string            { return proto.CompactTextString(m) }
+func (*EmailRequest) ProtoMessage()               {}
+func (*EmailRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *EmailRequest) GetEmail() string {
 	if m != nil {
-		return m.Date
+		return m.Email
 	}
-	return nil
+	return ""
+}
+
+type AccountTokenRequest struct {
+	Token string `protobuf:"bytes,1,opt,name=Token" json:"Token,omitempty"`
 }
 
-func (m *Activity) GetLogDate() *google_protobuf.Timestamp {
+func (m *AccountTokenRequest) Reset()                    { *m = AccountTokenRequest{} }
+func (m *AccountTokenRequest) String() string            { return proto.CompactTextString(m) }
+func (*AccountTokenRequest) ProtoMessage()               {}
+func (*AccountTokenRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+func (m *AccountTokenRequest) GetToken() string {
 	if m != nil {
-		return m.LogDate
+		return m.Token
+	}
+	return ""
+}
+
+type AccountTokenResponse struct {
+	Token string `protobuf:"bytes,1,opt,name=Token" json:"Token,omitempty"`
+}
+
+func (m *AccountTokenResponse) Reset()                    { *m = AccountTokenResponse{} }
+func (m *AccountTokenResponse) String() string            { return proto.CompactTextString(m) }
+func (*AccountTokenResponse) ProtoMessage()               {}
+func (*AccountTokenResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+func (m *AccountTokenResponse) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+type PasswordLoginRequest struct {
+	Email    string `protobuf:"bytes,1,opt,name=Email" json:"Email,omitempty"`
+	Password string `protobuf:"bytes,2,opt,name=Password" json:"Password,omitempty"`
+	IP       string `protobuf:"bytes,3,opt,name=IP" json:"IP,omitempty"`
+}
+
+func (m *PasswordLoginRequest) Reset()                    { *m = PasswordLoginRequest{} }
+func (m *PasswordLoginRequest) String() string            { return proto.CompactTextString(m) }
+func (*PasswordLoginRequest) ProtoMessage()               {}
+func (*PasswordLoginRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+func (m *PasswordLoginRequest) GetEmail() string {
+	if m != nil {
+		return m.Email
+	}
+	return ""
+}
+
+func (m *PasswordLoginRequest) GetPassword() string {
+	if m != nil {
+		return m.Password
+	}
+	return ""
+}
+
+func (m *PasswordLoginRequest) GetIP() string {
+	if m != nil {
+		return m.IP
+	}
+	return ""
+}
+
+type PasswordLoginResponse struct {
+	Status PasswordLoginResponse_Result `protobuf:"varint,1,opt,name=Status,enum=PasswordLoginResponse_Result" json:"Status,omitempty"`
+	User   *User                        `protobuf:"bytes,2,opt,name=User" json:"User,omitempty"`
+}
+
+func (m *PasswordLoginResponse) Reset()                    { *m = PasswordLoginResponse{} }
+func (m *PasswordLoginResponse) String() string            { return proto.CompactTextString(m) }
+func (*PasswordLoginResponse) ProtoMessage()               {}
+func (*PasswordLoginResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+func (m *PasswordLoginResponse) GetStatus() PasswordLoginResponse_Result {
+	if m != nil {
+		return m.Status
+	}
+	return PasswordLoginResponse_OK
+}
+
+func (m *PasswordLoginResponse) GetUser() *User {
+	if m != nil {
+		return m.User
 	}
 	return nil
 }
 
-type Activity_RoasterInfo struct {
-	ID   int64  `protobuf:"varint,1,opt,name=ID" json:"ID,omitempty"`
-	Name string `protobuf:"bytes,2,opt,name=Name" json:"Name,omitempty"`
+type ResetPasswordRequest struct {
+	Token    string `protobuf:"bytes,1,opt,name=Token" json:"Token,omitempty"`
+	Password string `protobuf:"bytes,2,opt,name=Password" json:"Password,omitempty"`
 }
 
-func (m *Activity_RoasterInfo) Reset()                    { *m = Activity_RoasterInfo{} }
-func (m *Activity_RoasterInfo) String() string            { return proto.CompactTextString(m) }
-func (*Activity_RoasterInfo) ProtoMessage()               {}
-func (*Activity_RoasterInfo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12, 0} }
+func (m *ResetPasswordRequest) Reset()                    { *m = ResetPasswordRequest{} }
+func (m *ResetPasswordRequest) String() string            { return proto.CompactTextString(m) }
+func (*ResetPasswordRequest) ProtoMessage()               {}
+func (*ResetPasswordRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
 
-func (m *Activity_RoasterInfo) GetID() int64 {
+func (m *ResetPasswordRequest) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+func (m *ResetPasswordRequest) GetPassword() string {
+	if m != nil {
+		return m.Password
+	}
+	return ""
+}
+
+type Token struct {
+	ID       string                      `protobuf:"bytes,1,opt,name=ID" json:"ID,omitempty"`
+	UserID   string                      `protobuf:"bytes,2,opt,name=UserID" json:"UserID,omitempty"`
+	Name     string                      `protobuf:"bytes,3,opt,name=Name" json:"Name,omitempty"`
+	Prefix   string                      `protobuf:"bytes,4,opt,name=Prefix" json:"Prefix,omitempty"`
+	Created  *google_protobuf1.Timestamp `protobuf:"bytes,5,opt,name=Created" json:"Created,omitempty"`
+	LastUsed *google_protobuf1.Timestamp `protobuf:"bytes,6,opt,name=LastUsed" json:"LastUsed,omitempty"`
+}
+
+func (m *Token) Reset()                    { *m = Token{} }
+func (m *Token) String() string            { return proto.CompactTextString(m) }
+func (*Token) ProtoMessage()               {}
+func (*Token) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+
+func (m *Token) GetID() string {
 	if m != nil {
 		return m.ID
 	}
-	return 0
+	return ""
 }
 
-func (m *Activity_RoasterInfo) GetName() string {
+func (m *Token) GetUserID() string {
+	if m != nil {
+		return m.UserID
+	}
+	return ""
+}
+
+func (m *Token) GetName() string {
 	if m != nil {
 		return m.Name
 	}
 	return ""
 }
 
-type Activity_DrinkAmount struct {
-	N    int32                             `protobuf:"varint,1,opt,name=N" json:"N,omitempty"`
-	Unit Activity_DrinkAmount_CaffeineUnit `protobuf:"varint,2,opt,name=Unit,enum=Activity_DrinkAmount_CaffeineUnit" json:"Unit,omitempty"`
+func (m *Token) GetPrefix() string {
+	if m != nil {
+		return m.Prefix
+	}
+	return ""
 }
 
-func (m *Activity_DrinkAmount) Reset()                    { *m = Activity_DrinkAmount{} }
-func (m *Activity_DrinkAmount) String() string            { return proto.CompactTextString(m) }
-func (*Activity_DrinkAmount) ProtoMessage()               {}
-func (*Activity_DrinkAmount) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12, 1} }
+func (m *Token) GetCreated() *google_protobuf1.Timestamp {
+	if m != nil {
+		return m.Created
+	}
+	return nil
+}
 
-func (m *Activity_DrinkAmount) GetN() int32 {
+func (m *Token) GetLastUsed() *google_protobuf1.Timestamp {
 	if m != nil {
-		return m.N
+		return m.LastUsed
 	}
-	return 0
+	return nil
 }
 
-func (m *Activity_DrinkAmount) GetUnit() Activity_DrinkAmount_CaffeineUnit {
+type CreateTokenRequest struct {
+	UserID string `protobuf:"bytes,1,opt,name=UserID" json:"UserID,omitempty"`
+	Name   string `protobuf:"bytes,2,opt,name=Name" json:"Name,omitempty"`
+}
+
+func (m *CreateTokenRequest) Reset()                    { *m = CreateTokenRequest{} }
+func (m *CreateTokenRequest) String() string            { return proto.CompactTextString(m) }
+func (*CreateTokenRequest) ProtoMessage()               {}
+func (*CreateTokenRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+
+func (m *CreateTokenRequest) GetUserID() string {
 	if m != nil {
-		return m.Unit
+		return m.UserID
 	}
-	return Activity_DrinkAmount_UNSPECIFIED
+	return ""
 }
 
-type ActivityRequest struct {
-	ID int64 `protobuf:"varint,1,opt,name=ID" json:"ID,omitempty"`
+func (m *CreateTokenRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
 }
 
-func (m *ActivityRequest) Reset()                    { *m = ActivityRequest{} }
-func (m *ActivityRequest) String() string            { return proto.CompactTextString(m) }
-func (*ActivityRequest) ProtoMessage()               {}
-func (*ActivityRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+type CreateTokenResponse struct {
+	Token  *Token `protobuf:"bytes,1,opt,name=Token" json:"Token,omitempty"`
+	Secret string `protobuf:"bytes,2,opt,name=Secret" json:"Secret,omitempty"`
+}
 
-func (m *ActivityRequest) GetID() int64 {
+func (m *CreateTokenResponse) Reset()                    { *m = CreateTokenResponse{} }
+func (m *CreateTokenResponse) String() string            { return proto.CompactTextString(m) }
+func (*CreateTokenResponse) ProtoMessage()               {}
+func (*CreateTokenResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+
+func (m *CreateTokenResponse) GetToken() *Token {
 	if m != nil {
-		return m.ID
+		return m.Token
 	}
-	return 0
+	return nil
 }
 
-type UserActivitiesRequest struct {
+func (m *CreateTokenResponse) GetSecret() string {
+	if m != nil {
+		return m.Secret
+	}
+	return ""
+}
+
+type ListTokensRequest struct {
 	UserID string `protobuf:"bytes,1,opt,name=UserID" json:"UserID,omitempty"`
 }
 
-func (m *UserActivitiesRequest) Reset()                    { *m = UserActivitiesRequest{} }
-func (m *UserActivitiesRequest) String() string            { return proto.CompactTextString(m) }
-func (*UserActivitiesRequest) ProtoMessage()               {}
-func (*UserActivitiesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+func (m *ListTokensRequest) Reset()                    { *m = ListTokensRequest{} }
+func (m *ListTokensRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListTokensRequest) ProtoMessage()               {}
+func (*ListTokensRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
 
-func (m *UserActivitiesRequest) GetUserID() string {
+func (m *ListTokensRequest) GetUserID() string {
 	if m != nil {
 		return m.UserID
 	}
 	return ""
 }
 
-type UserActivitiesResponse struct {
-	Activities []*Activity `protobuf:"bytes,1,rep,name=Activities" json:"Activities,omitempty"`
+type ListTokensResponse struct {
+	Tokens []*Token `protobuf:"bytes,1,rep,name=Tokens" json:"Tokens,omitempty"`
 }
 
-func (m *UserActivitiesResponse) Reset()                    { *m = UserActivitiesResponse{} }
-func (m *UserActivitiesResponse) String() string            { return proto.CompactTextString(m) }
-func (*UserActivitiesResponse) ProtoMessage()               {}
-func (*UserActivitiesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+func (m *ListTokensResponse) Reset()                    { *m = ListTokensResponse{} }
+func (m *ListTokensResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListTokensResponse) ProtoMessage()               {}
+func (*ListTokensResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
 
-func (m *UserActivitiesResponse) GetActivities() []*Activity {
+func (m *ListTokensResponse) GetTokens() []*Token {
 	if m != nil {
-		return m.Activities
+		return m.Tokens
 	}
 	return nil
 }
 
-func init() {
-	proto.RegisterType((*UserRequest)(nil), "UserRequest")
-	proto.RegisterType((*UserResponse)(nil), "UserResponse")
-	proto.RegisterType((*User)(nil), "User")
-	proto.RegisterType((*GoogleUser)(nil), "GoogleUser")
-	proto.RegisterType((*Roaster)(nil), "Roaster")
-	proto.RegisterType((*RoasterRequest)(nil), "RoasterRequest")
-	proto.RegisterType((*RoasterCreateRequest)(nil), "RoasterCreateRequest")
-	proto.RegisterType((*RoasterResponse)(nil), "RoasterResponse")
-	proto.RegisterType((*RoastersRequest)(nil), "RoastersRequest")
-	proto.RegisterType((*RoastersResponse)(nil), "RoastersResponse")
-	proto.RegisterType((*PostActivityRequest)(nil), "PostActivityRequest")
-	proto.RegisterType((*PostActivityRequest_File)(nil), "PostActivityRequest.File")
-	proto.RegisterType((*PostActivityResponse)(nil), "PostActivityResponse")
-	proto.RegisterType((*Activity)(nil), "Activity")
-	proto.RegisterType((*Activity_RoasterInfo)(nil), "Activity.RoasterInfo")
-	proto.RegisterType((*Activity_DrinkAmount)(nil), "Activity.DrinkAmount")
-	proto.RegisterType((*ActivityRequest)(nil), "ActivityRequest")
-	proto.RegisterType((*UserActivitiesRequest)(nil), "UserActivitiesRequest")
-	proto.RegisterType((*UserActivitiesResponse)(nil), "UserActivitiesResponse")
-	proto.RegisterEnum("Activity_DrinkAmount_CaffeineUnit", Activity_DrinkAmount_CaffeineUnit_name, Activity_DrinkAmount_CaffeineUnit_value)
+type RevokeTokenRequest struct {
+	UserID string `protobuf:"bytes,1,opt,name=UserID" json:"UserID,omitempty"`
+	ID     string `protobuf:"bytes,2,opt,name=ID" json:"ID,omitempty"`
+}
+
+func (m *RevokeTokenRequest) Reset()                    { *m = RevokeTokenRequest{} }
+func (m *RevokeTokenRequest) String() string            { return proto.CompactTextString(m) }
+func (*RevokeTokenRequest) ProtoMessage()               {}
+func (*RevokeTokenRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
+
+func (m *RevokeTokenRequest) GetUserID() string {
+	if m != nil {
+		return m.UserID
+	}
+	return ""
+}
+
+func (m *RevokeTokenRequest) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+type RevokeTokenResponse struct {
+	Found bool `protobuf:"varint,1,opt,name=Found" json:"Found,omitempty"`
+}
+
+func (m *RevokeTokenResponse) Reset()                    { *m = RevokeTokenResponse{} }
+func (m *RevokeTokenResponse) String() string            { return proto.CompactTextString(m) }
+func (*RevokeTokenResponse) ProtoMessage()               {}
+func (*RevokeTokenResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{31} }
+
+func (m *RevokeTokenResponse) GetFound() bool {
+	if m != nil {
+		return m.Found
+	}
+	return false
+}
+
+type AuthenticateTokenRequest struct {
+	Secret string `protobuf:"bytes,1,opt,name=Secret" json:"Secret,omitempty"`
+}
+
+func (m *AuthenticateTokenRequest) Reset()                    { *m = AuthenticateTokenRequest{} }
+func (m *AuthenticateTokenRequest) String() string            { return proto.CompactTextString(m) }
+func (*AuthenticateTokenRequest) ProtoMessage()               {}
+func (*AuthenticateTokenRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{32} }
+
+func (m *AuthenticateTokenRequest) GetSecret() string {
+	if m != nil {
+		return m.Secret
+	}
+	return ""
+}
+
+type Session struct {
+	ID        string                      `protobuf:"bytes,1,opt,name=ID" json:"ID,omitempty"`
+	UserID    string                      `protobuf:"bytes,2,opt,name=UserID" json:"UserID,omitempty"`
+	UserAgent string                      `protobuf:"bytes,3,opt,name=UserAgent" json:"UserAgent,omitempty"`
+	IP        string                      `protobuf:"bytes,4,opt,name=IP" json:"IP,omitempty"`
+	Created   *google_protobuf1.Timestamp `protobuf:"bytes,5,opt,name=Created" json:"Created,omitempty"`
+	LastSeen  *google_protobuf1.Timestamp `protobuf:"bytes,6,opt,name=LastSeen" json:"LastSeen,omitempty"`
+	Expires   *google_protobuf1.Timestamp `protobuf:"bytes,7,opt,name=Expires" json:"Expires,omitempty"`
+}
+
+func (m *Session) Reset()                    { *m = Session{} }
+func (m *Session) String() string            { return proto.CompactTextString(m) }
+func (*Session) ProtoMessage()               {}
+func (*Session) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{33} }
+
+func (m *Session) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *Session) GetUserID() string {
+	if m != nil {
+		return m.UserID
+	}
+	return ""
+}
+
+func (m *Session) GetUserAgent() string {
+	if m != nil {
+		return m.UserAgent
+	}
+	return ""
+}
+
+func (m *Session) GetIP() string {
+	if m != nil {
+		return m.IP
+	}
+	return ""
+}
+
+func (m *Session) GetCreated() *google_protobuf1.Timestamp {
+	if m != nil {
+		return m.Created
+	}
+	return nil
+}
+
+func (m *Session) GetLastSeen() *google_protobuf1.Timestamp {
+	if m != nil {
+		return m.LastSeen
+	}
+	return nil
+}
+
+func (m *Session) GetExpires() *google_protobuf1.Timestamp {
+	if m != nil {
+		return m.Expires
+	}
+	return nil
+}
+
+type CreateSessionRequest struct {
+	UserID    string `protobuf:"bytes,1,opt,name=UserID" json:"UserID,omitempty"`
+	UserAgent string `protobuf:"bytes,2,opt,name=UserAgent" json:"UserAgent,omitempty"`
+	IP        string `protobuf:"bytes,3,opt,name=IP" json:"IP,omitempty"`
+}
+
+func (m *CreateSessionRequest) Reset()                    { *m = CreateSessionRequest{} }
+func (m *CreateSessionRequest) String() string            { return proto.CompactTextString(m) }
+func (*CreateSessionRequest) ProtoMessage()               {}
+func (*CreateSessionRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{34} }
+
+func (m *CreateSessionRequest) GetUserID() string {
+	if m != nil {
+		return m.UserID
+	}
+	return ""
+}
+
+func (m *CreateSessionRequest) GetUserAgent() string {
+	if m != nil {
+		return m.UserAgent
+	}
+	return ""
+}
+
+func (m *CreateSessionRequest) GetIP() string {
+	if m != nil {
+		return m.IP
+	}
+	return ""
+}
+
+type CreateSessionResponse struct {
+	Session *Session `protobuf:"bytes,1,opt,name=Session" json:"Session,omitempty"`
+	Secret  string   `protobuf:"bytes,2,opt,name=Secret" json:"Secret,omitempty"`
+}
+
+func (m *CreateSessionResponse) Reset()                    { *m = CreateSessionResponse{} }
+func (m *CreateSessionResponse) String() string            { return proto.CompactTextString(m) }
+func (*CreateSessionResponse) ProtoMessage()               {}
+func (*CreateSessionResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{35} }
+
+func (m *CreateSessionResponse) GetSession() *Session {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
+func (m *CreateSessionResponse) GetSecret() string {
+	if m != nil {
+		return m.Secret
+	}
+	return ""
+}
+
+type GetSessionRequest struct {
+	Secret string `protobuf:"bytes,1,opt,name=Secret" json:"Secret,omitempty"`
+}
+
+func (m *GetSessionRequest) Reset()                    { *m = GetSessionRequest{} }
+func (m *GetSessionRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetSessionRequest) ProtoMessage()               {}
+func (*GetSessionRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{36} }
+
+func (m *GetSessionRequest) GetSecret() string {
+	if m != nil {
+		return m.Secret
+	}
+	return ""
+}
+
+type SessionResponse struct {
+	Found   bool     `protobuf:"varint,1,opt,name=Found" json:"Found,omitempty"`
+	Session *Session `protobuf:"bytes,2,opt,name=Session" json:"Session,omitempty"`
+	User    *User    `protobuf:"bytes,3,opt,name=User" json:"User,omitempty"`
+}
+
+func (m *SessionResponse) Reset()                    { *m = SessionResponse{} }
+func (m *SessionResponse) String() string            { return proto.CompactTextString(m) }
+func (*SessionResponse) ProtoMessage()               {}
+func (*SessionResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37} }
+
+func (m *SessionResponse) GetFound() bool {
+	if m != nil {
+		return m.Found
+	}
+	return false
+}
+
+func (m *SessionResponse) GetSession() *Session {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
+func (m *SessionResponse) GetUser() *User {
+	if m != nil {
+		return m.User
+	}
+	return nil
+}
+
+type ListSessionsRequest struct {
+	UserID string `protobuf:"bytes,1,opt,name=UserID" json:"UserID,omitempty"`
+}
+
+func (m *ListSessionsRequest) Reset()                    { *m = ListSessionsRequest{} }
+func (m *ListSessionsRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListSessionsRequest) ProtoMessage()               {}
+func (*ListSessionsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{38} }
+
+func (m *ListSessionsRequest) GetUserID() string {
+	if m != nil {
+		return m.UserID
+	}
+	return ""
+}
+
+type ListSessionsResponse struct {
+	Sessions []*Session `protobuf:"bytes,1,rep,name=Sessions" json:"Sessions,omitempty"`
+}
+
+func (m *ListSessionsResponse) Reset()                    { *m = ListSessionsResponse{} }
+func (m *ListSessionsResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListSessionsResponse) ProtoMessage()               {}
+func (*ListSessionsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{39} }
+
+func (m *ListSessionsResponse) GetSessions() []*Session {
+	if m != nil {
+		return m.Sessions
+	}
+	return nil
+}
+
+type RevokeSessionRequest struct {
+	UserID string `protobuf:"bytes,1,opt,name=UserID" json:"UserID,omitempty"`
+	ID     string `protobuf:"bytes,2,opt,name=ID" json:"ID,omitempty"`
+}
+
+func (m *RevokeSessionRequest) Reset()                    { *m = RevokeSessionRequest{} }
+func (m *RevokeSessionRequest) String() string            { return proto.CompactTextString(m) }
+func (*RevokeSessionRequest) ProtoMessage()               {}
+func (*RevokeSessionRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{40} }
+
+func (m *RevokeSessionRequest) GetUserID() string {
+	if m != nil {
+		return m.UserID
+	}
+	return ""
+}
+
+func (m *RevokeSessionRequest) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+type RevokeAllSessionsRequest struct {
+	UserID string `protobuf:"bytes,1,opt,name=UserID" json:"UserID,omitempty"`
+}
+
+func (m *RevokeAllSessionsRequest) Reset()                    { *m = RevokeAllSessionsRequest{} }
+func (m *RevokeAllSessionsRequest) String() string            { return proto.CompactTextString(m) }
+func (*RevokeAllSessionsRequest) ProtoMessage()               {}
+func (*RevokeAllSessionsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{41} }
+
+func (m *RevokeAllSessionsRequest) GetUserID() string {
+	if m != nil {
+		return m.UserID
+	}
+	return ""
+}
+
+type RevokeSessionResponse struct {
+	Revoked int32 `protobuf:"varint,1,opt,name=Revoked" json:"Revoked,omitempty"`
+}
+
+func (m *RevokeSessionResponse) Reset()                    { *m = RevokeSessionResponse{} }
+func (m *RevokeSessionResponse) String() string            { return proto.CompactTextString(m) }
+func (*RevokeSessionResponse) ProtoMessage()               {}
+func (*RevokeSessionResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{42} }
+
+func (m *RevokeSessionResponse) GetRevoked() int32 {
+	if m != nil {
+		return m.Revoked
+	}
+	return 0
+}
+
+type DeleteUserRequest struct {
+	UserID string `protobuf:"bytes,1,opt,name=UserID" json:"UserID,omitempty"`
+}
+
+func (m *DeleteUserRequest) Reset()                    { *m = DeleteUserRequest{} }
+func (m *DeleteUserRequest) String() string            { return proto.CompactTextString(m) }
+func (*DeleteUserRequest) ProtoMessage()               {}
+func (*DeleteUserRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{43} }
+
+func (m *DeleteUserRequest) GetUserID() string {
+	if m != nil {
+		return m.UserID
+	}
+	return ""
+}
+
+type GetDeletionRequest struct {
+	ID string `protobuf:"bytes,1,opt,name=ID" json:"ID,omitempty"`
+}
+
+func (m *GetDeletionRequest) Reset()                    { *m = GetDeletionRequest{} }
+func (m *GetDeletionRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetDeletionRequest) ProtoMessage()               {}
+func (*GetDeletionRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{44} }
+
+func (m *GetDeletionRequest) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+// Deletion is the status of an account deletion, which is shown to anyone
+// with its ID, so it has neither the user nor the errors of the job.
+type Deletion struct {
+	ID   string `protobuf:"bytes,1,opt,name=ID" json:"ID,omitempty"`
+	Done bool   `protobuf:"varint,3,opt,name=Done" json:"Done,omitempty"`
+	// Step is the name of the step the job is at, empty when done.
+	Step string `protobuf:"bytes,4,opt,name=Step" json:"Step,omitempty"`
+	// Retrying is set when the last attempt at the step failed.
+	Retrying  bool                        `protobuf:"varint,8,opt,name=Retrying" json:"Retrying,omitempty"`
+	Requested *google_protobuf1.Timestamp `protobuf:"bytes,6,opt,name=Requested" json:"Requested,omitempty"`
+	Updated   *google_protobuf1.Timestamp `protobuf:"bytes,7,opt,name=Updated" json:"Updated,omitempty"`
+}
+
+func (m *Deletion) Reset()                    { *m = Deletion{} }
+func (m *Deletion) String() string            { return proto.CompactTextString(m) }
+func (*Deletion) ProtoMessage()               {}
+func (*Deletion) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{45} }
+
+func (m *Deletion) GetID() string {
+	if m != nil {
+		return m.ID
+	}
+	return ""
+}
+
+func (m *Deletion) GetDone() bool {
+	if m != nil {
+		return m.Done
+	}
+	return false
+}
+
+func (m *Deletion) GetStep() string {
+	if m != nil {
+		return m.Step
+	}
+	return ""
+}
+
+func (m *Deletion) GetRetrying() bool {
+	if m != nil {
+		return m.Retrying
+	}
+	return false
+}
+
+func (m *Deletion) GetRequested() *google_protobuf1.Timestamp {
+	if m != nil {
+		return m.Requested
+	}
+	return nil
+}
+
+func (m *Deletion) GetUpdated() *google_protobuf1.Timestamp {
+	if m != nil {
+		return m.Updated
+	}
+	return nil
+}
+
+type DeletionResponse struct {
+	Found    bool      `protobuf:"varint,1,opt,name=Found" json:"Found,omitempty"`
+	Deletion *Deletion `protobuf:"bytes,2,opt,name=Deletion" json:"Deletion,omitempty"`
+}
+
+func (m *DeletionResponse) Reset()                    { *m = DeletionResponse{} }
+func (m *DeletionResponse) String() string            { return proto.CompactTextString(m) }
+func (*DeletionResponse) ProtoMessage()               {}
+func (*DeletionResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{46} }
+
+func (m *DeletionResponse) GetFound() bool {
+	if m != nil {
+		return m.Found
+	}
+	return false
+}
+
+func (m *DeletionResponse) GetDeletion() *Deletion {
+	if m != nil {
+		return m.Deletion
+	}
+	return nil
+}
+
+type Roaster struct {
+	ID      int64  `protobuf:"varint,1,opt,name=ID" json:"ID,omitempty"`
+	Name    string `protobuf:"bytes,2,opt,name=Name" json:"Name,omitempty"`
+	Picture string `protobuf:"bytes,3,opt,name=Picture" json:"Picture,omitempty"`
+}
+
+func (m *Roaster) Reset()                    { *m = Roaster{} }
+func (m *Roaster) String() string            { return proto.CompactTextString(m) }
+func (*Roaster) ProtoMessage()               {}
+func (*Roaster) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{47} }
+
+func (m *Roaster) GetID() int64 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+func (m *Roaster) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Roaster) GetPicture() string {
+	if m != nil {
+		return m.Picture
+	}
+	return ""
+}
+
+type RoasterRequest struct {
+	// Types that are valid to be assigned to Query:
+	//	*RoasterRequest_ID
+	//	*RoasterRequest_Name
+	Query isRoasterRequest_Query `protobuf_oneof:"Query"`
+}
+
+func (m *RoasterRequest) Reset()                    { *m = RoasterRequest{} }
+func (m *RoasterRequest) String() string            { return proto.CompactTextString(m) }
+func (*RoasterRequest) ProtoMessage()               {}
+func (*RoasterRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{48} }
+
+type isRoasterRequest_Query interface{ isRoasterRequest_Query() }
+
+type RoasterRequest_ID struct {
+	ID int64 `protobuf:"varint,1,opt,name=ID,oneof"`
+}
+type RoasterRequest_Name struct {
+	Name string `protobuf:"bytes,2,opt,name=Name,oneof"`
+}
+
+func (*RoasterRequest_ID) isRoasterRequest_Query()   {}
+func (*RoasterRequest_Name) isRoasterRequest_Query() {}
+
+func (m *RoasterRequest) GetQuery() isRoasterRequest_Query {
+	if m != nil {
+		return m.Query
+	}
+	return nil
+}
+
+func (m *RoasterRequest) GetID() int64 {
+	if x, ok := m.GetQuery().(*RoasterRequest_ID); ok {
+		return x.ID
+	}
+	return 0
+}
+
+func (m *RoasterRequest) GetName() string {
+	if x, ok := m.GetQuery().(*RoasterRequest_Name); ok {
+		return x.Name
+	}
+	return ""
+}
+
+// XXX_OneofFuncs is for the internal use of the proto package.
+func (*RoasterRequest) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
+	return _RoasterRequest_OneofMarshaler, _RoasterRequest_OneofUnmarshaler, _RoasterRequest_OneofSizer, []interface{}{
+		(*RoasterRequest_ID)(nil),
+		(*RoasterRequest_Name)(nil),
+	}
+}
+
+func _RoasterRequest_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
+	m := msg.(*RoasterRequest)
+	// Query
+	switch x := m.Query.(type) {
+	case *RoasterRequest_ID:
+		b.EncodeVarint(1<<3 | proto.WireVarint)
+		b.EncodeVarint(uint64(x.ID))
+	case *RoasterRequest_Name:
+		b.EncodeVarint(2<<3 | proto.WireBytes)
+		b.EncodeStringBytes(x.Name)
+	case nil:
+	default:
+		return fmt.Errorf("RoasterRequest.Query has unexpected type %T", x)
+	}
+	return nil
+}
+
+func _RoasterRequest_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
+	m := msg.(*RoasterRequest)
+	switch tag {
+	case 1: // Query.ID
+		if wire != proto.WireVarint {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeVarint()
+		m.Query = &RoasterRequest_ID{int64(x)}
+		return true, err
+	case 2: // Query.Name
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeStringBytes()
+		m.Query = &RoasterRequest_Name{x}
+		return true, err
+	default:
+		return false, nil
+	}
+}
+
+func _RoasterRequest_OneofSizer(msg proto.Message) (n int) {
+	m := msg.(*RoasterRequest)
+	// Query
+	switch x := m.Query.(type) {
+	case *RoasterRequest_ID:
+		n += proto.SizeVarint(1<<3 | proto.WireVarint)
+		n += proto.SizeVarint(uint64(x.ID))
+	case *RoasterRequest_Name:
+		n += proto.SizeVarint(2<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(len(x.Name)))
+		n += len(x.Name)
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	return n
+}
+
+type RoasterCreateRequest struct {
+	Name string `protobuf:"bytes,2,opt,name=Name" json:"Name,omitempty"`
+}
+
+func (m *RoasterCreateRequest) Reset()                    { *m = RoasterCreateRequest{} }
+func (m *RoasterCreateRequest) String() string            { return proto.CompactTextString(m) }
+func (*RoasterCreateRequest) ProtoMessage()               {}
+func (*RoasterCreateRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{49} }
+
+func (m *RoasterCreateRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type RoasterResponse struct {
+	Found   bool     `protobuf:"varint,1,opt,name=Found" json:"Found,omitempty"`
+	Roaster *Roaster `protobuf:"bytes,2,opt,name=Roaster" json:"Roaster,omitempty"`
+}
+
+func (m *RoasterResponse) Reset()                    { *m = RoasterResponse{} }
+func (m *RoasterResponse) String() string            { return proto.CompactTextString(m) }
+func (*RoasterResponse) ProtoMessage()               {}
+func (*RoasterResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{50} }
+
+func (m *RoasterResponse) GetFound() bool {
+	if m != nil {
+		return m.Found
+	}
+	return false
+}
+
+func (m *RoasterResponse) GetRoaster() *Roaster {
+	if m != nil {
+		return m.Roaster
+	}
+	return nil
+}
+
+// RoastersRequest lists the roasters by ID. Without a PageSize all of them
+// are returned.
+type RoastersRequest struct {
+	PageSize int32 `protobuf:"varint,1,opt,name=PageSize" json:"PageSize,omitempty"`
+	// PageToken is the NextPageToken of the previous page.
+	PageToken string `protobuf:"bytes,2,opt,name=PageToken" json:"PageToken,omitempty"`
+	// Query is a case-insensitive substring of the names to return.
+	Query string `protobuf:"bytes,3,opt,name=Query" json:"Query,omitempty"`
+}
+
+func (m *RoastersRequest) Reset()                    { *m = RoastersRequest{} }
+func (m *RoastersRequest) String() string            { return proto.CompactTextString(m) }
+func (*RoastersRequest) ProtoMessage()               {}
+func (*RoastersRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{51} }
+
+func (m *RoastersRequest) GetPageSize() int32 {
+	if m != nil {
+		return m.PageSize
+	}
+	return 0
+}
+
+func (m *RoastersRequest) GetPageToken() string {
+	if m != nil {
+		return m.PageToken
+	}
+	return ""
+}
+
+func (m *RoastersRequest) GetQuery() string {
+	if m != nil {
+		return m.Query
+	}
+	return ""
+}
+
+type RoastersResponse struct {
+	Results       []*Roaster `protobuf:"bytes,1,rep,name=Results" json:"Results,omitempty"`
+	NextPageToken string     `protobuf:"bytes,2,opt,name=NextPageToken" json:"NextPageToken,omitempty"`
+}
+
+func (m *RoastersResponse) Reset()                    { *m = RoastersResponse{} }
+func (m *RoastersResponse) String() string            { return proto.CompactTextString(m) }
+func (*RoastersResponse) ProtoMessage()               {}
+func (*RoastersResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{52} }
+
+func (m *RoastersResponse) GetResults() []*Roaster {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+func (m *RoastersResponse) GetNextPageToken() string {
+	if m != nil {
+		return m.NextPageToken
+	}
+	return ""
+}
+
+type PostActivityRequest struct {
+	UserID      string                      `protobuf:"bytes,1,opt,name=UserID" json:"UserID,omitempty"`
+	Homebrew    bool                        `protobuf:"varint,2,opt,name=Homebrew" json:"Homebrew,omitempty"`
+	Drink       string                      `protobuf:"bytes,6,opt,name=Drink" json:"Drink,omitempty"`
+	Method      string                      `protobuf:"bytes,3,opt,name=Method" json:"Method,omitempty"`
+	Amount      *Activity_DrinkAmount       `protobuf:"bytes,4,opt,name=Amount" json:"Amount,omitempty"`
+	Date        *google_protobuf1.Timestamp `protobuf:"bytes,5,opt,name=Date" json:"Date,omitempty"`
+	RoasterName string                      `protobuf:"bytes,8,opt,name=RoasterName" json:"RoasterName,omitempty"`
+	Origin      string                      `protobuf:"bytes,7,opt,name=Origin" json:"Origin,omitempty"`
+	Notes       string                      `protobuf:"bytes,9,opt,name=Notes" json:"Notes,omitempty"`
+	Picture     *PostActivityRequest_File   `protobuf:"bytes,10,opt,name=Picture" json:"Picture,omitempty"`
+	Visibility  Visibility                  `protobuf:"varint,11,opt,name=Visibility,enum=Visibility" json:"Visibility,omitempty"`
+}
+
+func (m *PostActivityRequest) Reset()                    { *m = PostActivityRequest{} }
+func (m *PostActivityRequest) String() string            { return proto.CompactTextString(m) }
+func (*PostActivityRequest) ProtoMessage()               {}
+func (*PostActivityRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{53} }
+
+func (m *PostActivityRequest) GetUserID() string {
+	if m != nil {
+		return m.UserID
+	}
+	return ""
+}
+
+func (m *PostActivityRequest) GetHomebrew() bool {
+	if m != nil {
+		return m.Homebrew
+	}
+	return false
+}
+
+func (m *PostActivityRequest) GetDrink() string {
+	if m != nil {
+		return m.Drink
+	}
+	return ""
+}
+
+func (m *PostActivityRequest) GetMethod() string {
+	if m != nil {
+		return m.Method
+	}
+	return ""
+}
+
+func (m *PostActivityRequest) GetAmount() *Activity_DrinkAmount {
+	if m != nil {
+		return m.Amount
+	}
+	return nil
+}
+
+func (m *PostActivityRequest) GetDate() *google_protobuf1.Timestamp {
+	if m != nil {
+		return m.Date
+	}
+	return nil
+}
+
+func (m *PostActivityRequest) GetRoasterName() string {
+	if m != nil {
+		return m.RoasterName
+	}
+	return ""
+}
+
+func (m *PostActivityRequest) GetOrigin() string {
+	if m != nil {
+		return m.Origin
+	}
+	return ""
+}
+
+func (m *PostActivityRequest) GetNotes() string {
+	if m != nil {
+		return m.Notes
+	}
+	return ""
+}
+
+func (m *PostActivityRequest) GetPicture() *PostActivityRequest_File {
+	if m != nil {
+		return m.Picture
+	}
+	return nil
+}
+
+func (m *PostActivityRequest) GetVisibility() Visibility {
+	if m != nil {
+		return m.Visibility
+	}
+	return Visibility_DEFAULT
+}
+
+type PostActivityRequest_File struct {
+	Data        []byte `protobuf:"bytes,1,opt,name=Data,proto3" json:"Data,omitempty"`
+	Filename    string `protobuf:"bytes,2,opt,name=Filename" json:"Filename,omitempty"`
+	ContentType string `protobuf:"bytes,3,opt,name=ContentType" json:"ContentType,omitempty"`
+}
+
+func (m *PostActivityRequest_File) Reset()                    { *m = PostActivityRequest_File{} }
+func (m *PostActivityRequest_File) String() string            { return proto.CompactTextString(m) }
+func (*PostActivityRequest_File) ProtoMessage()               {}
+func (*PostActivityRequest_File) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{53, 0} }
+
+func (m *PostActivityRequest_File) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *PostActivityRequest_File) GetFilename() string {
+	if m != nil {
+		return m.Filename
+	}
+	return ""
+}
+
+func (m *PostActivityRequest_File) GetContentType() string {
+	if m != nil {
+		return m.ContentType
+	}
+	return ""
+}
+
+type UploadPictureResponse struct {
+	URL string `protobuf:"bytes,1,opt,name=URL" json:"URL,omitempty"`
+}
+
+func (m *UploadPictureResponse) Reset()                    { *m = UploadPictureResponse{} }
+func (m *UploadPictureResponse) String() string            { return proto.CompactTextString(m) }
+func (*UploadPictureResponse) ProtoMessage()               {}
+func (*UploadPictureResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{54} }
+
+func (m *UploadPictureResponse) GetURL() string {
+	if m != nil {
+		return m.URL
+	}
+	return ""
+}
+
+type PurgeUserRequest struct {
+	UserID string `protobuf:"bytes,1,opt,name=UserID" json:"UserID,omitempty"`
+	// PictureURLs are other pictures of the user, such as the avatar,
+	// deleted with the last batch if they are in the picture bucket.
+	PictureURLs []string `protobuf:"bytes,2,rep,name=PictureURLs" json:"PictureURLs,omitempty"`
+}
+
+func (m *PurgeUserRequest) Reset()                    { *m = PurgeUserRequest{} }
+func (m *PurgeUserRequest) String() string            { return proto.CompactTextString(m) }
+func (*PurgeUserRequest) ProtoMessage()               {}
+func (*PurgeUserRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{55} }
+
+func (m *PurgeUserRequest) GetUserID() string {
+	if m != nil {
+		return m.UserID
+	}
+	return ""
+}
+
+func (m *PurgeUserRequest) GetPictureURLs() []string {
+	if m != nil {
+		return m.PictureURLs
+	}
+	return nil
+}
+
+type PurgeUserResponse struct {
+	Deleted int32 `protobuf:"varint,1,opt,name=Deleted" json:"Deleted,omitempty"`
+	Done    bool  `protobuf:"varint,2,opt,name=Done" json:"Done,omitempty"`
+}
+
+func (m *PurgeUserResponse) Reset()                    { *m = PurgeUserResponse{} }
+func (m *PurgeUserResponse) String() string            { return proto.CompactTextString(m) }
+func (*PurgeUserResponse) ProtoMessage()               {}
+func (*PurgeUserResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{56} }
+
+func (m *PurgeUserResponse) GetDeleted() int32 {
+	if m != nil {
+		return m.Deleted
+	}
+	return 0
+}
+
+func (m *PurgeUserResponse) GetDone() bool {
+	if m != nil {
+		return m.Done
+	}
+	return false
+}
+
+type PostActivityResponse struct {
+	ID int64 `protobuf:"varint,1,opt,name=ID" json:"ID,omitempty"`
+}
+
+func (m *PostActivityResponse) Reset()                    { *m = PostActivityResponse{} }
+func (m *PostActivityResponse) String() string            { return proto.CompactTextString(m) }
+func (*PostActivityResponse) ProtoMessage()               {}
+func (*PostActivityResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{57} }
+
+func (m *PostActivityResponse) GetID() int64 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+type Activity struct {
+	ID         int64                       `protobuf:"varint,1,opt,name=ID" json:"ID,omitempty"`
+	User       *User                       `protobuf:"bytes,2,opt,name=User" json:"User,omitempty"`
+	Homebrew   bool                        `protobuf:"varint,12,opt,name=Homebrew" json:"Homebrew,omitempty"`
+	Drink      string                      `protobuf:"bytes,3,opt,name=Drink" json:"Drink,omitempty"`
+	Method     string                      `protobuf:"bytes,4,opt,name=Method" json:"Method,omitempty"`
+	Amount     *Activity_DrinkAmount       `protobuf:"bytes,5,opt,name=Amount" json:"Amount,omitempty"`
+	Roaster    *Activity_RoasterInfo       `protobuf:"bytes,6,opt,name=Roaster" json:"Roaster,omitempty"`
+	Origin     string                      `protobuf:"bytes,7,opt,name=Origin" json:"Origin,omitempty"`
+	Notes      string                      `protobuf:"bytes,8,opt,name=Notes" json:"Notes,omitempty"`
+	PictureURL string                      `protobuf:"bytes,9,opt,name=PictureURL" json:"PictureURL,omitempty"`
+	Date       *google_protobuf1.Timestamp `protobuf:"bytes,10,opt,name=Date" json:"Date,omitempty"`
+	LogDate    *google_protobuf1.Timestamp `protobuf:"bytes,11,opt,name=LogDate" json:"LogDate,omitempty"`
+	Visibility Visibility                  `protobuf:"varint,13,opt,name=Visibility,enum=Visibility" json:"Visibility,omitempty"`
+}
+
+func (m *Activity) Reset()                    { *m = Activity{} }
+func (m *Activity) String() string            { return proto.CompactTextString(m) }
+func (*Activity) ProtoMessage()               {}
+func (*Activity) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{58} }
+
+func (m *Activity) GetID() int64 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+func (m *Activity) GetUser() *User {
+	if m != nil {
+		return m.User
+	}
+	return nil
+}
+
+func (m *Activity) GetHomebrew() bool {
+	if m != nil {
+		return m.Homebrew
+	}
+	return false
+}
+
+func (m *Activity) GetDrink() string {
+	if m != nil {
+		return m.Drink
+	}
+	return ""
+}
+
+func (m *Activity) GetMethod() string {
+	if m != nil {
+		return m.Method
+	}
+	return ""
+}
+
+func (m *Activity) GetAmount() *Activity_DrinkAmount {
+	if m != nil {
+		return m.Amount
+	}
+	return nil
+}
+
+func (m *Activity) GetRoaster() *Activity_RoasterInfo {
+	if m != nil {
+		return m.Roaster
+	}
+	return nil
+}
+
+func (m *Activity) GetOrigin() string {
+	if m != nil {
+		return m.Origin
+	}
+	return ""
+}
+
+func (m *Activity) GetNotes() string {
+	if m != nil {
+		return m.Notes
+	}
+	return ""
+}
+
+func (m *Activity) GetPictureURL() string {
+	if m != nil {
+		return m.PictureURL
+	}
+	return ""
+}
+
+func (m *Activity) GetDate() *google_protobuf1.Timestamp {
+	if m != nil {
+		return m.Date
+	}
+	return nil
+}
+
+func (m *Activity) GetLogDate() *google_protobuf1.Timestamp {
+	if m != nil {
+		return m.LogDate
+	}
+	return nil
+}
+
+func (m *Activity) GetVisibility() Visibility {
+	if m != nil {
+		return m.Visibility
+	}
+	return Visibility_DEFAULT
+}
+
+type Activity_RoasterInfo struct {
+	ID   int64  `protobuf:"varint,1,opt,name=ID" json:"ID,omitempty"`
+	Name string `protobuf:"bytes,2,opt,name=Name" json:"Name,omitempty"`
+}
+
+func (m *Activity_RoasterInfo) Reset()                    { *m = Activity_RoasterInfo{} }
+func (m *Activity_RoasterInfo) String() string            { return proto.CompactTextString(m) }
+func (*Activity_RoasterInfo) ProtoMessage()               {}
+func (*Activity_RoasterInfo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{58, 0} }
+
+func (m *Activity_RoasterInfo) GetID() int64 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+func (m *Activity_RoasterInfo) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type Activity_DrinkAmount struct {
+	N    int32                             `protobuf:"varint,1,opt,name=N" json:"N,omitempty"`
+	Unit Activity_DrinkAmount_CaffeineUnit `protobuf:"varint,2,opt,name=Unit,enum=Activity_DrinkAmount_CaffeineUnit" json:"Unit,omitempty"`
+}
+
+func (m *Activity_DrinkAmount) Reset()                    { *m = Activity_DrinkAmount{} }
+func (m *Activity_DrinkAmount) String() string            { return proto.CompactTextString(m) }
+func (*Activity_DrinkAmount) ProtoMessage()               {}
+func (*Activity_DrinkAmount) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{58, 1} }
+
+func (m *Activity_DrinkAmount) GetN() int32 {
+	if m != nil {
+		return m.N
+	}
+	return 0
+}
+
+func (m *Activity_DrinkAmount) GetUnit() Activity_DrinkAmount_CaffeineUnit {
+	if m != nil {
+		return m.Unit
+	}
+	return Activity_DrinkAmount_UNSPECIFIED
+}
+
+// ViewerID is the user the activities are requested for, empty for anonymous
+// visitors. Activities the viewer is not allowed to see are not found.
+type ActivityRequest struct {
+	ID       int64  `protobuf:"varint,1,opt,name=ID" json:"ID,omitempty"`
+	ViewerID string `protobuf:"bytes,2,opt,name=ViewerID" json:"ViewerID,omitempty"`
+}
+
+func (m *ActivityRequest) Reset()                    { *m = ActivityRequest{} }
+func (m *ActivityRequest) String() string            { return proto.CompactTextString(m) }
+func (*ActivityRequest) ProtoMessage()               {}
+func (*ActivityRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{59} }
+
+func (m *ActivityRequest) GetID() int64 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+func (m *ActivityRequest) GetViewerID() string {
+	if m != nil {
+		return m.ViewerID
+	}
+	return ""
+}
+
+// UserActivitiesRequest lists the activities of a user, newest first.
+// Without a PageSize all of them are returned.
+type UserActivitiesRequest struct {
+	UserID   string `protobuf:"bytes,1,opt,name=UserID" json:"UserID,omitempty"`
+	ViewerID string `protobuf:"bytes,2,opt,name=ViewerID" json:"ViewerID,omitempty"`
+	PageSize int32  `protobuf:"varint,3,opt,name=PageSize" json:"PageSize,omitempty"`
+	// PageToken is the NextPageToken of the previous page.
+	PageToken string `protobuf:"bytes,4,opt,name=PageToken" json:"PageToken,omitempty"`
+}
+
+func (m *UserActivitiesRequest) Reset()                    { *m = UserActivitiesRequest{} }
+func (m *UserActivitiesRequest) String() string            { return proto.CompactTextString(m) }
+func (*UserActivitiesRequest) ProtoMessage()               {}
+func (*UserActivitiesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{60} }
+
+func (m *UserActivitiesRequest) GetUserID() string {
+	if m != nil {
+		return m.UserID
+	}
+	return ""
+}
+
+func (m *UserActivitiesRequest) GetViewerID() string {
+	if m != nil {
+		return m.ViewerID
+	}
+	return ""
+}
+
+func (m *UserActivitiesRequest) GetPageSize() int32 {
+	if m != nil {
+		return m.PageSize
+	}
+	return 0
+}
+
+func (m *UserActivitiesRequest) GetPageToken() string {
+	if m != nil {
+		return m.PageToken
+	}
+	return ""
+}
+
+type UserActivitiesResponse struct {
+	Activities    []*Activity `protobuf:"bytes,1,rep,name=Activities" json:"Activities,omitempty"`
+	NextPageToken string      `protobuf:"bytes,2,opt,name=NextPageToken" json:"NextPageToken,omitempty"`
+}
+
+func (m *UserActivitiesResponse) Reset()                    { *m = UserActivitiesResponse{} }
+func (m *UserActivitiesResponse) String() string            { return proto.CompactTextString(m) }
+func (*UserActivitiesResponse) ProtoMessage()               {}
+func (*UserActivitiesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{61} }
+
+func (m *UserActivitiesResponse) GetActivities() []*Activity {
+	if m != nil {
+		return m.Activities
+	}
+	return nil
+}
+
+func (m *UserActivitiesResponse) GetNextPageToken() string {
+	if m != nil {
+		return m.NextPageToken
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*UserRequest)(nil), "UserRequest")
+	proto.RegisterType((*UsernameRequest)(nil), "UsernameRequest")
+	proto.RegisterType((*UserResponse)(nil), "UserResponse")
+	proto.RegisterType((*User)(nil), "User")
+	proto.RegisterType((*FollowRequest)(nil), "FollowRequest")
+	proto.RegisterType((*FollowResponse)(nil), "FollowResponse")
+	proto.RegisterType((*ListFollowersRequest)(nil), "ListFollowersRequest")
+	proto.RegisterType((*ListFollowersResponse)(nil), "ListFollowersResponse")
+	proto.RegisterType((*Follower)(nil), "Follower")
+	proto.RegisterType((*UpdateUserRequest)(nil), "UpdateUserRequest")
+	proto.RegisterType((*GoogleUser)(nil), "GoogleUser")
+	proto.RegisterType((*ExternalProfile)(nil), "ExternalProfile")
+	proto.RegisterType((*AuthorizeExternalRequest)(nil), "AuthorizeExternalRequest")
+	proto.RegisterType((*Identity)(nil), "Identity")
+	proto.RegisterType((*ListIdentitiesRequest)(nil), "ListIdentitiesRequest")
+	proto.RegisterType((*ListIdentitiesResponse)(nil), "ListIdentitiesResponse")
+	proto.RegisterType((*UnlinkIdentityRequest)(nil), "UnlinkIdentityRequest")
+	proto.RegisterType((*UnlinkIdentityResponse)(nil), "UnlinkIdentityResponse")
+	proto.RegisterType((*RegisterRequest)(nil), "RegisterRequest")
+	proto.RegisterType((*EmailRequest)(nil), "EmailRequest")
+	proto.RegisterType((*AccountTokenRequest)(nil), "AccountTokenRequest")
+	proto.RegisterType((*AccountTokenResponse)(nil), "AccountTokenResponse")
+	proto.RegisterType((*PasswordLoginRequest)(nil), "PasswordLoginRequest")
+	proto.RegisterType((*PasswordLoginResponse)(nil), "PasswordLoginResponse")
+	proto.RegisterType((*ResetPasswordRequest)(nil), "ResetPasswordRequest")
+	proto.RegisterType((*Token)(nil), "Token")
+	proto.RegisterType((*CreateTokenRequest)(nil), "CreateTokenRequest")
+	proto.RegisterType((*CreateTokenResponse)(nil), "CreateTokenResponse")
+	proto.RegisterType((*ListTokensRequest)(nil), "ListTokensRequest")
+	proto.RegisterType((*ListTokensResponse)(nil), "ListTokensResponse")
+	proto.RegisterType((*RevokeTokenRequest)(nil), "RevokeTokenRequest")
+	proto.RegisterType((*RevokeTokenResponse)(nil), "RevokeTokenResponse")
+	proto.RegisterType((*AuthenticateTokenRequest)(nil), "AuthenticateTokenRequest")
+	proto.RegisterType((*Session)(nil), "Session")
+	proto.RegisterType((*CreateSessionRequest)(nil), "CreateSessionRequest")
+	proto.RegisterType((*CreateSessionResponse)(nil), "CreateSessionResponse")
+	proto.RegisterType((*GetSessionRequest)(nil), "GetSessionRequest")
+	proto.RegisterType((*SessionResponse)(nil), "SessionResponse")
+	proto.RegisterType((*ListSessionsRequest)(nil), "ListSessionsRequest")
+	proto.RegisterType((*ListSessionsResponse)(nil), "ListSessionsResponse")
+	proto.RegisterType((*RevokeSessionRequest)(nil), "RevokeSessionRequest")
+	proto.RegisterType((*RevokeAllSessionsRequest)(nil), "RevokeAllSessionsRequest")
+	proto.RegisterType((*RevokeSessionResponse)(nil), "RevokeSessionResponse")
+	proto.RegisterType((*DeleteUserRequest)(nil), "DeleteUserRequest")
+	proto.RegisterType((*GetDeletionRequest)(nil), "GetDeletionRequest")
+	proto.RegisterType((*Deletion)(nil), "Deletion")
+	proto.RegisterType((*DeletionResponse)(nil), "DeletionResponse")
+	proto.RegisterType((*Roaster)(nil), "Roaster")
+	proto.RegisterType((*RoasterRequest)(nil), "RoasterRequest")
+	proto.RegisterType((*RoasterCreateRequest)(nil), "RoasterCreateRequest")
+	proto.RegisterType((*RoasterResponse)(nil), "RoasterResponse")
+	proto.RegisterType((*RoastersRequest)(nil), "RoastersRequest")
+	proto.RegisterType((*RoastersResponse)(nil), "RoastersResponse")
+	proto.RegisterType((*PostActivityRequest)(nil), "PostActivityRequest")
+	proto.RegisterType((*PostActivityRequest_File)(nil), "PostActivityRequest.File")
+	proto.RegisterType((*UploadPictureResponse)(nil), "UploadPictureResponse")
+	proto.RegisterType((*PurgeUserRequest)(nil), "PurgeUserRequest")
+	proto.RegisterType((*PurgeUserResponse)(nil), "PurgeUserResponse")
+	proto.RegisterType((*PostActivityResponse)(nil), "PostActivityResponse")
+	proto.RegisterType((*Activity)(nil), "Activity")
+	proto.RegisterType((*Activity_RoasterInfo)(nil), "Activity.RoasterInfo")
+	proto.RegisterType((*Activity_DrinkAmount)(nil), "Activity.DrinkAmount")
+	proto.RegisterType((*ActivityRequest)(nil), "ActivityRequest")
+	proto.RegisterType((*UserActivitiesRequest)(nil), "UserActivitiesRequest")
+	proto.RegisterType((*UserActivitiesResponse)(nil), "UserActivitiesResponse")
+	proto.RegisterEnum("Visibility", Visibility_name, Visibility_value)
+	proto.RegisterEnum("PasswordLoginResponse_Result", PasswordLoginResponse_Result_name, PasswordLoginResponse_Result_value)
+	proto.RegisterEnum("Activity_DrinkAmount_CaffeineUnit", Activity_DrinkAmount_CaffeineUnit_name, Activity_DrinkAmount_CaffeineUnit_value)
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for UserDirectory service
+
+type UserDirectoryClient interface {
+	// AuthorizeGoogle signs in the user with a Google account. It creates
+	// accounts, so it is not exposed through the gateway.
+	AuthorizeGoogle(ctx context.Context, in *GoogleUser, opts ...grpc.CallOption) (*User, error)
+	// AuthorizeExternal signs in the user with an identity of an external
+	// provider, creating a new account on first login. If LinkToUserID is
+	// set, the identity is linked to that account instead. It is not exposed
+	// through the gateway.
+	AuthorizeExternal(ctx context.Context, in *AuthorizeExternalRequest, opts ...grpc.CallOption) (*User, error)
+	ListIdentities(ctx context.Context, in *ListIdentitiesRequest, opts ...grpc.CallOption) (*ListIdentitiesResponse, error)
+	UnlinkIdentity(ctx context.Context, in *UnlinkIdentityRequest, opts ...grpc.CallOption) (*UnlinkIdentityResponse, error)
+	// GetUser and GetUserByUsername have no HTTP mapping, as they return
+	// the settings of the user and private profiles too.
+	GetUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*UserResponse, error)
+	// GetUserByUsername looks up a user by username, ignoring case.
+	GetUserByUsername(ctx context.Context, in *UsernameRequest, opts ...grpc.CallOption) (*UserResponse, error)
+	// UpdateUser changes the profile and settings of the user. Only the
+	// fields listed in Fields are updated, or all of them if it is empty.
+	UpdateUser(ctx context.Context, in *UpdateUserRequest, opts ...grpc.CallOption) (*User, error)
+	// Following. Following an account that is not public has to be approved
+	// by its owner, until then the follow is pending.
+	Follow(ctx context.Context, in *FollowRequest, opts ...grpc.CallOption) (*FollowResponse, error)
+	Unfollow(ctx context.Context, in *FollowRequest, opts ...grpc.CallOption) (*FollowResponse, error)
+	GetFollow(ctx context.Context, in *FollowRequest, opts ...grpc.CallOption) (*FollowResponse, error)
+	ApproveFollower(ctx context.Context, in *FollowRequest, opts ...grpc.CallOption) (*FollowResponse, error)
+	ListFollowers(ctx context.Context, in *ListFollowersRequest, opts ...grpc.CallOption) (*ListFollowersResponse, error)
+	// Personal access tokens. Only a hash of the token secret is stored, so
+	// the secret is returned only once by CreateToken.
+	CreateToken(ctx context.Context, in *CreateTokenRequest, opts ...grpc.CallOption) (*CreateTokenResponse, error)
+	ListTokens(ctx context.Context, in *ListTokensRequest, opts ...grpc.CallOption) (*ListTokensResponse, error)
+	RevokeToken(ctx context.Context, in *RevokeTokenRequest, opts ...grpc.CallOption) (*RevokeTokenResponse, error)
+	AuthenticateToken(ctx context.Context, in *AuthenticateTokenRequest, opts ...grpc.CallOption) (*UserResponse, error)
+	// Login sessions of the web service. Like tokens, only a hash of the
+	// session secret is stored and the secret is returned only on creation.
+	CreateSession(ctx context.Context, in *CreateSessionRequest, opts ...grpc.CallOption) (*CreateSessionResponse, error)
+	GetSession(ctx context.Context, in *GetSessionRequest, opts ...grpc.CallOption) (*SessionResponse, error)
+	ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error)
+	RevokeSession(ctx context.Context, in *RevokeSessionRequest, opts ...grpc.CallOption) (*RevokeSessionResponse, error)
+	RevokeAllSessions(ctx context.Context, in *RevokeAllSessionsRequest, opts ...grpc.CallOption) (*RevokeSessionResponse, error)
+	// Local accounts with email and password, for deployments without an
+	// external identity provider. The tokens returned by Register,
+	// RequestEmailVerification and RequestPasswordReset are meant to be
+	// emailed to the user, and are empty if there is nothing to send.
+	Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*AccountTokenResponse, error)
+	RequestEmailVerification(ctx context.Context, in *EmailRequest, opts ...grpc.CallOption) (*AccountTokenResponse, error)
+	VerifyEmail(ctx context.Context, in *AccountTokenRequest, opts ...grpc.CallOption) (*UserResponse, error)
+	PasswordLogin(ctx context.Context, in *PasswordLoginRequest, opts ...grpc.CallOption) (*PasswordLoginResponse, error)
+	RequestPasswordReset(ctx context.Context, in *EmailRequest, opts ...grpc.CallOption) (*AccountTokenResponse, error)
+	ResetPassword(ctx context.Context, in *ResetPasswordRequest, opts ...grpc.CallOption) (*UserResponse, error)
+	// Account deletion. DeleteUser hides the account and logs it out right
+	// away, and starts a background job removing its data, the progress of
+	// which is reported by GetDeletion.
+	DeleteUser(ctx context.Context, in *DeleteUserRequest, opts ...grpc.CallOption) (*Deletion, error)
+	GetDeletion(ctx context.Context, in *GetDeletionRequest, opts ...grpc.CallOption) (*DeletionResponse, error)
+}
+
+type userDirectoryClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewUserDirectoryClient(cc *grpc.ClientConn) UserDirectoryClient {
+	return &userDirectoryClient{cc}
+}
+
+func (c *userDirectoryClient) AuthorizeGoogle(ctx context.Context, in *GoogleUser, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := grpc.Invoke(ctx, "/UserDirectory/AuthorizeGoogle", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) AuthorizeExternal(ctx context.Context, in *AuthorizeExternalRequest, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := grpc.Invoke(ctx, "/UserDirectory/AuthorizeExternal", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) ListIdentities(ctx context.Context, in *ListIdentitiesRequest, opts ...grpc.CallOption) (*ListIdentitiesResponse, error) {
+	out := new(ListIdentitiesResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/ListIdentities", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) UnlinkIdentity(ctx context.Context, in *UnlinkIdentityRequest, opts ...grpc.CallOption) (*UnlinkIdentityResponse, error) {
+	out := new(UnlinkIdentityResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/UnlinkIdentity", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) GetUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*UserResponse, error) {
+	out := new(UserResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/GetUser", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) GetUserByUsername(ctx context.Context, in *UsernameRequest, opts ...grpc.CallOption) (*UserResponse, error) {
+	out := new(UserResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/GetUserByUsername", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) UpdateUser(ctx context.Context, in *UpdateUserRequest, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := grpc.Invoke(ctx, "/UserDirectory/UpdateUser", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) Follow(ctx context.Context, in *FollowRequest, opts ...grpc.CallOption) (*FollowResponse, error) {
+	out := new(FollowResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/Follow", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) Unfollow(ctx context.Context, in *FollowRequest, opts ...grpc.CallOption) (*FollowResponse, error) {
+	out := new(FollowResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/Unfollow", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) GetFollow(ctx context.Context, in *FollowRequest, opts ...grpc.CallOption) (*FollowResponse, error) {
+	out := new(FollowResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/GetFollow", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) ApproveFollower(ctx context.Context, in *FollowRequest, opts ...grpc.CallOption) (*FollowResponse, error) {
+	out := new(FollowResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/ApproveFollower", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) ListFollowers(ctx context.Context, in *ListFollowersRequest, opts ...grpc.CallOption) (*ListFollowersResponse, error) {
+	out := new(ListFollowersResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/ListFollowers", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) CreateToken(ctx context.Context, in *CreateTokenRequest, opts ...grpc.CallOption) (*CreateTokenResponse, error) {
+	out := new(CreateTokenResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/CreateToken", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) ListTokens(ctx context.Context, in *ListTokensRequest, opts ...grpc.CallOption) (*ListTokensResponse, error) {
+	out := new(ListTokensResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/ListTokens", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) RevokeToken(ctx context.Context, in *RevokeTokenRequest, opts ...grpc.CallOption) (*RevokeTokenResponse, error) {
+	out := new(RevokeTokenResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/RevokeToken", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) AuthenticateToken(ctx context.Context, in *AuthenticateTokenRequest, opts ...grpc.CallOption) (*UserResponse, error) {
+	out := new(UserResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/AuthenticateToken", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) CreateSession(ctx context.Context, in *CreateSessionRequest, opts ...grpc.CallOption) (*CreateSessionResponse, error) {
+	out := new(CreateSessionResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/CreateSession", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) GetSession(ctx context.Context, in *GetSessionRequest, opts ...grpc.CallOption) (*SessionResponse, error) {
+	out := new(SessionResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/GetSession", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error) {
+	out := new(ListSessionsResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/ListSessions", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) RevokeSession(ctx context.Context, in *RevokeSessionRequest, opts ...grpc.CallOption) (*RevokeSessionResponse, error) {
+	out := new(RevokeSessionResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/RevokeSession", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) RevokeAllSessions(ctx context.Context, in *RevokeAllSessionsRequest, opts ...grpc.CallOption) (*RevokeSessionResponse, error) {
+	out := new(RevokeSessionResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/RevokeAllSessions", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*AccountTokenResponse, error) {
+	out := new(AccountTokenResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/Register", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) RequestEmailVerification(ctx context.Context, in *EmailRequest, opts ...grpc.CallOption) (*AccountTokenResponse, error) {
+	out := new(AccountTokenResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/RequestEmailVerification", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) VerifyEmail(ctx context.Context, in *AccountTokenRequest, opts ...grpc.CallOption) (*UserResponse, error) {
+	out := new(UserResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/VerifyEmail", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) PasswordLogin(ctx context.Context, in *PasswordLoginRequest, opts ...grpc.CallOption) (*PasswordLoginResponse, error) {
+	out := new(PasswordLoginResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/PasswordLogin", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) RequestPasswordReset(ctx context.Context, in *EmailRequest, opts ...grpc.CallOption) (*AccountTokenResponse, error) {
+	out := new(AccountTokenResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/RequestPasswordReset", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) ResetPassword(ctx context.Context, in *ResetPasswordRequest, opts ...grpc.CallOption) (*UserResponse, error) {
+	out := new(UserResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/ResetPassword", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) DeleteUser(ctx context.Context, in *DeleteUserRequest, opts ...grpc.CallOption) (*Deletion, error) {
+	out := new(Deletion)
+	err := grpc.Invoke(ctx, "/UserDirectory/DeleteUser", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userDirectoryClient) GetDeletion(ctx context.Context, in *GetDeletionRequest, opts ...grpc.CallOption) (*DeletionResponse, error) {
+	out := new(DeletionResponse)
+	err := grpc.Invoke(ctx, "/UserDirectory/GetDeletion", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for UserDirectory service
+
+type UserDirectoryServer interface {
+	// AuthorizeGoogle signs in the user with a Google account. It creates
+	// accounts, so it is not exposed through the gateway.
+	AuthorizeGoogle(context.Context, *GoogleUser) (*User, error)
+	// AuthorizeExternal signs in the user with an identity of an external
+	// provider, creating a new account on first login. If LinkToUserID is
+	// set, the identity is linked to that account instead. It is not exposed
+	// through the gateway.
+	AuthorizeExternal(context.Context, *AuthorizeExternalRequest) (*User, error)
+	ListIdentities(context.Context, *ListIdentitiesRequest) (*ListIdentitiesResponse, error)
+	UnlinkIdentity(context.Context, *UnlinkIdentityRequest) (*UnlinkIdentityResponse, error)
+	// GetUser and GetUserByUsername have no HTTP mapping, as they return
+	// the settings of the user and private profiles too.
+	GetUser(context.Context, *UserRequest) (*UserResponse, error)
+	// GetUserByUsername looks up a user by username, ignoring case.
+	GetUserByUsername(context.Context, *UsernameRequest) (*UserResponse, error)
+	// UpdateUser changes the profile and settings of the user. Only the
+	// fields listed in Fields are updated, or all of them if it is empty.
+	UpdateUser(context.Context, *UpdateUserRequest) (*User, error)
+	// Following. Following an account that is not public has to be approved
+	// by its owner, until then the follow is pending.
+	Follow(context.Context, *FollowRequest) (*FollowResponse, error)
+	Unfollow(context.Context, *FollowRequest) (*FollowResponse, error)
+	GetFollow(context.Context, *FollowRequest) (*FollowResponse, error)
+	ApproveFollower(context.Context, *FollowRequest) (*FollowResponse, error)
+	ListFollowers(context.Context, *ListFollowersRequest) (*ListFollowersResponse, error)
+	// Personal access tokens. Only a hash of the token secret is stored, so
+	// the secret is returned only once by CreateToken.
+	CreateToken(context.Context, *CreateTokenRequest) (*CreateTokenResponse, error)
+	ListTokens(context.Context, *ListTokensRequest) (*ListTokensResponse, error)
+	RevokeToken(context.Context, *RevokeTokenRequest) (*RevokeTokenResponse, error)
+	AuthenticateToken(context.Context, *AuthenticateTokenRequest) (*UserResponse, error)
+	// Login sessions of the web service. Like tokens, only a hash of the
+	// session secret is stored and the secret is returned only on creation.
+	CreateSession(context.Context, *CreateSessionRequest) (*CreateSessionResponse, error)
+	GetSession(context.Context, *GetSessionRequest) (*SessionResponse, error)
+	ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error)
+	RevokeSession(context.Context, *RevokeSessionRequest) (*RevokeSessionResponse, error)
+	RevokeAllSessions(context.Context, *RevokeAllSessionsRequest) (*RevokeSessionResponse, error)
+	// Local accounts with email and password, for deployments without an
+	// external identity provider. The tokens returned by Register,
+	// RequestEmailVerification and RequestPasswordReset are meant to be
+	// emailed to the user, and are empty if there is nothing to send.
+	Register(context.Context, *RegisterRequest) (*AccountTokenResponse, error)
+	RequestEmailVerification(context.Context, *EmailRequest) (*AccountTokenResponse, error)
+	VerifyEmail(context.Context, *AccountTokenRequest) (*UserResponse, error)
+	PasswordLogin(context.Context, *PasswordLoginRequest) (*PasswordLoginResponse, error)
+	RequestPasswordReset(context.Context, *EmailRequest) (*AccountTokenResponse, error)
+	ResetPassword(context.Context, *ResetPasswordRequest) (*UserResponse, error)
+	// Account deletion. DeleteUser hides the account and logs it out right
+	// away, and starts a background job removing its data, the progress of
+	// which is reported by GetDeletion.
+	DeleteUser(context.Context, *DeleteUserRequest) (*Deletion, error)
+	GetDeletion(context.Context, *GetDeletionRequest) (*DeletionResponse, error)
+}
+
+func RegisterUserDirectoryServer(s *grpc.Server, srv UserDirectoryServer) {
+	s.RegisterService(&_UserDirectory_serviceDesc, srv)
+}
+
+func _UserDirectory_AuthorizeGoogle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GoogleUser)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).AuthorizeGoogle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/AuthorizeGoogle",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).AuthorizeGoogle(ctx, req.(*GoogleUser))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserDirectory_AuthorizeExternal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthorizeExternalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).AuthorizeExternal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/AuthorizeExternal",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).AuthorizeExternal(ctx, req.(*AuthorizeExternalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserDirectory_ListIdentities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListIdentitiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).ListIdentities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/ListIdentities",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).ListIdentities(ctx, req.(*ListIdentitiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserDirectory_UnlinkIdentity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnlinkIdentityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).UnlinkIdentity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/UnlinkIdentity",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).UnlinkIdentity(ctx, req.(*UnlinkIdentityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserDirectory_GetUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).GetUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/GetUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).GetUser(ctx, req.(*UserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserDirectory_GetUserByUsername_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UsernameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).GetUserByUsername(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/GetUserByUsername",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).GetUserByUsername(ctx, req.(*UsernameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserDirectory_UpdateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).UpdateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/UpdateUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).UpdateUser(ctx, req.(*UpdateUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserDirectory_Follow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FollowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).Follow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/Follow",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).Follow(ctx, req.(*FollowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserDirectory_Unfollow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FollowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).Unfollow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/Unfollow",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).Unfollow(ctx, req.(*FollowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserDirectory_GetFollow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FollowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).GetFollow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/GetFollow",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).GetFollow(ctx, req.(*FollowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserDirectory_ApproveFollower_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FollowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).ApproveFollower(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/ApproveFollower",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).ApproveFollower(ctx, req.(*FollowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserDirectory_ListFollowers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFollowersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).ListFollowers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/ListFollowers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).ListFollowers(ctx, req.(*ListFollowersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserDirectory_CreateToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).CreateToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/CreateToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).CreateToken(ctx, req.(*CreateTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserDirectory_ListTokens_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTokensRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).ListTokens(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/ListTokens",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).ListTokens(ctx, req.(*ListTokensRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserDirectory_RevokeToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).RevokeToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/RevokeToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).RevokeToken(ctx, req.(*RevokeTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserDirectory_AuthenticateToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthenticateTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).AuthenticateToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/AuthenticateToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).AuthenticateToken(ctx, req.(*AuthenticateTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserDirectory_CreateSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).CreateSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/CreateSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).CreateSession(ctx, req.(*CreateSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+func _UserDirectory_GetSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).GetSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/GetSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).GetSession(ctx, req.(*GetSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
 
-// Client API for UserDirectory service
+func _UserDirectory_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/ListSessions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).ListSessions(ctx, req.(*ListSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
 
-type UserDirectoryClient interface {
-	AuthorizeGoogle(ctx context.Context, in *GoogleUser, opts ...grpc.CallOption) (*User, error)
-	GetUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*UserResponse, error)
+func _UserDirectory_RevokeSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).RevokeSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/RevokeSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).RevokeSession(ctx, req.(*RevokeSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-type userDirectoryClient struct {
-	cc *grpc.ClientConn
+func _UserDirectory_RevokeAllSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeAllSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).RevokeAllSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/RevokeAllSessions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).RevokeAllSessions(ctx, req.(*RevokeAllSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func NewUserDirectoryClient(cc *grpc.ClientConn) UserDirectoryClient {
-	return &userDirectoryClient{cc}
+func _UserDirectory_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/Register",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).Register(ctx, req.(*RegisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *userDirectoryClient) AuthorizeGoogle(ctx context.Context, in *GoogleUser, opts ...grpc.CallOption) (*User, error) {
-	out := new(User)
-	err := grpc.Invoke(ctx, "/UserDirectory/AuthorizeGoogle", in, out, c.cc, opts...)
-	if err != nil {
+func _UserDirectory_RequestEmailVerification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmailRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).RequestEmailVerification(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/RequestEmailVerification",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).RequestEmailVerification(ctx, req.(*EmailRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *userDirectoryClient) GetUser(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*UserResponse, error) {
-	out := new(UserResponse)
-	err := grpc.Invoke(ctx, "/UserDirectory/GetUser", in, out, c.cc, opts...)
-	if err != nil {
+func _UserDirectory_VerifyEmail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountTokenRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).VerifyEmail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/VerifyEmail",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).VerifyEmail(ctx, req.(*AccountTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-// Server API for UserDirectory service
+func _UserDirectory_PasswordLogin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PasswordLoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).PasswordLogin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/PasswordLogin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).PasswordLogin(ctx, req.(*PasswordLoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
 
-type UserDirectoryServer interface {
-	AuthorizeGoogle(context.Context, *GoogleUser) (*User, error)
-	GetUser(context.Context, *UserRequest) (*UserResponse, error)
+func _UserDirectory_RequestPasswordReset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmailRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).RequestPasswordReset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/RequestPasswordReset",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).RequestPasswordReset(ctx, req.(*EmailRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func RegisterUserDirectoryServer(s *grpc.Server, srv UserDirectoryServer) {
-	s.RegisterService(&_UserDirectory_serviceDesc, srv)
+func _UserDirectory_ResetPassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetPasswordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserDirectoryServer).ResetPassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/UserDirectory/ResetPassword",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserDirectoryServer).ResetPassword(ctx, req.(*ResetPasswordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func _UserDirectory_AuthorizeGoogle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GoogleUser)
+func _UserDirectory_DeleteUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteUserRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(UserDirectoryServer).AuthorizeGoogle(ctx, in)
+		return srv.(UserDirectoryServer).DeleteUser(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/UserDirectory/AuthorizeGoogle",
+		FullMethod: "/UserDirectory/DeleteUser",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(UserDirectoryServer).AuthorizeGoogle(ctx, req.(*GoogleUser))
+		return srv.(UserDirectoryServer).DeleteUser(ctx, req.(*DeleteUserRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _UserDirectory_GetUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(UserRequest)
+func _UserDirectory_GetDeletion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDeletionRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(UserDirectoryServer).GetUser(ctx, in)
+		return srv.(UserDirectoryServer).GetDeletion(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/UserDirectory/GetUser",
+		FullMethod: "/UserDirectory/GetDeletion",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(UserDirectoryServer).GetUser(ctx, req.(*UserRequest))
+		return srv.(UserDirectoryServer).GetDeletion(ctx, req.(*GetDeletionRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -850,10 +3131,118 @@ var _UserDirectory_serviceDesc = grpc.ServiceDesc{
 			MethodName: "AuthorizeGoogle",
 			Handler:    _UserDirectory_AuthorizeGoogle_Handler,
 		},
+		{
+			MethodName: "AuthorizeExternal",
+			Handler:    _UserDirectory_AuthorizeExternal_Handler,
+		},
+		{
+			MethodName: "ListIdentities",
+			Handler:    _UserDirectory_ListIdentities_Handler,
+		},
+		{
+			MethodName: "UnlinkIdentity",
+			Handler:    _UserDirectory_UnlinkIdentity_Handler,
+		},
 		{
 			MethodName: "GetUser",
 			Handler:    _UserDirectory_GetUser_Handler,
 		},
+		{
+			MethodName: "GetUserByUsername",
+			Handler:    _UserDirectory_GetUserByUsername_Handler,
+		},
+		{
+			MethodName: "UpdateUser",
+			Handler:    _UserDirectory_UpdateUser_Handler,
+		},
+		{
+			MethodName: "Follow",
+			Handler:    _UserDirectory_Follow_Handler,
+		},
+		{
+			MethodName: "Unfollow",
+			Handler:    _UserDirectory_Unfollow_Handler,
+		},
+		{
+			MethodName: "GetFollow",
+			Handler:    _UserDirectory_GetFollow_Handler,
+		},
+		{
+			MethodName: "ApproveFollower",
+			Handler:    _UserDirectory_ApproveFollower_Handler,
+		},
+		{
+			MethodName: "ListFollowers",
+			Handler:    _UserDirectory_ListFollowers_Handler,
+		},
+		{
+			MethodName: "CreateToken",
+			Handler:    _UserDirectory_CreateToken_Handler,
+		},
+		{
+			MethodName: "ListTokens",
+			Handler:    _UserDirectory_ListTokens_Handler,
+		},
+		{
+			MethodName: "RevokeToken",
+			Handler:    _UserDirectory_RevokeToken_Handler,
+		},
+		{
+			MethodName: "AuthenticateToken",
+			Handler:    _UserDirectory_AuthenticateToken_Handler,
+		},
+		{
+			MethodName: "CreateSession",
+			Handler:    _UserDirectory_CreateSession_Handler,
+		},
+		{
+			MethodName: "GetSession",
+			Handler:    _UserDirectory_GetSession_Handler,
+		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _UserDirectory_ListSessions_Handler,
+		},
+		{
+			MethodName: "RevokeSession",
+			Handler:    _UserDirectory_RevokeSession_Handler,
+		},
+		{
+			MethodName: "RevokeAllSessions",
+			Handler:    _UserDirectory_RevokeAllSessions_Handler,
+		},
+		{
+			MethodName: "Register",
+			Handler:    _UserDirectory_Register_Handler,
+		},
+		{
+			MethodName: "RequestEmailVerification",
+			Handler:    _UserDirectory_RequestEmailVerification_Handler,
+		},
+		{
+			MethodName: "VerifyEmail",
+			Handler:    _UserDirectory_VerifyEmail_Handler,
+		},
+		{
+			MethodName: "PasswordLogin",
+			Handler:    _UserDirectory_PasswordLogin_Handler,
+		},
+		{
+			MethodName: "RequestPasswordReset",
+			Handler:    _UserDirectory_RequestPasswordReset_Handler,
+		},
+		{
+			MethodName: "ResetPassword",
+			Handler:    _UserDirectory_ResetPassword_Handler,
+		},
+		{
+			MethodName: "DeleteUser",
+			Handler:    _UserDirectory_DeleteUser_Handler,
+		},
+		{
+			MethodName: "GetDeletion",
+			Handler:    _UserDirectory_GetDeletion_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "coffeelog.proto",
@@ -862,7 +3251,11 @@ var _UserDirectory_serviceDesc = grpc.ServiceDesc{
 // Client API for RoasterDirectory service
 
 type RoasterDirectoryClient interface {
+	// GetRoaster looks up a roaster by ID or Name, given as a query
+	// parameter over HTTP (oneof fields cannot be bound to the path).
 	GetRoaster(ctx context.Context, in *RoasterRequest, opts ...grpc.CallOption) (*RoasterResponse, error)
+	// CreateRoaster has no HTTP mapping, as it is only done for logged in
+	// users.
 	CreateRoaster(ctx context.Context, in *RoasterCreateRequest, opts ...grpc.CallOption) (*Roaster, error)
 	ListRoasters(ctx context.Context, in *RoastersRequest, opts ...grpc.CallOption) (*RoastersResponse, error)
 }
@@ -905,7 +3298,11 @@ func (c *roasterDirectoryClient) ListRoasters(ctx context.Context, in *RoastersR
 // Server API for RoasterDirectory service
 
 type RoasterDirectoryServer interface {
+	// GetRoaster looks up a roaster by ID or Name, given as a query
+	// parameter over HTTP (oneof fields cannot be bound to the path).
 	GetRoaster(context.Context, *RoasterRequest) (*RoasterResponse, error)
+	// CreateRoaster has no HTTP mapping, as it is only done for logged in
+	// users.
 	CreateRoaster(context.Context, *RoasterCreateRequest) (*Roaster, error)
 	ListRoasters(context.Context, *RoastersRequest) (*RoastersResponse, error)
 }
@@ -995,6 +3392,12 @@ type ActivityDirectoryClient interface {
 	PostActivity(ctx context.Context, in *PostActivityRequest, opts ...grpc.CallOption) (*PostActivityResponse, error)
 	GetActivity(ctx context.Context, in *ActivityRequest, opts ...grpc.CallOption) (*Activity, error)
 	GetUserActivities(ctx context.Context, in *UserActivitiesRequest, opts ...grpc.CallOption) (*UserActivitiesResponse, error)
+	// UploadPicture stores a picture in the public picture bucket, for
+	// pictures not attached to an activity, such as avatars.
+	UploadPicture(ctx context.Context, in *PostActivityRequest_File, opts ...grpc.CallOption) (*UploadPictureResponse, error)
+	// PurgeUser deletes a batch of the activities of a user with their
+	// pictures, and is called until Done when the account is deleted.
+	PurgeUser(ctx context.Context, in *PurgeUserRequest, opts ...grpc.CallOption) (*PurgeUserResponse, error)
 }
 
 type activityDirectoryClient struct {
@@ -1032,12 +3435,36 @@ func (c *activityDirectoryClient) GetUserActivities(ctx context.Context, in *Use
 	return out, nil
 }
 
+func (c *activityDirectoryClient) UploadPicture(ctx context.Context, in *PostActivityRequest_File, opts ...grpc.CallOption) (*UploadPictureResponse, error) {
+	out := new(UploadPictureResponse)
+	err := grpc.Invoke(ctx, "/ActivityDirectory/UploadPicture", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *activityDirectoryClient) PurgeUser(ctx context.Context, in *PurgeUserRequest, opts ...grpc.CallOption) (*PurgeUserResponse, error) {
+	out := new(PurgeUserResponse)
+	err := grpc.Invoke(ctx, "/ActivityDirectory/PurgeUser", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Server API for ActivityDirectory service
 
 type ActivityDirectoryServer interface {
 	PostActivity(context.Context, *PostActivityRequest) (*PostActivityResponse, error)
 	GetActivity(context.Context, *ActivityRequest) (*Activity, error)
 	GetUserActivities(context.Context, *UserActivitiesRequest) (*UserActivitiesResponse, error)
+	// UploadPicture stores a picture in the public picture bucket, for
+	// pictures not attached to an activity, such as avatars.
+	UploadPicture(context.Context, *PostActivityRequest_File) (*UploadPictureResponse, error)
+	// PurgeUser deletes a batch of the activities of a user with their
+	// pictures, and is called until Done when the account is deleted.
+	PurgeUser(context.Context, *PurgeUserRequest) (*PurgeUserResponse, error)
 }
 
 func RegisterActivityDirectoryServer(s *grpc.Server, srv ActivityDirectoryServer) {
@@ -1098,6 +3525,42 @@ func _ActivityDirectory_GetUserActivities_Handler(srv interface{}, ctx context.C
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ActivityDirectory_UploadPicture_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PostActivityRequest_File)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ActivityDirectoryServer).UploadPicture(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ActivityDirectory/UploadPicture",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ActivityDirectoryServer).UploadPicture(ctx, req.(*PostActivityRequest_File))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ActivityDirectory_PurgeUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ActivityDirectoryServer).PurgeUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ActivityDirectory/PurgeUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ActivityDirectoryServer).PurgeUser(ctx, req.(*PurgeUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _ActivityDirectory_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "ActivityDirectory",
 	HandlerType: (*ActivityDirectoryServer)(nil),
@@ -1114,6 +3577,14 @@ var _ActivityDirectory_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetUserActivities",
 			Handler:    _ActivityDirectory_GetUserActivities_Handler,
 		},
+		{
+			MethodName: "UploadPicture",
+			Handler:    _ActivityDirectory_UploadPicture_Handler,
+		},
+		{
+			MethodName: "PurgeUser",
+			Handler:    _ActivityDirectory_PurgeUser_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "coffeelog.proto",
@@ -1122,63 +3593,172 @@ var _ActivityDirectory_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("coffeelog.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 915 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x55, 0x5b, 0x6e, 0xeb, 0x44,
-	0x18, 0xb6, 0x1b, 0xe7, 0xf6, 0x3b, 0x6d, 0xd2, 0x21, 0x2d, 0x3e, 0x96, 0x80, 0x32, 0x0f, 0x50,
-	0x10, 0x4c, 0x75, 0x72, 0xa0, 0x6f, 0x08, 0x95, 0xa4, 0x4d, 0x23, 0x4a, 0x5a, 0xdc, 0x46, 0xe2,
-	0xd5, 0xed, 0x99, 0xe4, 0x58, 0x24, 0x9e, 0x60, 0x8f, 0x41, 0x61, 0x0b, 0x6c, 0x86, 0x17, 0xf6,
-	0xc0, 0x02, 0x58, 0x10, 0x9a, 0xf1, 0x8c, 0xed, 0xb8, 0x2e, 0xad, 0x78, 0xfc, 0xef, 0x97, 0xef,
-	0x9b, 0x7f, 0xa0, 0xfb, 0xc0, 0xe6, 0x73, 0x4a, 0x97, 0x6c, 0x41, 0xd6, 0x11, 0xe3, 0xcc, 0xfd,
-	0x68, 0xc1, 0xd8, 0x62, 0x49, 0x4f, 0xa4, 0x74, 0x9f, 0xcc, 0x4f, 0x78, 0xb0, 0xa2, 0x31, 0xf7,
-	0x57, 0xeb, 0xd4, 0x01, 0x7f, 0x00, 0xf6, 0x2c, 0xa6, 0x91, 0x47, 0x7f, 0x49, 0x68, 0xcc, 0xd1,
-	0x1e, 0xec, 0x4c, 0x46, 0x8e, 0x79, 0x64, 0x1e, 0xb7, 0xbd, 0x9d, 0xc9, 0x08, 0x7f, 0x0b, 0x9d,
-	0xd4, 0x1c, 0xaf, 0x59, 0x18, 0x53, 0xd4, 0x87, 0xfa, 0x05, 0x4b, 0xc2, 0xb7, 0xd2, 0xa5, 0xe5,
-	0xa5, 0x02, 0x7a, 0x05, 0x96, 0xf0, 0x72, 0x76, 0x8e, 0xcc, 0x63, 0x7b, 0x50, 0x27, 0x32, 0x44,
-	0xaa, 0xb0, 0x97, 0x9a, 0xca, 0x89, 0xd1, 0x11, 0xd8, 0xa3, 0x20, 0x5e, 0x2f, 0xfd, 0xcd, 0xd4,
-	0x5f, 0x51, 0x19, 0xd9, 0xf6, 0x8a, 0x2a, 0xe4, 0x40, 0xf3, 0x26, 0x78, 0xe0, 0x49, 0x44, 0x9d,
-	0x9a, 0xb4, 0x6a, 0x11, 0x73, 0x80, 0xb1, 0x1c, 0xeb, 0x7f, 0x66, 0xfe, 0x10, 0x40, 0xa5, 0x9a,
-	0x79, 0x57, 0x2a, 0x79, 0x41, 0x23, 0x86, 0x3c, 0x5f, 0xf9, 0xc1, 0xd2, 0xb1, 0xa4, 0x29, 0x15,
-	0xf0, 0x18, 0x9a, 0x1e, 0xf3, 0x63, 0xbe, 0x55, 0xb2, 0x26, 0x4b, 0x22, 0xb0, 0x0a, 0xb5, 0xac,
-	0x67, 0xda, 0x3f, 0x83, 0x3d, 0x95, 0x48, 0x6f, 0xbd, 0x97, 0xe7, 0xbb, 0x34, 0x64, 0xc6, 0x7e,
-	0x31, 0xe3, 0xa5, 0x91, 0xe6, 0xfc, 0xae, 0x09, 0xf5, 0x1f, 0x13, 0x1a, 0x6d, 0xf0, 0xe7, 0xd0,
-	0x57, 0x29, 0x86, 0x11, 0xf5, 0x39, 0xd5, 0x89, 0x2a, 0x1a, 0xc1, 0xdf, 0x43, 0x37, 0x2b, 0xf7,
-	0x9f, 0x28, 0xe2, 0x6c, 0x40, 0x05, 0x64, 0x8b, 0xe8, 0x40, 0x6d, 0xc0, 0xfb, 0x59, 0xb2, 0x58,
-	0xd5, 0xc4, 0xa7, 0xd0, 0xcb, 0x55, 0xaa, 0x80, 0x48, 0x45, 0xe3, 0x64, 0xc9, 0x63, 0xc7, 0x3c,
-	0xaa, 0x95, 0x52, 0xa5, 0x06, 0xfc, 0x57, 0x0d, 0xde, 0xbb, 0x61, 0x31, 0x3f, 0x7b, 0xe0, 0xc1,
-	0xaf, 0x01, 0xdf, 0xe8, 0x19, 0x0e, 0xa1, 0x21, 0x70, 0xcd, 0x30, 0x55, 0x12, 0x72, 0xa1, 0x75,
-	0xc9, 0x56, 0xf4, 0x3e, 0xa2, 0xbf, 0xc9, 0xfe, 0x5a, 0x5e, 0x26, 0x8b, 0x81, 0x46, 0x51, 0x10,
-	0xfe, 0xec, 0x34, 0x52, 0xc4, 0xa4, 0x20, 0x32, 0xfd, 0x40, 0xf9, 0x3b, 0xf6, 0x56, 0x21, 0xa0,
-	0x24, 0xf4, 0x25, 0x34, 0xce, 0x56, 0x2c, 0x09, 0xb9, 0x04, 0xd8, 0x1e, 0x1c, 0x10, 0xdd, 0x03,
-	0x91, 0x81, 0xa9, 0xd1, 0x53, 0x4e, 0x88, 0x80, 0x35, 0xf2, 0x39, 0x75, 0xea, 0xd2, 0xd9, 0x25,
-	0xe9, 0x93, 0x22, 0xfa, 0x49, 0x91, 0x3b, 0xfd, 0xa4, 0x3c, 0xe9, 0x27, 0x08, 0xa8, 0x86, 0x95,
-	0x58, 0xb4, 0x52, 0x02, 0x16, 0x54, 0xa2, 0xb1, 0xeb, 0x28, 0x58, 0x04, 0xa1, 0xd3, 0x4c, 0x1b,
-	0x4b, 0x25, 0x31, 0xc6, 0x94, 0x71, 0x1a, 0x3b, 0xed, 0x74, 0x0c, 0x29, 0xa0, 0x37, 0x39, 0x93,
-	0x40, 0xb6, 0xf0, 0x8a, 0x54, 0xec, 0x8d, 0x5c, 0x04, 0x4b, 0x9a, 0x91, 0xcc, 0xfd, 0x09, 0x2c,
-	0xa1, 0x10, 0x8c, 0x18, 0xf9, 0xdc, 0x97, 0xbb, 0xec, 0xc8, 0x06, 0x7d, 0xb1, 0x49, 0x61, 0x0b,
-	0x73, 0xa6, 0x64, 0xb2, 0x68, 0x7e, 0xc8, 0x42, 0x4e, 0x43, 0x7e, 0xb7, 0x59, 0x6b, 0xea, 0x16,
-	0x55, 0xf8, 0x13, 0xe8, 0x6f, 0x97, 0x57, 0x98, 0x97, 0x1e, 0x05, 0xfe, 0xc7, 0x82, 0x96, 0x76,
-	0x7a, 0xf4, 0x62, 0x9e, 0xbe, 0x18, 0x5b, 0x38, 0x77, 0x9e, 0xc2, 0xb9, 0x56, 0x8d, 0xb3, 0xf5,
-	0x04, 0xce, 0xf5, 0x97, 0xe0, 0x7c, 0x92, 0xf3, 0xbf, 0x51, 0xf6, 0x57, 0x86, 0x49, 0x38, 0x67,
-	0xd9, 0x63, 0x78, 0x1e, 0xc6, 0x56, 0x11, 0xc6, 0xed, 0xab, 0xd3, 0x7e, 0x74, 0x75, 0x34, 0xcd,
-	0xe0, 0x85, 0x34, 0xfb, 0x0a, 0x9a, 0x57, 0x6c, 0x21, 0x43, 0xec, 0x67, 0x43, 0xb4, 0xab, 0xfb,
-	0x3a, 0x23, 0xa7, 0x98, 0xe5, 0x25, 0x97, 0xcc, 0xfd, 0xc3, 0x04, 0xbb, 0xb0, 0x2f, 0xd4, 0x01,
-	0x73, 0x2a, 0x43, 0xea, 0x9e, 0x39, 0x45, 0xa7, 0x60, 0xcd, 0xc2, 0x80, 0xcb, 0x88, 0xbd, 0x01,
-	0xae, 0x5c, 0x31, 0x19, 0xfa, 0xf3, 0x39, 0x0d, 0x42, 0x2a, 0x3c, 0x3d, 0xe9, 0x8f, 0x4f, 0xa1,
-	0x53, 0xd4, 0xa2, 0x2e, 0xd8, 0xb3, 0xe9, 0xed, 0xcd, 0xf9, 0x70, 0x72, 0x31, 0x39, 0x1f, 0xf5,
-	0x0c, 0xd4, 0x86, 0xfa, 0xed, 0xe5, 0xf5, 0xdd, 0x6d, 0xcf, 0x44, 0x00, 0x8d, 0xeb, 0xd9, 0x74,
-	0x78, 0x7e, 0xdb, 0xdb, 0xc1, 0x1f, 0x43, 0xb7, 0x7c, 0x31, 0xca, 0xcc, 0x3b, 0x81, 0x03, 0xc1,
-	0x24, 0xe5, 0x16, 0xd0, 0xf8, 0x99, 0xd3, 0x82, 0x87, 0x70, 0x58, 0x0e, 0x50, 0xa4, 0xfe, 0x0c,
-	0x20, 0xd7, 0xaa, 0x5b, 0xd6, 0xce, 0x66, 0xf4, 0x0a, 0xc6, 0xc1, 0x3d, 0xec, 0x8a, 0x24, 0xa3,
-	0x20, 0xa2, 0x0f, 0x9c, 0x45, 0x1b, 0xf4, 0x29, 0x74, 0xcf, 0x12, 0xfe, 0x8e, 0x45, 0xc1, 0xef,
-	0x34, 0xfd, 0xaf, 0x90, 0x4d, 0xf2, 0x8f, 0xcb, 0x4d, 0x59, 0x8f, 0x0d, 0x74, 0x0c, 0xcd, 0x31,
-	0xe5, 0x92, 0xfc, 0x1d, 0x52, 0xf8, 0x8d, 0xdd, 0x5d, 0x52, 0xfc, 0x7c, 0xb1, 0x31, 0xf8, 0xd3,
-	0xcc, 0x8e, 0x6d, 0x5e, 0xe7, 0x35, 0xc0, 0x98, 0x72, 0x4d, 0xca, 0x2e, 0xd9, 0xfe, 0x5c, 0xdc,
-	0x1e, 0x29, 0x9d, 0x7f, 0x6c, 0xa0, 0x01, 0xec, 0xaa, 0x8f, 0x43, 0x45, 0x1d, 0x90, 0xaa, 0xff,
-	0xc4, 0xcd, 0xce, 0x36, 0x36, 0xd0, 0xd7, 0xd0, 0xb9, 0x0a, 0x62, 0x5d, 0x27, 0x46, 0x59, 0x5e,
-	0xbd, 0x5e, 0x77, 0x9f, 0x94, 0x3f, 0x02, 0x6c, 0x0c, 0xfe, 0x36, 0x61, 0x5f, 0xef, 0x2b, 0xef,
-	0xf9, 0x1b, 0xe8, 0x14, 0x8f, 0x08, 0xea, 0x57, 0x9d, 0x34, 0xf7, 0x80, 0x54, 0x5d, 0x1a, 0x6c,
-	0xa0, 0x2f, 0xc0, 0x1e, 0xd3, 0x3c, 0xba, 0x47, 0xca, 0x91, 0x39, 0x46, 0xd8, 0x40, 0x17, 0xb0,
-	0xaf, 0xf6, 0x9b, 0xc3, 0x85, 0x0e, 0x49, 0x25, 0x47, 0xdc, 0xf7, 0x49, 0x35, 0x15, 0xb0, 0x71,
-	0xdf, 0x90, 0x0f, 0xeb, 0xcd, 0xbf, 0x01, 0x00, 0x00, 0xff, 0xff, 0x05, 0x86, 0x48, 0x69, 0x66,
-	0x09, 0x00, 0x00,
+	// 2666 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x59, 0x4b, 0x6f, 0x23, 0xc7,
+	0xf1, 0xe7, 0x43, 0xe2, 0xa3, 0x28, 0x8a, 0x54, 0xf3, 0xb1, 0xdc, 0xf9, 0xfb, 0xb1, 0x68, 0xac,
+	0xff, 0x5e, 0xef, 0x66, 0x5b, 0x31, 0xbd, 0x5e, 0x07, 0x86, 0x1d, 0x2f, 0x57, 0xa4, 0x24, 0xda,
+	0x8c, 0xc4, 0x0c, 0x49, 0xc5, 0x08, 0x0c, 0x04, 0x5c, 0xb2, 0x25, 0x4f, 0x44, 0xcd, 0xd0, 0x33,
+	0xc3, 0xf5, 0xca, 0x86, 0x0f, 0x4e, 0x90, 0x4b, 0x72, 0xc8, 0x21, 0xc8, 0x31, 0x40, 0xbe, 0x43,
+	0x3e, 0x45, 0xce, 0xb9, 0xe5, 0x12, 0x20, 0xc8, 0x07, 0x09, 0xfa, 0x35, 0x2f, 0x0e, 0x1f, 0x70,
+	0x6e, 0x53, 0xd5, 0xd5, 0xd5, 0xd5, 0xd5, 0x55, 0xd5, 0x5d, 0xbf, 0x81, 0xd2, 0xc4, 0xba, 0xbc,
+	0xa4, 0x74, 0x66, 0x5d, 0x91, 0xb9, 0x6d, 0xb9, 0x96, 0xf6, 0xda, 0x95, 0x65, 0x5d, 0xcd, 0xe8,
+	0xe1, 0x78, 0x6e, 0x1c, 0x8e, 0x4d, 0xd3, 0x72, 0xc7, 0xae, 0x61, 0x99, 0x8e, 0x1c, 0x7d, 0x53,
+	0x8e, 0x72, 0xea, 0xc5, 0xe2, 0xf2, 0xd0, 0x35, 0x6e, 0xa8, 0xe3, 0x8e, 0x6f, 0xe6, 0x42, 0x00,
+	0xbf, 0x0e, 0x85, 0x91, 0x43, 0x6d, 0x9d, 0x7e, 0xb5, 0xa0, 0x8e, 0x8b, 0xf6, 0x21, 0xd5, 0x6d,
+	0x37, 0x92, 0xf7, 0x92, 0x0f, 0xf2, 0x7a, 0xaa, 0xdb, 0xc6, 0x8f, 0xa1, 0xc4, 0x86, 0xcd, 0xf1,
+	0x0d, 0x55, 0x22, 0x1a, 0xe4, 0x14, 0x4b, 0x0a, 0x7a, 0x34, 0xfe, 0x04, 0xf6, 0x84, 0x36, 0x67,
+	0x6e, 0x99, 0x0e, 0x45, 0x55, 0xd8, 0x3d, 0xb6, 0x16, 0xe6, 0x94, 0x0b, 0xe6, 0x74, 0x41, 0xa0,
+	0xbb, 0xb0, 0xc3, 0xa4, 0x1a, 0xa9, 0x7b, 0xc9, 0x07, 0x85, 0xe6, 0x2e, 0xe1, 0x53, 0x38, 0x0b,
+	0x7f, 0x9f, 0x12, 0x63, 0x51, 0x43, 0xd0, 0x3d, 0x28, 0xb4, 0x0d, 0x67, 0x3e, 0x1b, 0xdf, 0x9e,
+	0xb1, 0x85, 0x53, 0x7c, 0x20, 0xc8, 0x42, 0x0d, 0xc8, 0xf6, 0x8d, 0x89, 0xbb, 0xb0, 0x69, 0x23,
+	0xcd, 0x47, 0x15, 0x89, 0xca, 0x90, 0x7e, 0x6e, 0x58, 0x8d, 0x1d, 0xce, 0x65, 0x9f, 0xa1, 0x3d,
+	0xe4, 0xc3, 0x7b, 0x60, 0x36, 0x8f, 0x4c, 0xc3, 0x75, 0x1a, 0xbb, 0x7c, 0x40, 0x10, 0x6c, 0xc6,
+	0xd0, 0xb8, 0xa1, 0xbf, 0xb4, 0x4c, 0xda, 0xc8, 0x88, 0x19, 0x8a, 0x46, 0x8f, 0x00, 0x2e, 0x0c,
+	0xc7, 0x78, 0x61, 0xcc, 0x0c, 0xf7, 0xb6, 0x91, 0xbd, 0x97, 0x7c, 0xb0, 0xdf, 0x2c, 0x10, 0x9f,
+	0xa5, 0x07, 0x86, 0xd9, 0x46, 0x06, 0xb7, 0xe6, 0xa4, 0x6f, 0x5b, 0x97, 0xc6, 0x8c, 0x36, 0x72,
+	0xdc, 0x31, 0x41, 0x16, 0x3e, 0x81, 0xe2, 0xb1, 0x35, 0x9b, 0x59, 0x5f, 0x2b, 0x8f, 0xd7, 0x21,
+	0xc3, 0xac, 0xf3, 0xfc, 0x21, 0x29, 0xf4, 0x06, 0x80, 0x10, 0xe4, 0x63, 0xc2, 0x25, 0x01, 0x0e,
+	0x3e, 0x85, 0x7d, 0xa5, 0x48, 0x9e, 0xc7, 0x6b, 0x90, 0x17, 0x1c, 0xc3, 0xbc, 0x92, 0x67, 0xe2,
+	0x33, 0xb8, 0x07, 0xa9, 0x39, 0x65, 0x63, 0x29, 0x3e, 0xa6, 0x48, 0x4c, 0xa0, 0xda, 0x33, 0x1c,
+	0x57, 0xe9, 0x76, 0x36, 0x58, 0x86, 0x9f, 0x41, 0x2d, 0x22, 0x2f, 0x0d, 0x78, 0x5b, 0x19, 0x40,
+	0x6d, 0xa7, 0x91, 0xbc, 0x97, 0x7e, 0x50, 0x68, 0xe6, 0x89, 0xe2, 0xe8, 0xfe, 0x18, 0xfe, 0x0a,
+	0x72, 0x8a, 0xf0, 0xe2, 0x25, 0xb9, 0x14, 0x2f, 0xab, 0x4d, 0x46, 0x3f, 0x86, 0xdd, 0x81, 0x61,
+	0x4e, 0x44, 0x30, 0x14, 0x9a, 0x1a, 0x11, 0x99, 0x40, 0x54, 0x26, 0x90, 0xa1, 0xca, 0x04, 0x5d,
+	0x08, 0xe2, 0x63, 0x38, 0x18, 0xcd, 0xa7, 0x63, 0x97, 0x06, 0x13, 0x62, 0xcd, 0xda, 0x75, 0xc8,
+	0x1c, 0x1b, 0x74, 0x36, 0x75, 0x1a, 0xa9, 0x7b, 0x69, 0xb6, 0x79, 0x41, 0x61, 0x17, 0xe0, 0x84,
+	0xaf, 0xf5, 0x03, 0x03, 0xf9, 0x0d, 0x00, 0x19, 0xb9, 0x23, 0xbd, 0x27, 0x63, 0x39, 0xc0, 0x61,
+	0x01, 0xda, 0xb9, 0x19, 0x1b, 0x33, 0x19, 0xd0, 0x82, 0xc0, 0x7f, 0x4c, 0x42, 0xa9, 0xf3, 0xca,
+	0x65, 0x41, 0x3c, 0x93, 0x91, 0x14, 0x5d, 0x2b, 0xb9, 0x69, 0xad, 0xd4, 0xea, 0xb5, 0xd2, 0x81,
+	0xb5, 0xd0, 0x7d, 0x28, 0xf2, 0x8f, 0x0b, 0x6a, 0x1b, 0x97, 0x06, 0x9d, 0x72, 0x4b, 0x72, 0x7a,
+	0x98, 0x89, 0xff, 0x9a, 0x84, 0x46, 0x6b, 0xe1, 0x7e, 0x69, 0xd9, 0xc6, 0x37, 0x54, 0x99, 0x16,
+	0xa8, 0x22, 0x7d, 0xdb, 0x7a, 0x69, 0x4c, 0xa5, 0x6f, 0xf3, 0xba, 0x47, 0xb3, 0x43, 0x1d, 0x2c,
+	0x5e, 0xfc, 0x9a, 0x4e, 0x5c, 0x69, 0x91, 0x22, 0xd1, 0x43, 0xc8, 0xaa, 0xc4, 0x11, 0xc7, 0x5a,
+	0x26, 0x91, 0x3d, 0xeb, 0x4a, 0x00, 0x61, 0xd8, 0xeb, 0x19, 0xe6, 0xf5, 0xd0, 0x92, 0x11, 0x2a,
+	0xbc, 0x15, 0xe2, 0xe1, 0xdf, 0x27, 0x21, 0xd7, 0x9d, 0x52, 0xd3, 0x65, 0x99, 0xf9, 0xc3, 0x4c,
+	0x8a, 0xf7, 0x50, 0x13, 0x32, 0x6c, 0x21, 0xe9, 0x9a, 0xf5, 0xe1, 0x27, 0x25, 0xf1, 0xa1, 0x48,
+	0x1a, 0x69, 0x8f, 0x41, 0x37, 0x66, 0x19, 0x85, 0x7a, 0x74, 0x82, 0x4c, 0xb3, 0x77, 0x00, 0x7c,
+	0xae, 0x97, 0x67, 0x6a, 0xa7, 0x7a, 0x60, 0x90, 0xc5, 0xc8, 0xe9, 0xd8, 0xe9, 0x8f, 0x1d, 0xe7,
+	0x6b, 0xcb, 0x9e, 0xca, 0x2c, 0x0a, 0xb2, 0x30, 0x85, 0xda, 0xc8, 0x9c, 0x19, 0xe6, 0xb5, 0x37,
+	0x7f, 0x43, 0x5d, 0x0a, 0x3a, 0x32, 0xb5, 0xda, 0x91, 0xe9, 0x90, 0x23, 0x31, 0x81, 0x7a, 0x74,
+	0x99, 0x75, 0xb7, 0x08, 0xa6, 0x50, 0xd2, 0xe9, 0x95, 0xe1, 0xb8, 0x7e, 0xb2, 0x7a, 0x67, 0x91,
+	0x0c, 0x9e, 0x05, 0x33, 0x27, 0xb8, 0xbd, 0xbc, 0xee, 0xd1, 0xd1, 0x0c, 0x49, 0x2f, 0x65, 0x08,
+	0xbe, 0x0f, 0x7b, 0x5c, 0xcd, 0xda, 0x35, 0xf0, 0x23, 0xa8, 0xb4, 0x26, 0x13, 0x6b, 0x61, 0xba,
+	0x43, 0xeb, 0x9a, 0x9a, 0x01, 0x61, 0x4e, 0x2b, 0x61, 0x4e, 0xe0, 0x1f, 0x41, 0x35, 0x2c, 0xec,
+	0xef, 0x33, 0x46, 0xfa, 0x73, 0xa8, 0x2a, 0x73, 0x7b, 0xd6, 0x95, 0x61, 0xfe, 0xf0, 0xcd, 0xb2,
+	0x52, 0xd4, 0x97, 0x7b, 0x4c, 0x75, 0xfb, 0xf8, 0x6f, 0x49, 0xa8, 0x45, 0x54, 0x4b, 0x4b, 0xde,
+	0x87, 0xcc, 0xc0, 0x1d, 0xbb, 0x0b, 0x87, 0x2b, 0xdf, 0x6f, 0xbe, 0x4e, 0x62, 0xe5, 0x88, 0x4e,
+	0x9d, 0xc5, 0xcc, 0xd5, 0xa5, 0xf0, 0xba, 0x8b, 0xfd, 0x14, 0x32, 0x42, 0x18, 0x65, 0x20, 0x75,
+	0xfe, 0x59, 0x39, 0x81, 0xee, 0x40, 0xa5, 0x7b, 0x76, 0xd1, 0xea, 0x75, 0xdb, 0xbf, 0x3a, 0xd2,
+	0x3b, 0xed, 0xce, 0xd9, 0xb0, 0xdb, 0xea, 0x0d, 0xca, 0x49, 0x54, 0x84, 0xfc, 0xf0, 0x54, 0x3f,
+	0x1f, 0x0e, 0x7b, 0x9d, 0x76, 0x39, 0x85, 0xf6, 0x01, 0x46, 0x67, 0x17, 0x1d, 0xbd, 0x7b, 0xdc,
+	0xed, 0xb4, 0xcb, 0x69, 0x7c, 0x0a, 0x55, 0x9d, 0x3a, 0xd4, 0x55, 0x16, 0xad, 0xf5, 0xf5, 0x3a,
+	0x7f, 0xe0, 0xbf, 0x27, 0xe5, 0x94, 0xa5, 0x22, 0xed, 0x47, 0x76, 0x2a, 0x14, 0xd9, 0x08, 0x76,
+	0x02, 0x71, 0xc2, 0xbf, 0x99, 0x6c, 0xdf, 0xa6, 0x97, 0xc6, 0x2b, 0x59, 0x61, 0x24, 0x85, 0x9e,
+	0x40, 0xf6, 0xc8, 0xa6, 0x63, 0x97, 0x4e, 0x1b, 0xbb, 0x1b, 0x6b, 0x80, 0x12, 0x45, 0x4f, 0x21,
+	0xd7, 0x1b, 0x3b, 0xee, 0xc8, 0xa1, 0xd3, 0x46, 0x66, 0xe3, 0x34, 0x4f, 0x16, 0x3f, 0x03, 0x24,
+	0x54, 0x84, 0xe2, 0x6f, 0x55, 0x86, 0xaa, 0x7d, 0xa4, 0xfc, 0x7d, 0xe0, 0xcf, 0xa0, 0x12, 0xd2,
+	0xe0, 0x3d, 0x19, 0x02, 0x6e, 0x2d, 0x34, 0x33, 0x44, 0x0c, 0x4b, 0xc7, 0xd5, 0x21, 0x33, 0xa0,
+	0x13, 0x9b, 0xaa, 0xb2, 0x28, 0x29, 0xfc, 0x08, 0x0e, 0x58, 0x69, 0xe2, 0x42, 0x1b, 0xeb, 0xd8,
+	0x13, 0x40, 0x41, 0x61, 0xb9, 0xf0, 0x1b, 0x90, 0x11, 0x1c, 0x59, 0xbf, 0xd4, 0xca, 0x92, 0x8b,
+	0x3f, 0x02, 0xa4, 0xd3, 0x97, 0xd6, 0xf5, 0x76, 0x3b, 0x16, 0x27, 0x9c, 0xf2, 0x1e, 0xb6, 0x8f,
+	0xa0, 0x12, 0x9a, 0xbd, 0xb6, 0xd4, 0x34, 0xc5, 0x45, 0xc6, 0xca, 0xd2, 0x24, 0xc6, 0xc5, 0xd2,
+	0x03, 0xc9, 0x90, 0x07, 0x7e, 0x9b, 0x82, 0xec, 0x80, 0x3a, 0x8e, 0x61, 0x6d, 0x1f, 0x5e, 0xaf,
+	0x41, 0x9e, 0x7d, 0xb5, 0xae, 0xa8, 0xa9, 0xca, 0xa3, 0xcf, 0x90, 0xe9, 0xbb, 0xa3, 0xd2, 0xf7,
+	0x7f, 0x0b, 0xb0, 0x01, 0xa5, 0xe6, 0xb6, 0x01, 0xc6, 0x64, 0xd9, 0x6a, 0x9d, 0x57, 0x73, 0xc3,
+	0xa6, 0x4e, 0x23, 0xbb, 0x71, 0x9a, 0x12, 0xc5, 0x5f, 0x40, 0x55, 0x2c, 0x2c, 0x5d, 0xb1, 0xe9,
+	0x98, 0x42, 0x1e, 0x48, 0xc5, 0x7b, 0xc0, 0x2f, 0x60, 0x03, 0xa8, 0x45, 0xb4, 0xcb, 0x63, 0xc4,
+	0x9e, 0xef, 0x65, 0xd8, 0xe6, 0x88, 0x12, 0xf1, 0x0e, 0x65, 0x4d, 0xe8, 0x9e, 0x50, 0x77, 0xd9,
+	0xde, 0xd8, 0x53, 0xbe, 0x84, 0x52, 0x74, 0xed, 0xf8, 0x9e, 0x27, 0x60, 0x51, 0x6a, 0x95, 0x45,
+	0xaa, 0x7c, 0xa6, 0x97, 0xcb, 0xe7, 0x63, 0xa8, 0xb0, 0x14, 0x91, 0x92, 0x1b, 0x33, 0xea, 0x23,
+	0xa8, 0x86, 0xc5, 0xa5, 0x6d, 0xf7, 0x21, 0xa7, 0x78, 0x32, 0xab, 0x7c, 0x33, 0xbc, 0x11, 0xfc,
+	0x53, 0xa8, 0x8a, 0xdc, 0xd8, 0xf2, 0xd0, 0xa2, 0xb9, 0xd5, 0x84, 0x86, 0x98, 0xdf, 0x9a, 0xcd,
+	0xb6, 0xb5, 0xf8, 0x5d, 0xa8, 0x45, 0xd6, 0x94, 0x26, 0x37, 0x20, 0x2b, 0x06, 0x84, 0x43, 0x77,
+	0x75, 0x45, 0xb2, 0x83, 0x6a, 0xd3, 0x19, 0x0d, 0xbf, 0xd7, 0x57, 0xe9, 0xbf, 0x0f, 0xe8, 0x84,
+	0xba, 0x5c, 0x3e, 0xb0, 0xa3, 0x68, 0xbb, 0xfb, 0xcf, 0x24, 0xe4, 0x94, 0x4c, 0x74, 0x90, 0x15,
+	0xcd, 0x36, 0x6b, 0xff, 0xd2, 0xfc, 0x5c, 0xf9, 0x37, 0xe3, 0x0d, 0x5c, 0x3a, 0x97, 0x59, 0xc9,
+	0xbf, 0xd9, 0x95, 0xa3, 0x53, 0xd7, 0xbe, 0x65, 0x4d, 0x89, 0x68, 0xef, 0x3c, 0x1a, 0xfd, 0x04,
+	0xf2, 0x72, 0xed, 0xad, 0xea, 0xbb, 0x2f, 0xcc, 0xf2, 0x4f, 0x74, 0x27, 0xd3, 0x6d, 0xf2, 0x4f,
+	0x8a, 0x7e, 0xba, 0x93, 0x4b, 0x95, 0xd3, 0x9f, 0xee, 0xe4, 0x76, 0xcb, 0x19, 0x7c, 0x0e, 0x65,
+	0x7f, 0xff, 0x6b, 0x83, 0xf5, 0x2d, 0xdf, 0x0b, 0x32, 0x5a, 0xf3, 0xc4, 0x9b, 0xea, 0x0d, 0xe1,
+	0x13, 0xc8, 0xea, 0xd6, 0xd8, 0x71, 0x43, 0x5d, 0x4e, 0x5a, 0xf9, 0x2a, 0x7a, 0xc1, 0xac, 0x6e,
+	0xd0, 0x71, 0x0b, 0xf6, 0xa5, 0x22, 0x75, 0x30, 0x65, 0x5f, 0xdf, 0x69, 0x82, 0x6b, 0xac, 0x06,
+	0x35, 0x9e, 0x26, 0x84, 0xce, 0xe7, 0x59, 0xd8, 0xfd, 0xf9, 0x82, 0xda, 0xb7, 0xf8, 0x21, 0x54,
+	0xa5, 0x0a, 0x51, 0x11, 0x94, 0xa2, 0xf8, 0x9b, 0xae, 0xe4, 0x2d, 0xb7, 0x29, 0x69, 0xa5, 0xa0,
+	0x97, 0xb4, 0x6a, 0xa2, 0x1a, 0xc0, 0x63, 0x4f, 0x99, 0x13, 0xec, 0x6d, 0xc6, 0x57, 0x74, 0x60,
+	0x7c, 0x43, 0x65, 0xcc, 0x7a, 0x34, 0x2b, 0x70, 0xec, 0x5b, 0x5c, 0xa9, 0xb2, 0xc0, 0x79, 0x0c,
+	0x54, 0x95, 0xdb, 0x51, 0xcd, 0x84, 0xd8, 0xdb, 0x17, 0x50, 0xf6, 0x97, 0xf0, 0x2b, 0x9c, 0x78,
+	0x4f, 0xf9, 0x89, 0xec, 0x9b, 0x26, 0x06, 0x58, 0x9b, 0x76, 0x46, 0x5f, 0xb9, 0xd1, 0xf5, 0xc2,
+	0x4c, 0xfc, 0xef, 0x34, 0x54, 0xfa, 0x96, 0xe3, 0xb6, 0x26, 0xae, 0xf1, 0x72, 0xbb, 0xd7, 0xfd,
+	0xa9, 0x75, 0x43, 0x5f, 0xd8, 0xf4, 0x6b, 0xd9, 0x2d, 0x78, 0x34, 0xb3, 0xbf, 0x6d, 0x1b, 0xe6,
+	0xb5, 0x84, 0x48, 0x04, 0xc1, 0x34, 0xfd, 0x8c, 0xba, 0x5f, 0x5a, 0x53, 0xb9, 0x2d, 0x49, 0xa1,
+	0xc7, 0x90, 0x69, 0xdd, 0xb0, 0x67, 0xb0, 0x6c, 0x92, 0x6a, 0x44, 0xd9, 0x40, 0xf8, 0x44, 0x31,
+	0xa8, 0x4b, 0x21, 0x44, 0x60, 0xa7, 0x3d, 0x76, 0xe9, 0x16, 0x97, 0x1d, 0x97, 0x63, 0x6f, 0x7b,
+	0xe9, 0x12, 0x1e, 0x01, 0x39, 0xf1, 0xb6, 0x0f, 0xb0, 0x98, 0x61, 0xe7, 0xb6, 0x71, 0x65, 0x98,
+	0x3c, 0xa5, 0xf2, 0xba, 0xa4, 0xd8, 0x36, 0xce, 0x2c, 0x97, 0x3a, 0x12, 0x1b, 0x12, 0x04, 0x7a,
+	0xcf, 0x8f, 0x5f, 0xe0, 0x26, 0xdc, 0x25, 0x31, 0x7e, 0x23, 0xc7, 0xa2, 0x0b, 0x15, 0x92, 0x11,
+	0x6c, 0xa8, 0xb0, 0x16, 0x1b, 0xd2, 0x3e, 0x87, 0x1d, 0x36, 0x9b, 0x57, 0x9a, 0xb1, 0x3b, 0xe6,
+	0x8e, 0xdf, 0xe3, 0xbb, 0x19, 0x33, 0xb7, 0xb3, 0x31, 0xd3, 0x0f, 0x66, 0x8f, 0x66, 0x3b, 0x3d,
+	0xb2, 0x4c, 0x97, 0x9a, 0xee, 0xf0, 0x76, 0xee, 0x75, 0x31, 0x01, 0x16, 0x7e, 0x07, 0x6a, 0xa3,
+	0xf9, 0xcc, 0x1a, 0x4f, 0xa5, 0x5d, 0x5e, 0x1c, 0x95, 0x21, 0xcd, 0x3a, 0x7f, 0x71, 0xc4, 0xec,
+	0x13, 0xf7, 0xa0, 0xdc, 0x5f, 0xd8, 0x57, 0xdb, 0x54, 0x55, 0xb6, 0xb0, 0x0f, 0x16, 0x28, 0x1c,
+	0x24, 0xc8, 0xc2, 0x2d, 0x38, 0x08, 0x68, 0xf3, 0x6b, 0xba, 0xa8, 0xdc, 0x5e, 0x4d, 0x97, 0xa4,
+	0x57, 0x63, 0x53, 0x7e, 0x8d, 0xc5, 0xff, 0x0f, 0xd5, 0xb0, 0x9f, 0xa5, 0x96, 0x48, 0xcd, 0xc1,
+	0xdf, 0xef, 0x42, 0x4e, 0x09, 0x45, 0x07, 0xd7, 0xb4, 0x26, 0xa1, 0x80, 0xde, 0x5b, 0x15, 0xd0,
+	0xe9, 0xf8, 0x80, 0xde, 0x59, 0x11, 0xd0, 0xbb, 0xdb, 0x04, 0xf4, 0xa1, 0x5f, 0x5e, 0x32, 0x51,
+	0x79, 0x39, 0xd0, 0x35, 0x2f, 0x2d, 0xaf, 0xd6, 0x6c, 0x8e, 0xd7, 0x5c, 0x30, 0x5e, 0xc3, 0xd8,
+	0x4e, 0x7e, 0x09, 0xdb, 0x51, 0xf9, 0x04, 0x5b, 0xe6, 0xd3, 0x13, 0xc8, 0xf6, 0xac, 0x2b, 0x3e,
+	0xa5, 0xb0, 0xf9, 0x06, 0x92, 0xa2, 0x91, 0x04, 0x28, 0xae, 0x4f, 0x80, 0x77, 0xa1, 0x10, 0xd8,
+	0xf8, 0x36, 0xb7, 0x8a, 0xf6, 0x87, 0x24, 0x14, 0x02, 0xce, 0x45, 0x7b, 0x90, 0x3c, 0x93, 0x51,
+	0x95, 0x3c, 0x43, 0x4f, 0x61, 0x87, 0xe1, 0xb7, 0x7c, 0xc6, 0x7e, 0x13, 0xc7, 0x9e, 0x07, 0x39,
+	0x1a, 0x5f, 0x5e, 0x52, 0xc3, 0xa4, 0x4c, 0x52, 0xe7, 0xf2, 0xf8, 0x29, 0xec, 0x05, 0xb9, 0xa8,
+	0x04, 0x85, 0xd1, 0xd9, 0xa0, 0xdf, 0x39, 0x12, 0x5d, 0x68, 0x02, 0xe5, 0x61, 0x77, 0x70, 0x7a,
+	0x3e, 0x64, 0xfd, 0x2a, 0x40, 0xe6, 0x7c, 0x74, 0x76, 0xd4, 0x19, 0x94, 0x53, 0xf8, 0x63, 0x28,
+	0x45, 0xeb, 0x68, 0x74, 0x13, 0x1a, 0xe4, 0x2e, 0x0c, 0x1a, 0xc4, 0x6c, 0x3d, 0x1a, 0xff, 0x2e,
+	0x09, 0x35, 0xfe, 0xdc, 0x15, 0x3a, 0x36, 0x63, 0x40, 0xeb, 0xb4, 0x85, 0xee, 0xa1, 0xf4, 0xba,
+	0x7b, 0x68, 0x27, 0x72, 0x0f, 0x61, 0x03, 0xea, 0x51, 0x33, 0x7c, 0x64, 0xc9, 0xe7, 0x7a, 0xc8,
+	0x92, 0xb7, 0xe7, 0xc0, 0xe0, 0x76, 0xd7, 0xcf, 0xc3, 0x56, 0x30, 0x3e, 0x50, 0x01, 0xb2, 0xed,
+	0xce, 0x71, 0x6b, 0xd4, 0x1b, 0x96, 0x13, 0xcc, 0xb1, 0xfd, 0xd1, 0xf3, 0x5e, 0xf7, 0x48, 0x80,
+	0x02, 0xc7, 0xe7, 0xbd, 0xde, 0xf9, 0x2f, 0x3a, 0xfa, 0xa0, 0x9c, 0x62, 0x72, 0x7d, 0xbd, 0x7b,
+	0xd1, 0x1a, 0x76, 0xca, 0xe9, 0xe6, 0x9f, 0x8b, 0x50, 0x64, 0xe6, 0xb6, 0x0d, 0x9b, 0x4e, 0x5c,
+	0xcb, 0xbe, 0x45, 0x6f, 0x43, 0xc9, 0x43, 0x1e, 0x05, 0x16, 0x8b, 0x0a, 0xc4, 0x07, 0x65, 0x35,
+	0x91, 0xff, 0x38, 0x81, 0x3e, 0x80, 0x83, 0x25, 0x88, 0x12, 0xdd, 0x25, 0xab, 0x60, 0x4b, 0x7f,
+	0xe2, 0x11, 0xec, 0x87, 0xb1, 0x37, 0x54, 0x27, 0xb1, 0xe8, 0x9d, 0x76, 0x87, 0xc4, 0x83, 0x74,
+	0x42, 0x49, 0x18, 0xf2, 0x42, 0x75, 0x12, 0x0b, 0xb5, 0x69, 0x77, 0x48, 0x3c, 0x36, 0x86, 0x13,
+	0xe8, 0x01, 0x64, 0x4f, 0xa8, 0xcb, 0x2b, 0xd9, 0x1e, 0x09, 0x14, 0x6d, 0xad, 0x48, 0x82, 0x45,
+	0x17, 0x27, 0xd0, 0x53, 0xde, 0xd9, 0x30, 0xe6, 0xf3, 0x5b, 0xef, 0x77, 0x47, 0x99, 0x44, 0x7e,
+	0xf0, 0x2c, 0xcf, 0x7b, 0x07, 0xc0, 0x07, 0xc6, 0x11, 0x22, 0x4b, 0x28, 0xb9, 0xef, 0x96, 0x47,
+	0x90, 0x11, 0xb0, 0x3d, 0xda, 0x27, 0xa1, 0x9f, 0x18, 0x5a, 0x89, 0x84, 0xff, 0x45, 0xe0, 0x04,
+	0x7a, 0x0c, 0xb9, 0x91, 0x79, 0xb9, 0xb5, 0x38, 0x81, 0xfc, 0x09, 0x75, 0xb7, 0x57, 0xff, 0x04,
+	0x4a, 0xad, 0xf9, 0xdc, 0xb6, 0x5e, 0x52, 0xef, 0x4f, 0xc2, 0x16, 0xb3, 0x9e, 0x41, 0x31, 0xf4,
+	0xeb, 0x02, 0xd5, 0x48, 0xdc, 0xaf, 0x0f, 0xad, 0x4e, 0x62, 0xff, 0x70, 0xe0, 0x04, 0xfa, 0x10,
+	0x0a, 0x01, 0x20, 0x05, 0x55, 0xc8, 0x32, 0x30, 0xa3, 0x55, 0x49, 0x0c, 0xd6, 0xc2, 0xe3, 0x11,
+	0x7c, 0x28, 0x04, 0x21, 0xb2, 0x04, 0xa2, 0x68, 0x15, 0xb2, 0x8c, 0x95, 0x88, 0x45, 0x03, 0x78,
+	0x06, 0xaa, 0x90, 0x65, 0x6c, 0x44, 0xab, 0x92, 0x18, 0xc8, 0x03, 0x27, 0xd0, 0x27, 0x22, 0x09,
+	0x42, 0xf0, 0x86, 0x4c, 0x82, 0x38, 0xc8, 0x63, 0x39, 0x40, 0x9e, 0x41, 0x31, 0xd4, 0x87, 0xa3,
+	0x1a, 0x89, 0xeb, 0xfa, 0xb5, 0x3a, 0x89, 0x6d, 0xd7, 0xf9, 0x59, 0x81, 0xdf, 0x74, 0x23, 0x44,
+	0x96, 0x3a, 0x70, 0xad, 0x4c, 0x96, 0x67, 0x7d, 0x0c, 0x7b, 0xc1, 0x36, 0x17, 0x55, 0x49, 0x4c,
+	0x93, 0xac, 0xd5, 0x48, 0x5c, 0x2f, 0x2c, 0xcc, 0x0e, 0xf5, 0x9c, 0xa8, 0x46, 0xe2, 0xfa, 0x5e,
+	0xad, 0x4e, 0x62, 0x5b, 0x53, 0x9c, 0x40, 0xa7, 0x70, 0xb0, 0xd4, 0xe9, 0xa2, 0xbb, 0x64, 0x55,
+	0xf7, 0xbb, 0x46, 0xd3, 0xfb, 0x90, 0x53, 0x68, 0x36, 0x2a, 0x93, 0x08, 0xb0, 0xad, 0xd5, 0x48,
+	0x1c, 0x60, 0x8c, 0x13, 0xe8, 0x39, 0x34, 0xa4, 0x4c, 0xe0, 0xdf, 0xcb, 0x84, 0xff, 0x02, 0x46,
+	0x45, 0x12, 0x04, 0xae, 0x57, 0xeb, 0x78, 0x02, 0x05, 0x3e, 0xef, 0x96, 0x8b, 0xa3, 0x2a, 0x89,
+	0x41, 0xb2, 0x63, 0xcf, 0x3c, 0x84, 0x09, 0xa3, 0x1a, 0x89, 0x83, 0xa9, 0xb5, 0x7a, 0x3c, 0x74,
+	0x8c, 0x13, 0x88, 0xc3, 0x0c, 0x5c, 0xc8, 0x87, 0x72, 0x1d, 0xea, 0x6e, 0x6d, 0xf7, 0x07, 0x50,
+	0x0c, 0x01, 0xc1, 0xfc, 0xf8, 0x96, 0x81, 0xe1, 0x65, 0xd3, 0x1f, 0x03, 0xf8, 0xc0, 0x01, 0x42,
+	0x64, 0x09, 0x45, 0xd0, 0xfc, 0x76, 0x97, 0xaf, 0x53, 0x08, 0x40, 0x07, 0xa8, 0x42, 0x96, 0x81,
+	0x04, 0xed, 0x80, 0x44, 0x5b, 0x6b, 0x9c, 0x68, 0xfe, 0x2b, 0xe9, 0x35, 0x6e, 0xfe, 0xd5, 0xd4,
+	0xe3, 0x91, 0x2e, 0xd9, 0xa8, 0x44, 0xc2, 0x8d, 0xaf, 0x56, 0x26, 0x91, 0xd6, 0x14, 0xff, 0xdf,
+	0x6f, 0xfe, 0xf1, 0x9f, 0x3f, 0xa5, 0x6a, 0xa8, 0x72, 0xf8, 0xf2, 0xdd, 0x43, 0x5b, 0x0c, 0x3a,
+	0x1f, 0xce, 0x2c, 0xeb, 0x7a, 0x31, 0x47, 0x4d, 0x95, 0x79, 0x4a, 0x61, 0x8d, 0xc4, 0xb5, 0xc1,
+	0x9a, 0xd7, 0x1d, 0xe2, 0x04, 0xea, 0x8a, 0xac, 0x91, 0x0c, 0x07, 0x79, 0x4b, 0x3a, 0xfe, 0x6e,
+	0xa2, 0xfd, 0x26, 0xae, 0x72, 0x2b, 0xf6, 0xd1, 0x5e, 0xd0, 0x8a, 0xe6, 0x5f, 0xd2, 0x70, 0xa0,
+	0xee, 0x7e, 0x7f, 0x8b, 0x17, 0xb0, 0x17, 0x7c, 0xb0, 0xa3, 0x6a, 0x5c, 0x9f, 0xa4, 0xd5, 0x48,
+	0xdc, 0xab, 0x1e, 0xdf, 0xe5, 0x0b, 0x55, 0x3e, 0x4c, 0x3e, 0xc4, 0xfb, 0x6c, 0xad, 0xb1, 0xff,
+	0xa0, 0x38, 0xe1, 0x07, 0xe1, 0xa9, 0x2d, 0x93, 0xa8, 0x4a, 0xff, 0x21, 0x12, 0xf6, 0x9a, 0xaf,
+	0xe3, 0xf0, 0xdb, 0x6e, 0xfb, 0x3b, 0x74, 0xed, 0x5d, 0x84, 0x81, 0xe7, 0x4a, 0x9d, 0xc4, 0xbe,
+	0xbc, 0xb4, 0x3b, 0x4b, 0x7c, 0x69, 0xe9, 0x5b, 0x7c, 0x89, 0x37, 0xd1, 0xeb, 0x6c, 0x89, 0x85,
+	0x43, 0x6d, 0xe7, 0xf0, 0x5b, 0xf1, 0x2a, 0xfb, 0x2e, 0x68, 0x75, 0x1b, 0x8a, 0xa1, 0xd6, 0x0b,
+	0xad, 0x6e, 0x1b, 0xb5, 0x3a, 0x89, 0xed, 0xd2, 0x78, 0x92, 0xe6, 0xbd, 0x3e, 0x0a, 0x1d, 0x90,
+	0x68, 0x87, 0xa6, 0x21, 0xb2, 0xd4, 0x66, 0xe1, 0xc4, 0x8b, 0x0c, 0x7f, 0x99, 0xbf, 0xf7, 0xdf,
+	0x01, 0x00, 0xc6, 0x8c, 0x58, 0xf4, 0x36, 0x22, 0x00, 0x00,
 }
@@ -0,0 +1,211 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package health reports whether the services can serve requests, over the
+// standard gRPC health checking protocol.
+//
+// The dependencies of a service, such as Datastore or the other services, are
+// checked in the background and reported under their names. The service as a
+// whole, under "" and the names of its gRPC services, is serving when all of
+// its dependencies are and it is not shutting down.
+package health
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.com/ahmetb/coffeelog/health/grpc_health_v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// checkInterval is how often the dependencies are checked.
+	checkInterval = 10 * time.Second
+
+	// checkTimeout is how long a dependency has to respond.
+	checkTimeout = 5 * time.Second
+)
+
+// errNotChecked is the status of the dependencies before their first check.
+var errNotChecked = errors.New("not checked yet")
+
+// Check returns an error if the dependency cannot be used.
+type Check func(ctx context.Context) error
+
+// Server keeps the status of the dependencies of the service.
+type Server struct {
+	log      *logrus.Entry
+	draining func() bool
+	services map[string]bool
+	checks   map[string]Check
+
+	mu   sync.RWMutex
+	errs map[string]error
+}
+
+// NewServer returns a server for the gRPC services, which are not serving
+// while draining returns true.
+func NewServer(log *logrus.Entry, draining func() bool, services ...string) *Server {
+	s := &Server{
+		log:      log.WithField("facility", "health"),
+		draining: draining,
+		services: make(map[string]bool),
+		checks:   make(map[string]Check),
+		errs:     make(map[string]error)}
+	for _, v := range services {
+		s.services[v] = true
+	}
+	return s
+}
+
+// AddCheck adds the check of a dependency, which must be done before Start.
+func (s *Server) AddCheck(name string, c Check) {
+	s.checks[name] = c
+	s.errs[name] = errNotChecked
+}
+
+// Start runs the checks until ctx is cancelled.
+func (s *Server) Start(ctx context.Context) {
+	go func() {
+		t := time.NewTicker(checkInterval)
+		defer t.Stop()
+		for {
+			s.runChecks(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+func (s *Server) runChecks(ctx context.Context) {
+	var wg sync.WaitGroup
+	for name, c := range s.checks {
+		wg.Add(1)
+		go func(name string, c Check) {
+			defer wg.Done()
+			cctx, cancel := context.WithTimeout(ctx, checkTimeout)
+			err := c(cctx)
+			cancel()
+
+			s.mu.Lock()
+			prev := s.errs[name]
+			s.errs[name] = err
+			s.mu.Unlock()
+			if err != nil && (prev == nil || prev == errNotChecked) {
+				s.log.WithFields(logrus.Fields{
+					"dependency": name,
+					"error":      err}).Warn("dependency is unhealthy")
+			} else if err == nil && prev != nil {
+				s.log.WithField("dependency", name).Info("dependency is healthy")
+			}
+		}(name, c)
+	}
+	wg.Wait()
+}
+
+// Dependency is the status of a dependency.
+type Dependency struct {
+	Name string
+	Err  error
+}
+
+// Dependencies returns the status of the dependencies, sorted by name.
+func (s *Server) Dependencies() []Dependency {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v := make([]Dependency, 0, len(s.errs))
+	for name, err := range s.errs {
+		v = append(v, Dependency{name, err})
+	}
+	sort.Slice(v, func(i, j int) bool { return v[i].Name < v[j].Name })
+	return v
+}
+
+// Serving reports whether the service can serve requests.
+func (s *Server) Serving() bool {
+	if s.draining() {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, err := range s.errs {
+		if err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Check returns the status of the service, or of one of its dependencies.
+func (s *Server) Check(ctx context.Context, req *pb.HealthCheckRequest) (*pb.HealthCheckResponse, error) {
+	name := req.GetService()
+	var ok bool
+	if name == "" || s.services[name] {
+		ok = s.Serving()
+	} else if _, found := s.checks[name]; found {
+		s.mu.RLock()
+		ok = s.errs[name] == nil
+		s.mu.RUnlock()
+	} else {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", name)
+	}
+	if !ok {
+		return &pb.HealthCheckResponse{Status: pb.HealthCheckResponse_NOT_SERVING}, nil
+	}
+	return &pb.HealthCheckResponse{Status: pb.HealthCheckResponse_SERVING}, nil
+}
+
+// PeerCheck checks that the service at the other end of cc responds to
+// health checks. Whether it is serving is not considered, so that services
+// depending on each other do not wait for each other to become ready.
+func PeerCheck(cc *grpc.ClientConn) Check {
+	cl := pb.NewHealthClient(cc)
+	return func(ctx context.Context) error {
+		_, err := cl.Check(ctx, &pb.HealthCheckRequest{})
+		return err
+	}
+}
+
+// Probe asks the service listening on addr whether it is serving, for the
+// probes of Kubernetes. An addr without a host is on localhost.
+func Probe(addr string, opt grpc.DialOption) error {
+	if host, port, err := net.SplitHostPort(addr); err == nil && host == "" {
+		addr = net.JoinHostPort("localhost", port)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+	cc, err := grpc.DialContext(ctx, addr, opt, grpc.WithBlock())
+	if err != nil {
+		return errors.Wrap(err, "failed to connect")
+	}
+	defer cc.Close()
+	resp, err := pb.NewHealthClient(cc).Check(ctx, &pb.HealthCheckRequest{})
+	if err != nil {
+		return errors.Wrap(err, "health check failed")
+	}
+	if resp.GetStatus() != pb.HealthCheckResponse_SERVING {
+		return errors.Errorf("service is %s", resp.GetStatus())
+	}
+	return nil
+}
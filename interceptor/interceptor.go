@@ -0,0 +1,59 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package interceptor combines gRPC interceptors, as clients and servers only
+// take one of each.
+package interceptor
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// ChainUnaryServer returns an interceptor running the interceptors in order,
+// the first one being the outermost.
+func ChainUnaryServer(is ...grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		h := handler
+		for i := len(is) - 1; i >= 0; i-- {
+			h = bindServer(is[i], info, h)
+		}
+		return h(ctx, req)
+	}
+}
+
+func bindServer(i grpc.UnaryServerInterceptor, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) grpc.UnaryHandler {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		return i(ctx, req, info, next)
+	}
+}
+
+// ChainUnaryClient returns an interceptor running the interceptors in order,
+// the first one being the outermost.
+func ChainUnaryClient(is ...grpc.UnaryClientInterceptor) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		inv := invoker
+		for i := len(is) - 1; i >= 0; i-- {
+			inv = bindClient(is[i], inv)
+		}
+		return inv(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func bindClient(i grpc.UnaryClientInterceptor, next grpc.UnaryInvoker) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return i(ctx, method, req, reply, cc, next, opts...)
+	}
+}
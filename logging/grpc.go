@@ -0,0 +1,54 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"context"
+	"time"
+
+	"github.com/ahmetb/coffeelog/caller"
+	"github.com/ahmetb/coffeelog/trace"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// UnaryServerInterceptor puts the logger of the request in the context of
+// the handler and logs the RPCs. It has to run after the tracing and caller
+// interceptors, to find the trace and the user in the context.
+func UnaryServerInterceptor(log *logrus.Entry) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		span := trace.FromContext(ctx)
+		fields := logrus.Fields{
+			MethodField:  info.FullMethod,
+			TraceIDField: span.TraceID(),
+			SpanIDField:  span.SpanID(),
+		}
+		if userID := caller.FromContext(ctx); userID != "" {
+			fields[UserIDField] = userID
+		}
+		ctx = NewContext(ctx, log.WithFields(fields))
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		e := FromContext(ctx).WithFields(logrus.Fields{
+			"code":    grpc.Code(err).String(),
+			"elapsed": time.Since(start).String(),
+		})
+		if err != nil {
+			e = e.WithField("error", err)
+		}
+		e.Debug("rpc completed")
+		return resp, err
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ahmetb/coffeelog/trace"
+	"github.com/sirupsen/logrus"
+)
+
+// HTTPHandler puts the logger of the request in the context of the handler
+// and logs the requests. It has to run inside the tracing handler, to find
+// the trace in the context.
+func HTTPHandler(log *logrus.Entry, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := trace.FromContext(r.Context())
+		e := log.WithFields(logrus.Fields{
+			"method":     r.Method,
+			"path":       r.URL.Path,
+			TraceIDField: span.TraceID(),
+			SpanIDField:  span.SpanID(),
+		})
+		e.Debug("request accepted")
+		start := time.Now()
+		ctx := NewContext(r.Context(), e)
+		defer func() {
+			FromContext(ctx).WithField("elapsed", time.Since(start).String()).Debug("request completed")
+		}()
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,107 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logging configures the logs of the services and carries the logger
+// of a request in its context.
+//
+// The logger of a request has the fields identifying it: the trace and span
+// ids, the user making it and the RPC method. The trace id is passed between
+// the services by the tracing, so that a request can be followed from web to
+// the backends by filtering the logs of all services by trace.id.
+package logging
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/ahmetb/coffeelog/version"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// The fields of the logger of a request.
+const (
+	TraceIDField = "trace.id"
+	SpanIDField  = "span.id"
+	UserIDField  = "user.id"
+	MethodField  = "rpc.method"
+)
+
+// base is the logger of the service, used for the contexts without a logger.
+var base = logrus.NewEntry(logrus.StandardLogger())
+
+// New configures the standard logger with the level and format, "json" or
+// "text", and returns the logger of the service.
+func New(service, level, format string) (*logrus.Entry, error) {
+	lvl, err := logrus.ParseLevel(level)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid log level")
+	}
+	switch format {
+	case "json":
+		logrus.SetFormatter(&logrus.JSONFormatter{FieldMap: logrus.FieldMap{logrus.FieldKeyLevel: "severity"}})
+	case "text":
+		logrus.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	default:
+		return nil, errors.Errorf("unknown log format %q", format)
+	}
+	logrus.SetLevel(lvl)
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get hostname")
+	}
+	base = logrus.WithFields(logrus.Fields{
+		"service": service,
+		"host":    host,
+		"v":       version.Version(),
+	})
+	return base, nil
+}
+
+// holder holds the logger of a request, so that fields known only after the
+// context is created, such as the user, can be added with AddField.
+type holder struct {
+	mu  sync.Mutex
+	log *logrus.Entry
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the logger.
+func NewContext(ctx context.Context, log *logrus.Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, &holder{log: log})
+}
+
+// FromContext returns the logger in the context, or the logger of the
+// service if there is none.
+func FromContext(ctx context.Context) *logrus.Entry {
+	h, ok := ctx.Value(contextKey{}).(*holder)
+	if !ok {
+		return base
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.log
+}
+
+// AddField adds a field to the logger in the context, for the loggers taken
+// from the context afterwards.
+func AddField(ctx context.Context, key string, value interface{}) {
+	if h, ok := ctx.Value(contextKey{}).(*holder); ok {
+		h.mu.Lock()
+		h.log = h.log.WithField(key, value)
+		h.mu.Unlock()
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+var (
+	grpcServerHandled = NewCounter("grpc_server_handled_total",
+		"Number of RPCs completed by the server, by method and status code.",
+		"grpc_service", "grpc_method", "grpc_code")
+	grpcServerHandling = NewHistogram("grpc_server_handling_seconds",
+		"Latency of the RPCs completed by the server.",
+		DurationBuckets, "grpc_service", "grpc_method")
+	grpcClientHandled = NewCounter("grpc_client_handled_total",
+		"Number of RPCs completed by the clients, by method and status code.",
+		"grpc_service", "grpc_method", "grpc_code")
+	grpcClientHandling = NewHistogram("grpc_client_handling_seconds",
+		"Latency of the RPCs completed by the clients.",
+		DurationBuckets, "grpc_service", "grpc_method")
+)
+
+// splitMethod splits a full method name, such as "/UserDirectory/GetUser",
+// into the service and the method.
+func splitMethod(fullMethod string) (string, string) {
+	v := strings.SplitN(strings.TrimPrefix(fullMethod, "/"), "/", 2)
+	if len(v) != 2 {
+		return "unknown", "unknown"
+	}
+	return v[0], v[1]
+}
+
+// UnaryServerInterceptor counts the RPCs handled by the server and measures
+// their latency.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		svc, method := splitMethod(info.FullMethod)
+		grpcServerHandled.Inc(svc, method, grpc.Code(err).String())
+		grpcServerHandling.Observe(time.Since(start).Seconds(), svc, method)
+		return resp, err
+	}
+}
+
+// UnaryClientInterceptor counts the RPCs made by the client and measures
+// their latency.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, fullMethod string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, fullMethod, req, reply, cc, opts...)
+		svc, method := splitMethod(fullMethod)
+		grpcClientHandled.Inc(svc, method, grpc.Code(err).String())
+		grpcClientHandling.Observe(time.Since(start).Seconds(), svc, method)
+		return err
+	}
+}
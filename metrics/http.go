@@ -0,0 +1,77 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	httpRequests = NewCounter("http_requests_total",
+		"Number of HTTP requests completed, by route, method and status code.",
+		"route", "method", "code")
+	httpDuration = NewHistogram("http_request_duration_seconds",
+		"Latency of the HTTP requests.",
+		DurationBuckets, "route", "method")
+)
+
+// InstrumentHTTP counts the requests served by h and measures their latency,
+// by the route the request matches. A nil route puts all requests under "".
+func InstrumentHTTP(h http.Handler, route func(*http.Request) string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rt string
+		if route != nil {
+			rt = route(r)
+		}
+		sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+		start := time.Now()
+		h.ServeHTTP(sw, r)
+		method := httpMethod(r.Method)
+		httpRequests.Inc(rt, method, strconv.Itoa(sw.code))
+		httpDuration.Observe(time.Since(start).Seconds(), rt, method)
+	})
+}
+
+// httpMethod returns the method of the request, or "other" for the
+// non-standard ones, which clients could make up without limit.
+func httpMethod(m string) string {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return m
+	}
+	return "other"
+}
+
+// statusWriter records the status code of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	code  int
+	wrote bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wrote {
+		w.code, w.wrote = code, true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wrote = true
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,242 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics collects counters and histograms of the services and
+// exposes them to Prometheus in its text format on /metrics.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DurationBuckets are the upper bounds of the buckets of the latency
+// histograms, in seconds.
+var DurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+type metric interface {
+	name() string
+	write(w io.Writer)
+}
+
+func register(m metric) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	for _, v := range registry.metrics {
+		if v.name() == m.name() {
+			panic(fmt.Sprintf("metrics: %s registered twice", m.name()))
+		}
+	}
+	registry.metrics = append(registry.metrics, m)
+}
+
+// desc describes a metric and its labels.
+type desc struct {
+	n, help string
+	labels  []string
+}
+
+func (d desc) name() string { return d.n }
+
+func (d desc) writeHeader(w io.Writer, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.n, helpEscaper.Replace(d.help), d.n, typ)
+}
+
+// key returns the key of the series with the label values.
+func (d desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", d.n, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// series formats the name and the labels of a series, with the extra label
+// appended if it is not empty.
+func (d desc) series(suffix string, values []string, extra ...string) string {
+	pairs := make([]string, 0, len(values)+1)
+	for i, v := range values {
+		pairs = append(pairs, d.labels[i]+`="`+labelEscaper.Replace(v)+`"`)
+	}
+	if len(extra) == 2 {
+		pairs = append(pairs, extra[0]+`="`+extra[1]+`"`)
+	}
+	if len(pairs) == 0 {
+		return d.n + suffix
+	}
+	return d.n + suffix + "{" + strings.Join(pairs, ",") + "}"
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Counter is a metric that only goes up, with a series for each combination
+// of label values.
+type Counter struct {
+	desc
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labels []string
+	v      float64
+}
+
+// NewCounter registers a counter with the labels.
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{desc: desc{name, help, labels}, values: make(map[string]*counterValue)}
+	register(c)
+	return c
+}
+
+// Inc adds one to the series with the label values.
+func (c *Counter) Inc(labelValues ...string) { c.Add(1, labelValues...) }
+
+// Add adds v to the series with the label values.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	k := c.key(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cv, ok := c.values[k]
+	if !ok {
+		cv = &counterValue{labels: append([]string(nil), labelValues...)}
+		c.values[k] = cv
+	}
+	cv.v += v
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.writeHeader(w, "counter")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]string, 0, len(c.values))
+	for k := range c.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		cv := c.values[k]
+		fmt.Fprintf(w, "%s %s\n", c.series("", cv.labels), formatFloat(cv.v))
+	}
+}
+
+// Histogram is a metric that counts the observed values in buckets, with a
+// series for each combination of label values.
+type Histogram struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labels []string
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram registers a histogram with the bucket upper bounds, in
+// increasing order, and the labels.
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{desc: desc{name, help, labels}, buckets: buckets, values: make(map[string]*histogramValue)}
+	register(h)
+	return h
+}
+
+// Observe adds v to the series with the label values.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	k := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hv, ok := h.values[k]
+	if !ok {
+		hv = &histogramValue{
+			labels: append([]string(nil), labelValues...),
+			counts: make([]uint64, len(h.buckets))}
+		h.values[k] = hv
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		hv.counts[i]++
+	}
+	hv.count++
+	hv.sum += v
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.writeHeader(w, "histogram")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	keys := make([]string, 0, len(h.values))
+	for k := range h.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		hv := h.values[k]
+		var n uint64
+		for i, b := range h.buckets {
+			n += hv.counts[i]
+			fmt.Fprintf(w, "%s %d\n", h.series("_bucket", hv.labels, "le", formatFloat(b)), n)
+		}
+		fmt.Fprintf(w, "%s %d\n", h.series("_bucket", hv.labels, "le", "+Inf"), hv.count)
+		fmt.Fprintf(w, "%s %s\n", h.series("_sum", hv.labels), formatFloat(hv.sum))
+		fmt.Fprintf(w, "%s %d\n", h.series("_count", hv.labels), hv.count)
+	}
+}
+
+// Handler writes all metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		registry.mu.Lock()
+		v := append([]metric(nil), registry.metrics...)
+		registry.mu.Unlock()
+		sort.Slice(v, func(i, j int) bool { return v[i].name() < v[j].name() })
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		bw := bufio.NewWriter(w)
+		for _, m := range v {
+			m.write(bw)
+		}
+		bw.Flush()
+	})
+}
+
+// ListenAndServe serves the metrics on /metrics at addr.
+func ListenAndServe(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	return http.ListenAndServe(addr, mux)
+}
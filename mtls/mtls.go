@@ -0,0 +1,247 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mtls secures the gRPC connections between the services with mutual
+// TLS. Every service has a certificate signed by a shared CA, with the name of
+// the service as the common name, which the servers check the callers of each
+// method against.
+//
+// The certificate, key and CA files are reloaded when they change, so that
+// they can be rotated without restarting the services. Without any of them,
+// the connections are in plaintext, which is meant for local development.
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// reloadInterval is how often the files are checked for changes.
+const reloadInterval = 10 * time.Second
+
+// Config holds the paths of the PEM files of the certificate of the service,
+// its private key, and the CA certificates the peers are verified with.
+type Config struct {
+	CertFile, KeyFile, CAFile string
+}
+
+func (c Config) files() []string { return []string{c.CertFile, c.KeyFile, c.CAFile} }
+
+// Reloader holds the current certificate and CAs of the service.
+type Reloader struct {
+	cfg Config
+	log *logrus.Entry
+
+	mu       sync.RWMutex
+	cert     tls.Certificate
+	pool     *x509.CertPool
+	modTimes []time.Time
+}
+
+// Load reads the files and starts watching them for changes. It returns nil
+// if none of the files are given, for which the connections are plaintext.
+func Load(cfg Config, log *logrus.Entry) (*Reloader, error) {
+	if cfg.CertFile == "" && cfg.KeyFile == "" && cfg.CAFile == "" {
+		return nil, nil
+	} else if cfg.CertFile == "" || cfg.KeyFile == "" || cfg.CAFile == "" {
+		return nil, errors.New("tls certificate, key and ca files must be given together")
+	}
+	r := &Reloader{cfg: cfg, log: log.WithField("facility", "mtls")}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	go r.watch()
+	return r, nil
+}
+
+func (r *Reloader) load() error {
+	modTimes, err := modTimes(r.cfg.files())
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to load tls certificate")
+	}
+	b, err := ioutil.ReadFile(r.cfg.CAFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to read tls ca file")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return errors.Errorf("no certificates found in %s", r.cfg.CAFile)
+	}
+
+	r.mu.Lock()
+	r.cert, r.pool, r.modTimes = cert, pool, modTimes
+	r.mu.Unlock()
+	return nil
+}
+
+func modTimes(files []string) ([]time.Time, error) {
+	v := make([]time.Time, len(files))
+	for i, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to stat tls file")
+		}
+		v[i] = fi.ModTime()
+	}
+	return v, nil
+}
+
+// watch reloads the files when they change. The current files are kept if
+// the new ones fail to load, as they may be in the middle of being replaced.
+func (r *Reloader) watch() {
+	for range time.Tick(reloadInterval) {
+		v, err := modTimes(r.cfg.files())
+		if err != nil {
+			r.log.WithField("error", err).Warn("failed to check tls files for changes")
+			continue
+		}
+		r.mu.RLock()
+		changed := false
+		for i := range v {
+			changed = changed || !v[i].Equal(r.modTimes[i])
+		}
+		r.mu.RUnlock()
+		if !changed {
+			continue
+		}
+		if err := r.load(); err != nil {
+			r.log.WithField("error", err).Error("failed to reload tls files, keeping the current ones")
+			continue
+		}
+		r.log.Info("reloaded tls files")
+	}
+}
+
+func (r *Reloader) config() *tls.Config {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return &tls.Config{
+		Certificates: []tls.Certificate{r.cert},
+		RootCAs:      r.pool,
+		ClientCAs:    r.pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12}
+}
+
+// DialOption returns the option to dial the other services with, which is
+// plaintext for a nil Reloader.
+func (r *Reloader) DialOption() grpc.DialOption {
+	if r == nil {
+		return grpc.WithInsecure()
+	}
+	return grpc.WithTransportCredentials(&reloadingCreds{r: r})
+}
+
+// ServerOptions returns the options of the gRPC server, which are none for a
+// nil Reloader.
+func (r *Reloader) ServerOptions() []grpc.ServerOption {
+	if r == nil {
+		return nil
+	}
+	return []grpc.ServerOption{grpc.Creds(&reloadingCreds{r: r})}
+}
+
+// reloadingCreds does the TLS handshakes with the current files of the
+// Reloader.
+type reloadingCreds struct {
+	r          *Reloader
+	serverName string
+}
+
+func (c *reloadingCreds) current() credentials.TransportCredentials {
+	cfg := c.r.config()
+	cfg.ServerName = c.serverName
+	return credentials.NewTLS(cfg)
+}
+
+func (c *reloadingCreds) ClientHandshake(ctx context.Context, addr string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return c.current().ClientHandshake(ctx, addr, conn)
+}
+
+func (c *reloadingCreds) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return c.current().ServerHandshake(conn)
+}
+
+func (c *reloadingCreds) Info() credentials.ProtocolInfo { return c.current().Info() }
+
+func (c *reloadingCreds) Clone() credentials.TransportCredentials {
+	return &reloadingCreds{r: c.r, serverName: c.serverName}
+}
+
+func (c *reloadingCreds) OverrideServerName(name string) error {
+	c.serverName = name
+	return nil
+}
+
+// PeerName returns the common name of the certificate of the calling
+// service, or "" if the connection is not over TLS.
+func PeerName(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return info.State.VerifiedChains[0][0].Subject.CommonName
+}
+
+// Policy lists the services that may call each method, by full method name.
+type Policy map[string][]string
+
+// Allow lets the peers call the methods of the service.
+func (p Policy) Allow(service string, peers []string, methods ...string) Policy {
+	for _, m := range methods {
+		p["/"+service+"/"+m] = append(p["/"+service+"/"+m], peers...)
+	}
+	return p
+}
+
+// UnaryServerInterceptor rejects the calls by services the policy does not
+// allow to call the method, including any method not in the policy. Calls
+// over plaintext connections are not checked, as the server only accepts
+// those when TLS is not configured.
+func (p Policy) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if pr, ok := peer.FromContext(ctx); !ok || pr.AuthInfo == nil {
+			return handler(ctx, req)
+		}
+		name := PeerName(ctx)
+		for _, v := range p[info.FullMethod] {
+			if v == name {
+				return handler(ctx, req)
+			}
+		}
+		return nil, status.Errorf(codes.PermissionDenied, "%q may not call %s", name, info.FullMethod)
+	}
+}
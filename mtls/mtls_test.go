@@ -0,0 +1,81 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// peerContext returns the context of a call from the service, or over
+// plaintext if name is empty.
+func peerContext(name string) context.Context {
+	ctx := context.Background()
+	if name == "" {
+		return ctx
+	}
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: name}}
+	return peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{
+		State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}}})
+}
+
+func TestPolicy(t *testing.T) {
+	p := Policy{}.
+		Allow("Directory", []string{"web", "gateway"}, "Get", "List").
+		Allow("Directory", []string{"web"}, "Create").
+		Allow("Directory", []string{"worker"}, "Get")
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		want   codes.Code
+	}{
+		{name: "allowed", ctx: peerContext("web"), method: "/Directory/Create", want: codes.OK},
+		{name: "allowed by one of the rules", ctx: peerContext("worker"), method: "/Directory/Get", want: codes.OK},
+		{name: "other peer", ctx: peerContext("gateway"), method: "/Directory/Create", want: codes.PermissionDenied},
+		{name: "unknown peer", ctx: peerContext("intruder"), method: "/Directory/Get", want: codes.PermissionDenied},
+		{name: "method not in policy", ctx: peerContext("web"), method: "/Directory/Delete", want: codes.PermissionDenied},
+		{name: "other service", ctx: peerContext("web"), method: "/Other/Get", want: codes.PermissionDenied},
+		{name: "tls without a verified certificate",
+			ctx:    peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{}}),
+			method: "/Directory/Get", want: codes.PermissionDenied},
+		{name: "plaintext", ctx: peerContext(""), method: "/Directory/Delete", want: codes.OK},
+	}
+	intercept := p.UnaryServerInterceptor()
+	for _, tt := range tests {
+		_, err := intercept(tt.ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method},
+			func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil })
+		if got := grpc.Code(err); got != tt.want {
+			t.Errorf("%s: got %v, expected %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPeerName(t *testing.T) {
+	if got := PeerName(peerContext("web")); got != "web" {
+		t.Errorf("got %q, expected %q", got, "web")
+	}
+	if got := PeerName(peerContext("")); got != "" {
+		t.Errorf("plaintext: got %q, expected no name", got)
+	}
+}
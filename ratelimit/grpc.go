@@ -0,0 +1,52 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ahmetb/coffeelog/caller"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor limits the calls to the methods in limits, keyed by
+// their full name such as "/RoasterDirectory/ListRoasters", per user the
+// call is made on behalf of. Calls over the limit fail with
+// codes.ResourceExhausted. It has to run after the caller interceptor, to
+// tell the users apart.
+//
+// Anonymous calls are not limited: the peers are the other services, which
+// make them for all of their anonymous clients, so those have to be limited
+// per client at the edge.
+func UnaryServerInterceptor(store Store, limits map[string]Limit) grpc.UnaryServerInterceptor {
+	limiters := make(map[string]*Limiter, len(limits))
+	for m := range limits {
+		limiters[m] = New(m[strings.LastIndex(m, "/")+1:], store)
+	}
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		l, ok := limiters[info.FullMethod]
+		user := caller.FromContext(ctx)
+		if !ok || user == "" {
+			return handler(ctx, req)
+		}
+		if ok, retry := l.Allow(ctx, "user", user, limits[info.FullMethod]); !ok {
+			return nil, status.Errorf(codes.ResourceExhausted, "too many requests, try again in %ds", RetryAfter(retry))
+		}
+		return handler(ctx, req)
+	}
+}
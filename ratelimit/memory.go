@@ -0,0 +1,86 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// DefaultMaxKeys is the number of buckets the services keep in a MemoryStore.
+const DefaultMaxKeys = 100000
+
+// MemoryStore keeps the buckets in memory, for at most maxKeys keys.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	maxKeys int
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time // when tokens was last updated
+	full   time.Time // when the bucket will be full again
+}
+
+// NewMemoryStore returns an empty store that keeps up to maxKeys buckets.
+func NewMemoryStore(maxKeys int) *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), maxKeys: maxKeys}
+}
+
+// Take implements Store.
+func (m *MemoryStore) Take(_ context.Context, key string, l Limit) (bool, time.Duration, error) {
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.buckets[key]
+	if !ok {
+		if len(m.buckets) >= m.maxKeys {
+			m.evict(now)
+		}
+		b = &bucket{tokens: float64(l.Burst), last: now}
+		m.buckets[key] = b
+	} else {
+		b.tokens = math.Min(float64(l.Burst), b.tokens+now.Sub(b.last).Seconds()*l.Rate)
+		b.last = now
+	}
+
+	ok = b.tokens >= 1
+	if ok {
+		b.tokens--
+	}
+	b.full = now.Add(time.Duration((float64(l.Burst) - b.tokens) / l.Rate * float64(time.Second)))
+	if ok {
+		return true, 0, nil
+	}
+	return false, time.Duration((1 - b.tokens) / l.Rate * float64(time.Second)), nil
+}
+
+// evict drops the buckets that have refilled, which are no different from
+// new ones. If all are still in use, it drops them all rather than grow
+// without bound, resetting the limits.
+func (m *MemoryStore) evict(now time.Time) {
+	for k, b := range m.buckets {
+		if !b.full.After(now) {
+			delete(m.buckets, k)
+		}
+	}
+	if len(m.buckets) >= m.maxKeys {
+		m.buckets = make(map[string]*bucket)
+	}
+}
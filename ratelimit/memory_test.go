@@ -0,0 +1,82 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	l := Limit{Rate: 1, Burst: 3} // 3 at once, then one per second
+	tests := []struct {
+		name    string
+		elapsed time.Duration // moves the clock of the bucket back before taking
+		want    bool
+		retry   time.Duration
+	}{
+		{name: "first of the burst", want: true},
+		{name: "second of the burst", want: true},
+		{name: "last of the burst", want: true},
+		{name: "bucket empty", want: false, retry: time.Second},
+		{name: "refilled one", elapsed: time.Second, want: true},
+		{name: "empty again", want: false, retry: time.Second},
+		{name: "refilled up to the burst", elapsed: time.Hour, want: true},
+		{name: "second after refill", want: true},
+		{name: "third after refill", want: true},
+		{name: "fourth after refill", want: false, retry: time.Second},
+	}
+	m := NewMemoryStore(10)
+	for _, tt := range tests {
+		if b, ok := m.buckets["k"]; ok {
+			b.last = b.last.Add(-tt.elapsed)
+		}
+		ok, retry, err := m.Take(context.Background(), "k", l)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		} else if ok != tt.want {
+			t.Errorf("%s: got %v, expected %v", tt.name, ok, tt.want)
+		} else if d := retry - tt.retry; d < -10*time.Millisecond || d > 10*time.Millisecond {
+			t.Errorf("%s: got retry after %v, expected %v", tt.name, retry, tt.retry)
+		}
+	}
+
+	// the buckets of other keys are not affected
+	if ok, _, _ := m.Take(context.Background(), "other", l); !ok {
+		t.Error("other key: expected a full bucket")
+	}
+}
+
+func TestMemoryStoreEvict(t *testing.T) {
+	l := Limit{Rate: 1, Burst: 1}
+	m := NewMemoryStore(2)
+	ctx := context.Background()
+	m.Take(ctx, "a", l)
+	m.Take(ctx, "b", l)
+	m.buckets["a"].full = time.Now().Add(-time.Second) // refilled
+	m.Take(ctx, "c", l)
+	if _, ok := m.buckets["a"]; ok {
+		t.Error("expected the refilled bucket to be evicted")
+	} else if _, ok := m.buckets["b"]; !ok {
+		t.Error("expected the bucket in use to be kept")
+	}
+
+	// all buckets are in use: they are dropped rather than grow
+	m.Take(ctx, "d", l)
+	if len(m.buckets) != 1 {
+		t.Errorf("got %d buckets, expected 1", len(m.buckets))
+	}
+}
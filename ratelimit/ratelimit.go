@@ -0,0 +1,120 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit limits how often users and clients can make expensive
+// requests, with token buckets: a bucket holds up to Burst tokens, refills
+// at Rate tokens per second, and every request takes a token from it.
+//
+// The buckets are kept in a Store. MemoryStore keeps them in the process,
+// so each replica limits on its own; a Store shared by the replicas can take
+// its place to enforce the limits across them.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/ahmetb/coffeelog/metrics"
+	"github.com/pkg/errors"
+)
+
+var rateLimitRequests = metrics.NewCounter("ratelimit_requests_total",
+	"Number of requests checked against the rate limits, by limiter, key kind and result.",
+	"limiter", "by", "result")
+
+// Limit is the rate of a token bucket. The zero Limit does not limit.
+type Limit struct {
+	Rate  float64 // tokens per second
+	Burst int     // size of the bucket
+}
+
+// Unlimited reports whether the limit lets all requests through.
+func (l Limit) Unlimited() bool { return l.Rate <= 0 || l.Burst <= 0 }
+
+var periods = map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+
+// ParseLimit parses a limit of the form "N/s", "N/m" or "N/h", which lets N
+// requests through at once and N per second, minute or hour after. An empty
+// string or "0" does not limit.
+func ParseLimit(s string) (Limit, error) {
+	if s == "" || s == "0" {
+		return Limit{}, nil
+	}
+	v := strings.SplitN(s, "/", 2)
+	if len(v) != 2 {
+		return Limit{}, errors.Errorf("invalid rate limit %q, want N/s, N/m or N/h", s)
+	}
+	n, err := strconv.Atoi(v[0])
+	if err != nil || n < 0 {
+		return Limit{}, errors.Errorf("invalid number of requests in rate limit %q", s)
+	}
+	p, ok := periods[v[1]]
+	if !ok {
+		return Limit{}, errors.Errorf("invalid period in rate limit %q, want s, m or h", s)
+	}
+	return Limit{Rate: float64(n) / p.Seconds(), Burst: n}, nil
+}
+
+// Store keeps the token buckets.
+type Store interface {
+	// Take takes a token from the bucket of the key, which has the limit l,
+	// and reports whether there was one and, if not, how long until there
+	// will be.
+	Take(ctx context.Context, key string, l Limit) (bool, time.Duration, error)
+}
+
+// Limiter limits a group of requests with the buckets in a store.
+type Limiter struct {
+	name  string
+	store Store
+}
+
+// New returns a limiter with buckets in the store, named for the metrics
+// and to keep its buckets apart from those of the other limiters.
+func New(name string, store Store) *Limiter {
+	return &Limiter{name: name, store: store}
+}
+
+// Allow takes a token from the bucket of the key, such as a user ID or IP
+// address, of which by is the kind. It returns whether the request may go
+// ahead and, if not, how long until it may be retried. Requests are let
+// through if the store fails, so that it cannot take the service down.
+func (l *Limiter) Allow(ctx context.Context, by, key string, lim Limit) (bool, time.Duration) {
+	if lim.Unlimited() {
+		return true, 0
+	}
+	ok, retry, err := l.store.Take(ctx, l.name+"/"+by+"/"+key, lim)
+	switch {
+	case err != nil:
+		logging.FromContext(ctx).WithField("error", err).WithField("limiter", l.name).Warn("failed to check rate limit")
+		rateLimitRequests.Inc(l.name, by, "error")
+		return true, 0
+	case !ok:
+		logging.FromContext(ctx).WithField("limiter", l.name).WithField("by", by).Info("rate limit exceeded")
+		rateLimitRequests.Inc(l.name, by, "limited")
+		return false, retry
+	}
+	rateLimitRequests.Inc(l.name, by, "allowed")
+	return true, 0
+}
+
+// RetryAfter rounds the time to wait before retrying up to whole seconds,
+// for the Retry-After header and the messages.
+func RetryAfter(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,93 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{in: "", want: Limit{}},
+		{in: "0", want: Limit{}},
+		{in: "10/s", want: Limit{Rate: 10, Burst: 10}},
+		{in: "30/m", want: Limit{Rate: 0.5, Burst: 30}},
+		{in: "36/h", want: Limit{Rate: 0.01, Burst: 36}},
+		{in: "0/m", want: Limit{Rate: 0, Burst: 0}},
+		{in: "10", wantErr: true},
+		{in: "10/d", wantErr: true},
+		{in: "-1/m", wantErr: true},
+		{in: "ten/m", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: got error %v, expected error: %v", tt.in, err, tt.wantErr)
+		} else if got != tt.want {
+			t.Errorf("%q: got %+v, expected %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, Limit) (bool, time.Duration, error) {
+	return false, 0, errors.New("store is down")
+}
+
+func TestLimiterAllow(t *testing.T) {
+	tests := []struct {
+		name  string
+		store Store
+		lim   Limit
+		want  []bool
+	}{
+		{name: "limited", store: NewMemoryStore(10), lim: Limit{Rate: 1, Burst: 2},
+			want: []bool{true, true, false}},
+		{name: "unlimited", store: NewMemoryStore(10), lim: Limit{},
+			want: []bool{true, true, true}},
+		{name: "store failing", store: failingStore{}, lim: Limit{Rate: 1, Burst: 1},
+			want: []bool{true, true}},
+	}
+	for _, tt := range tests {
+		l := New("test", tt.store)
+		for i, want := range tt.want {
+			if got, _ := l.Allow(context.Background(), "user", "42", tt.lim); got != want {
+				t.Errorf("%s: request %d: got %v, expected %v", tt.name, i+1, got, want)
+			}
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	for d, want := range map[time.Duration]int{
+		0:                       0,
+		time.Millisecond:        1,
+		time.Second:             1,
+		1500 * time.Millisecond: 2,
+	} {
+		if got := RetryAfter(d); got != want {
+			t.Errorf("%v: got %d, expected %d", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rpcerror gives the errors of the services gRPC status codes, so
+// that the callers can tell a missing or invalid request from a failure, and
+// keeps the details of the failures out of the responses.
+//
+// The handlers return status errors, such as
+// status.Error(codes.NotFound, "user not found"), for the errors the callers
+// can act on. UnaryServerInterceptor logs any other error and replaces it
+// with a status without the details, and HTTPStatus maps the codes to the
+// HTTP statuses of the web frontend.
+package rpcerror
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ahmetb/coffeelog/logging"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Code returns the status code of err, which may be wrapped with
+// errors.Wrap. Errors without a status are codes.Unknown.
+func Code(err error) codes.Code {
+	switch err = errors.Cause(err); err {
+	case context.DeadlineExceeded:
+		return codes.DeadlineExceeded
+	case context.Canceled:
+		return codes.Canceled
+	}
+	return grpc.Code(err)
+}
+
+// Public returns the message of the status of err if its code is one of the
+// codes caused by the request rather than a failure, whose messages are
+// written for the callers.
+func Public(err error) (string, bool) {
+	s, ok := status.FromError(errors.Cause(err))
+	if !ok || err == nil {
+		return "", false
+	}
+	switch s.Code() {
+	case codes.InvalidArgument, codes.NotFound, codes.AlreadyExists,
+		codes.Unauthenticated, codes.PermissionDenied, codes.FailedPrecondition,
+		codes.OutOfRange, codes.ResourceExhausted:
+		return s.Message(), true
+	}
+	return "", false
+}
+
+// UnaryServerInterceptor passes on the errors of the handlers with a public
+// status, and logs and replaces the others with a status with only their
+// code, codes.Internal if they had none. It has to run after the logging
+// interceptor, to log with the logger of the request.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		code := Code(err)
+		if msg, ok := Public(err); ok {
+			return nil, status.Error(code, msg)
+		}
+		if code == codes.Unknown {
+			code = codes.Internal
+		}
+		logging.FromContext(ctx).WithField("error", err).Error("rpc failed")
+		return nil, status.Error(code, messages[code])
+	}
+}
+
+// messages are the messages of the statuses that are not public.
+var messages = map[codes.Code]string{
+	codes.Canceled:         "request canceled",
+	codes.DeadlineExceeded: "deadline exceeded",
+	codes.Unimplemented:    "not implemented",
+	codes.Unavailable:      "service unavailable",
+	codes.Internal:         "internal error",
+	codes.Aborted:          "internal error",
+	codes.DataLoss:         "internal error",
+}
+
+// HTTPStatus returns the HTTP status code for a status code.
+func HTTPStatus(c codes.Code) int {
+	switch c {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
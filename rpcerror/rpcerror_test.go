@@ -0,0 +1,83 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpcerror
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode codes.Code
+		wantMsg  string
+	}{
+		{name: "no error",
+			wantCode: codes.OK},
+		{name: "public status",
+			err:      status.Error(codes.NotFound, "user not found"),
+			wantCode: codes.NotFound, wantMsg: "user not found"},
+		{name: "wrapped public status",
+			err:      errors.Wrap(status.Error(codes.InvalidArgument, "drink is required"), "failed to post"),
+			wantCode: codes.InvalidArgument, wantMsg: "drink is required"},
+		{name: "plain error",
+			err:      errors.New("datastore: connection refused to 10.0.0.1"),
+			wantCode: codes.Internal, wantMsg: "internal error"},
+		{name: "status that is not public",
+			err:      status.Error(codes.Unavailable, "dial tcp 10.0.0.1:8001: connection refused"),
+			wantCode: codes.Unavailable, wantMsg: "service unavailable"},
+		{name: "deadline",
+			err:      errors.Wrap(context.DeadlineExceeded, "failed to query"),
+			wantCode: codes.DeadlineExceeded, wantMsg: "deadline exceeded"},
+	}
+	intercept := UnaryServerInterceptor()
+	for _, tt := range tests {
+		_, err := intercept(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/Test/Call"},
+			func(ctx context.Context, req interface{}) (interface{}, error) { return nil, tt.err })
+		if got := grpc.Code(err); got != tt.wantCode {
+			t.Errorf("%s: got code %v, expected %v", tt.name, got, tt.wantCode)
+		} else if got := grpc.ErrorDesc(err); err != nil && got != tt.wantMsg {
+			t.Errorf("%s: got message %q, expected %q", tt.name, got, tt.wantMsg)
+		}
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	for c, want := range map[codes.Code]int{
+		codes.OK:                http.StatusOK,
+		codes.InvalidArgument:   http.StatusBadRequest,
+		codes.Unauthenticated:   http.StatusUnauthorized,
+		codes.PermissionDenied:  http.StatusForbidden,
+		codes.NotFound:          http.StatusNotFound,
+		codes.AlreadyExists:     http.StatusConflict,
+		codes.ResourceExhausted: http.StatusTooManyRequests,
+		codes.Unavailable:       http.StatusServiceUnavailable,
+		codes.DeadlineExceeded:  http.StatusGatewayTimeout,
+		codes.Internal:          http.StatusInternalServerError,
+		codes.Unknown:           http.StatusInternalServerError,
+	} {
+		if got := HTTPStatus(c); got != want {
+			t.Errorf("%v: got %d, expected %d", c, got, want)
+		}
+	}
+}
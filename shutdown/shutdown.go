@@ -0,0 +1,124 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package shutdown stops the services gracefully when they are asked to
+// terminate, letting the requests in flight finish before exiting.
+//
+// On SIGTERM or SIGINT, the service is first marked as draining, which fails
+// its readiness check so that the load balancers stop sending it new
+// requests. After a delay for that to take effect, the servers stop accepting
+// connections and wait for the requests in flight, up to a deadline after
+// which the remaining ones are cut off. A second signal exits right away.
+package shutdown
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// traceFlushTimeout is how long to wait for the spans of the last requests
+// to be exported.
+const traceFlushTimeout = 5 * time.Second
+
+// Watcher waits for the signal to terminate the service.
+type Watcher struct {
+	log            *logrus.Entry
+	delay, timeout time.Duration
+	sig            chan os.Signal
+	draining       int32
+}
+
+// Watch starts listening for the signals to terminate. The servers are
+// stopped delay after the signal, and given timeout to finish the requests in
+// flight.
+func Watch(log *logrus.Entry, delay, timeout time.Duration) *Watcher {
+	w := &Watcher{
+		log:     log.WithField("facility", "shutdown"),
+		delay:   delay,
+		timeout: timeout,
+		sig:     make(chan os.Signal, 2)}
+	signal.Notify(w.sig, syscall.SIGTERM, os.Interrupt)
+	return w
+}
+
+// Draining reports whether the service is shutting down, in which case it is
+// not ready to serve new requests. The servers stopping with an error is then
+// expected.
+func (w *Watcher) Draining() bool { return atomic.LoadInt32(&w.draining) == 1 }
+
+// Wait blocks until the service is asked to terminate, marks it as draining
+// and waits for the delay. The returned context expires when the servers
+// must have stopped.
+func (w *Watcher) Wait() (context.Context, context.CancelFunc) {
+	s := <-w.sig
+	atomic.StoreInt32(&w.draining, 1)
+	w.log.WithFields(logrus.Fields{
+		"signal":  s.String(),
+		"delay":   w.delay.String(),
+		"timeout": w.timeout.String()}).Info("shutting down")
+	go func() {
+		s := <-w.sig
+		w.log.WithField("signal", s.String()).Warn("exiting without finishing the requests in flight")
+		os.Exit(1)
+	}()
+	time.Sleep(w.delay)
+	return context.WithTimeout(context.Background(), w.timeout)
+}
+
+// StopGRPC stops the server after the requests in flight have finished, or
+// when ctx expires.
+func (w *Watcher) StopGRPC(ctx context.Context, srv *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		w.log.Info("stopped grpc server")
+	case <-ctx.Done():
+		w.log.Warn("timed out waiting for grpc requests to finish, stopping")
+		srv.Stop()
+	}
+}
+
+// StopHTTP stops the server after the requests in flight have finished, or
+// when ctx expires.
+func (w *Watcher) StopHTTP(ctx context.Context, srv *http.Server) {
+	if err := srv.Shutdown(ctx); err != nil {
+		w.log.WithField("error", err).Warn("timed out waiting for http requests to finish, stopping")
+		srv.Close()
+		return
+	}
+	w.log.WithField("addr", srv.Addr).Info("stopped http server")
+}
+
+// FlushTraces waits for the spans of the last requests to be exported with
+// flush, such as the Shutdown method of the trace client.
+func (w *Watcher) FlushTraces(flush func(context.Context) error) {
+	w.log.Debug("waiting for traces to be exported")
+	ctx, cancel := context.WithTimeout(context.Background(), traceFlushTimeout)
+	defer cancel()
+	if err := flush(ctx); err != nil {
+		w.log.WithField("error", err).Warn("failed to export traces")
+	}
+}
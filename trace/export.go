@@ -0,0 +1,218 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// queueSize is the number of finished spans waiting to be exported,
+	// beyond which more spans are dropped.
+	queueSize = 2048
+
+	// batchSize is the most spans exported at once.
+	batchSize = 512
+
+	// batchDelay is the longest a finished span waits to be exported.
+	batchDelay = 2 * time.Second
+)
+
+type exporter interface {
+	export(spans []*Span) error
+}
+
+func (c *Client) enqueue(s *Span) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed || c.exporter == nil {
+		return
+	}
+	select {
+	case c.spans <- s:
+	default:
+		c.log.Warn("trace export queue is full, dropping span")
+	}
+}
+
+// run exports the finished spans in batches, until the queue is closed.
+func (c *Client) run() {
+	defer close(c.done)
+	t := time.NewTicker(batchDelay)
+	defer t.Stop()
+	var batch []*Span
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := c.exporter.export(batch); err != nil {
+			c.log.WithField("error", err).Warnf("failed to export %d spans", len(batch))
+		}
+		batch = nil
+	}
+	for {
+		select {
+		case s, ok := <-c.spans:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, s)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-t.C:
+			flush()
+		}
+	}
+}
+
+// The OTLP/JSON encoding of the spans, see
+// https://github.com/open-telemetry/opentelemetry-proto
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              int             `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            *otlpStatus     `json:"status,omitempty"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue string `json:"stringValue"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+)
+
+// otlpStatusError is the status of the spans with an "error" label.
+const otlpStatusError = 2
+
+func encodeOTLP(service string, spans []*Span) otlpRequest {
+	v := make([]otlpSpan, len(spans))
+	for i, s := range spans {
+		s.mu.Lock()
+		v[i] = otlpSpan{
+			TraceID:           s.TraceID(),
+			SpanID:            s.SpanID(),
+			Name:              s.name,
+			Kind:              s.kind,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10)}
+		if s.parentID != ([8]byte{}) {
+			v[i].ParentSpanID = hex.EncodeToString(s.parentID[:])
+		}
+		for k, l := range s.labels {
+			v[i].Attributes = append(v[i].Attributes, otlpAttribute{k, otlpValue{l}})
+		}
+		if msg, ok := s.labels["error"]; ok {
+			v[i].Status = &otlpStatus{Code: otlpStatusError, Message: msg}
+		}
+		s.mu.Unlock()
+	}
+	return otlpRequest{[]otlpResourceSpans{{
+		Resource: otlpResource{[]otlpAttribute{{"service.name", otlpValue{service}}}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{"github.com/ahmetb/coffeelog/trace"},
+			Spans: v}}}}}
+}
+
+// otlpExporter sends the spans to an OTLP/HTTP receiver, such as the
+// OpenTelemetry Collector, in JSON.
+type otlpExporter struct {
+	service string
+	url     string
+	client  *http.Client
+}
+
+func newOTLPExporter(service, endpoint string) *otlpExporter {
+	return &otlpExporter{
+		service: service,
+		url:     strings.TrimSuffix(endpoint, "/") + "/v1/traces",
+		client:  &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (e *otlpExporter) export(spans []*Span) error {
+	b, err := json.Marshal(encodeOTLP(e.service, spans))
+	if err != nil {
+		return errors.Wrap(err, "failed to encode spans")
+	}
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return errors.Wrap(err, "failed to send spans")
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("otlp receiver responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// stdoutExporter writes the spans to the standard output, a line of OTLP/JSON
+// for each batch.
+type stdoutExporter struct {
+	service string
+	mu      sync.Mutex
+	enc     *json.Encoder
+}
+
+func newStdoutExporter(service string) *stdoutExporter {
+	return &stdoutExporter{service: service, enc: json.NewEncoder(os.Stdout)}
+}
+
+func (e *stdoutExporter) export(spans []*Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return errors.Wrap(e.enc.Encode(encodeOTLP(e.service, spans)), "failed to write spans")
+}
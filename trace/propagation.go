@@ -0,0 +1,120 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// traceparentHeader is the W3C trace context header, which is also the key of
+// the gRPC metadata.
+const traceparentHeader = "traceparent"
+
+// parseTraceparent returns the trace id, parent span id and sampled flag in
+// a traceparent header, and whether it is valid.
+func parseTraceparent(h string) (traceID [16]byte, spanID [8]byte, sampled bool, ok bool) {
+	// version-traceid-spanid-flags, with more fields after future versions
+	if len(h) < 55 || h[2] != '-' || h[35] != '-' || h[52] != '-' {
+		return
+	}
+	version := h[:2]
+	if version == "ff" || (version == "00" && len(h) != 55) || (len(h) > 55 && h[55] != '-') {
+		return
+	}
+	if strings.ToLower(h) != h {
+		return
+	}
+	var flags [1]byte
+	if _, err := hex.Decode(traceID[:], []byte(h[3:35])); err != nil {
+		return
+	}
+	if _, err := hex.Decode(spanID[:], []byte(h[36:52])); err != nil {
+		return
+	}
+	if _, err := hex.Decode(flags[:], []byte(h[53:55])); err != nil {
+		return
+	}
+	if traceID == ([16]byte{}) || spanID == ([8]byte{}) {
+		return
+	}
+	return traceID, spanID, flags[0]&1 == 1, true
+}
+
+// HTTPHandler traces the requests to h, continuing the trace of the caller
+// if the request has a traceparent header. The handlers can take the span
+// from the context of the request with FromContext.
+func (c *Client) HTTPHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := c.newRemoteChild(r.URL.Path, r.Header.Get(traceparentHeader), kindServer)
+		span.SetLabel("http/method", r.Method)
+		span.SetLabel("http/url", r.URL.String())
+		defer span.Finish()
+		h.ServeHTTP(w, r.WithContext(NewContext(r.Context(), span)))
+	})
+}
+
+// GRPCClientInterceptor traces the RPCs as children of the span in their
+// context, or of the traceparent already in their outgoing metadata (as
+// grpc-gateway passes on from the HTTP request), or as new traces, and passes
+// the trace on to the server.
+func (c *Client) GRPCClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		md, ok := metadata.FromOutgoingContext(ctx)
+		if ok {
+			md = md.Copy()
+		} else {
+			md = metadata.MD{}
+		}
+		span := FromContext(ctx).NewChild(method)
+		if span == nil && len(md[traceparentHeader]) > 0 {
+			span = c.newRemoteChild(method, md[traceparentHeader][0], kindClient)
+		} else if span == nil {
+			span = c.NewSpan(method)
+		}
+		span.kind = kindClient
+		defer span.Finish()
+
+		md[traceparentHeader] = []string{span.Traceparent()}
+		err := invoker(metadata.NewOutgoingContext(ctx, md), method, req, reply, cc, opts...)
+		if err != nil {
+			span.SetLabel("error", err.Error())
+		}
+		return err
+	}
+}
+
+// GRPCServerInterceptor traces the RPCs, continuing the trace of the client.
+// The handlers can take the span from their context with FromContext.
+func (c *Client) GRPCServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var traceparent string
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md[traceparentHeader]) > 0 {
+			traceparent = md[traceparentHeader][0]
+		}
+		span := c.newRemoteChild(info.FullMethod, traceparent, kindServer)
+		defer span.Finish()
+		resp, err := handler(NewContext(ctx, span), req)
+		if err != nil {
+			span.SetLabel("error", err.Error())
+		}
+		return resp, err
+	}
+}
@@ -0,0 +1,318 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package trace traces the requests across the services, following the
+// OpenTelemetry specification: the trace context is propagated over HTTP and
+// gRPC with the W3C traceparent header, and the spans are exported with OTLP.
+//
+// The OpenTelemetry SDK for Go requires a newer Go than the services are
+// built with, so the package implements the parts of it the services use,
+// behind the API they used with the Stackdriver Trace client: spans are taken
+// from the context with FromContext and started with NewChild.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mrand "math/rand"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Span kinds, as numbered by OTLP.
+const (
+	kindInternal = 1
+	kindServer   = 2
+	kindClient   = 3
+)
+
+// Config configures the tracing of a service.
+type Config struct {
+	// Service is the name the spans are exported under.
+	Service string
+
+	// Exporter is where the spans are sent: "otlp", "stdout" or "none".
+	Exporter string
+
+	// Endpoint is the base URL of the OTLP/HTTP receiver, such as
+	// http://localhost:4318, for the otlp exporter.
+	Endpoint string
+
+	// SampleRatio is the fraction of the new traces that are sampled. The
+	// traces started by another service are sampled if it sampled them.
+	SampleRatio float64
+
+	// MaxPerSecond limits the new traces sampled per second, if positive.
+	MaxPerSecond float64
+}
+
+// Client starts the spans of a service and exports them.
+type Client struct {
+	sampler  *sampler
+	exporter exporter
+	log      *logrus.Entry
+
+	mu     sync.RWMutex
+	closed bool
+	spans  chan *Span
+	done   chan struct{}
+}
+
+// NewClient returns a client exporting the spans as configured.
+func NewClient(cfg Config, log *logrus.Entry) (*Client, error) {
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, errors.Errorf("trace sample ratio %v is not between 0 and 1", cfg.SampleRatio)
+	}
+	c := &Client{
+		sampler: &sampler{
+			ratio: cfg.SampleRatio,
+			max:   cfg.MaxPerSecond,
+			rnd:   mrand.New(mrand.NewSource(time.Now().UnixNano()))},
+		log:   log.WithField("facility", "trace"),
+		spans: make(chan *Span, queueSize),
+		done:  make(chan struct{})}
+	switch cfg.Exporter {
+	case "none", "":
+		close(c.done)
+		return c, nil
+	case "stdout":
+		c.exporter = newStdoutExporter(cfg.Service)
+	case "otlp":
+		if cfg.Endpoint == "" {
+			return nil, errors.New("otlp trace exporter needs an endpoint")
+		}
+		c.exporter = newOTLPExporter(cfg.Service, cfg.Endpoint)
+	default:
+		return nil, errors.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+	go c.run()
+	return c, nil
+}
+
+// NewSpan starts the root span of a new trace.
+func (c *Client) NewSpan(name string) *Span {
+	s := &Span{
+		client:  c,
+		kind:    kindInternal,
+		name:    name,
+		start:   time.Now(),
+		sampled: c.sampler.sample()}
+	s.traceID = newTraceID()
+	s.spanID = newSpanID()
+	return s
+}
+
+// newRemoteChild starts a span of the trace in the traceparent header of a
+// caller, or of a new trace if the header is not valid.
+func (c *Client) newRemoteChild(name, traceparent string, kind int) *Span {
+	traceID, parentID, sampled, ok := parseTraceparent(traceparent)
+	if !ok {
+		s := c.NewSpan(name)
+		s.kind = kind
+		return s
+	}
+	return &Span{
+		client:   c,
+		traceID:  traceID,
+		spanID:   newSpanID(),
+		parentID: parentID,
+		sampled:  sampled,
+		kind:     kind,
+		name:     name,
+		start:    time.Now()}
+}
+
+// Shutdown exports the spans that are finished and not exported yet. The
+// spans finished afterwards are dropped.
+func (c *Client) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	if !c.closed {
+		c.closed = true
+		if c.exporter != nil {
+			close(c.spans)
+		}
+	}
+	c.mu.Unlock()
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "failed to export the remaining spans")
+	}
+}
+
+// Span is an operation in a trace. The methods of a nil Span do nothing, so
+// that the code can be traced without checking whether it is.
+type Span struct {
+	client   *Client
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	sampled  bool
+	kind     int
+	name     string
+	start    time.Time
+
+	mu       sync.Mutex
+	end      time.Time
+	labels   map[string]string
+	finished bool
+}
+
+// NewChild starts a child span of s.
+func (s *Span) NewChild(name string) *Span {
+	if s == nil {
+		return nil
+	}
+	return &Span{
+		client:   s.client,
+		traceID:  s.traceID,
+		spanID:   newSpanID(),
+		parentID: s.spanID,
+		sampled:  s.sampled,
+		kind:     kindInternal,
+		name:     name,
+		start:    time.Now()}
+}
+
+// SetLabel sets an attribute of the span.
+func (s *Span) SetLabel(key, value string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.labels == nil {
+		s.labels = make(map[string]string)
+	}
+	s.labels[key] = value
+}
+
+// Finish ends the span and queues it to be exported if it is sampled.
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.finished {
+		s.mu.Unlock()
+		return
+	}
+	s.finished, s.end = true, time.Now()
+	s.mu.Unlock()
+	if s.sampled {
+		s.client.enqueue(s)
+	}
+}
+
+// TraceID returns the id of the trace of the span, in hex.
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.traceID[:])
+}
+
+// SpanID returns the id of the span, in hex.
+func (s *Span) SpanID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.spanID[:])
+}
+
+// Traceparent returns the W3C traceparent header identifying the span as the
+// parent of the spans of the callee.
+func (s *Span) Traceparent() string {
+	if s == nil {
+		return ""
+	}
+	var flags byte
+	if s.sampled {
+		flags = 1
+	}
+	return fmt.Sprintf("00-%x-%x-%02x", s.traceID, s.spanID, flags)
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the span.
+func NewContext(ctx context.Context, s *Span) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext returns the span in the context, or nil if there is none.
+func FromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(contextKey{}).(*Span)
+	return s
+}
+
+func newTraceID() (v [16]byte) {
+	randomID(v[:])
+	return v
+}
+
+func newSpanID() (v [8]byte) {
+	randomID(v[:])
+	return v
+}
+
+// randomID fills b with random bytes, which are not all zero as required by
+// the W3C trace context.
+func randomID(b []byte) {
+	for {
+		if _, err := rand.Read(b); err != nil {
+			panic(err)
+		}
+		for _, v := range b {
+			if v != 0 {
+				return
+			}
+		}
+	}
+}
+
+// sampler picks the new traces to sample.
+type sampler struct {
+	ratio float64
+	max   float64
+
+	mu     sync.Mutex
+	rnd    *mrand.Rand
+	second int64
+	count  float64
+}
+
+func (s *sampler) sample() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rnd.Float64() >= s.ratio {
+		return false
+	}
+	if s.max > 0 {
+		if now := time.Now().Unix(); now != s.second {
+			s.second, s.count = now, 0
+		}
+		if s.count >= s.max {
+			return false
+		}
+		s.count++
+	}
+	return true
+}
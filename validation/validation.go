@@ -0,0 +1,158 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package validation checks the activities users post, both in the web
+// frontend, to show the users what to fix in the form, and in the coffee
+// directory, before they are saved.
+//
+// The errors are reported per field, keyed by the names of the fields in the
+// API.
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+)
+
+// Maximum lengths of the text fields, in characters.
+const (
+	MaxDrinkLength   = 50
+	MaxRoasterLength = 100
+	MaxNotesLength   = 2000
+)
+
+var (
+	// Methods are the brew methods that can be picked for homebrews.
+	Methods = []string{
+		"Espresso",
+		"Chemex",
+		"Aeropress",
+		"Hario V60",
+		"French press",
+		"Dripper",
+		"Kyoto Dripper",
+		"Moka Pot",
+		"Turkish coffee",
+	}
+
+	// Origins are the countries the beans can be from, by region.
+	Origins = map[string][]string{
+		"Africa":   {"Kenya", "Ethiophia", "Nigeria", "Burundi", "Rwanda"},
+		"Americas": {"Colombia", "Venezuela", "Brazil", "Peru", "Cuba", "Ecuador", "Honduras", "Mexico", "Costa Rica"},
+		"Asia":     {"Indonesia", "India", "Vietnam"},
+	}
+
+	// amountRanges are the smallest and largest amounts of each unit.
+	amountRanges = map[pb.Activity_DrinkAmount_CaffeineUnit][2]int32{
+		pb.Activity_DrinkAmount_SHOTS:  {1, 8},
+		pb.Activity_DrinkAmount_OUNCES: {1, 64},
+	}
+)
+
+// Errors are the problems with the fields of a request, keyed by the field.
+type Errors map[string]string
+
+// Error lists the problems sorted by field.
+func (e Errors) Error() string {
+	fields := make([]string, 0, len(e))
+	for f := range e {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	msgs := make([]string, len(fields))
+	for i, f := range fields {
+		msgs[i] = e[f]
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Add records the problem with the field, unless it already has one.
+func (e Errors) Add(field, format string, args ...interface{}) {
+	if _, ok := e[field]; !ok {
+		e[field] = fmt.Sprintf(format, args...)
+	}
+}
+
+// Err returns the errors, or nil if there are none.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Activity checks the activity to post and adds its problems to errs.
+func Activity(req *pb.PostActivityRequest, errs Errors) {
+	drink := strings.TrimSpace(req.GetDrink())
+	if drink == "" {
+		errs.Add("drink", "drink is required")
+	}
+	maxLength(errs, "drink", drink, MaxDrinkLength)
+	maxLength(errs, "roaster", req.GetRoasterName(), MaxRoasterLength)
+	maxLength(errs, "notes", req.GetNotes(), MaxNotesLength)
+
+	if m := req.GetMethod(); m != "" && !contains(Methods, m) {
+		errs.Add("method", "unknown brew method %q", m)
+	}
+	if o := req.GetOrigin(); o != "" && !isOrigin(o) {
+		errs.Add("origin", "unknown origin %q", o)
+	}
+	if _, ok := pb.Visibility_name[int32(req.GetVisibility())]; !ok {
+		errs.Add("visibility", "unknown visibility")
+	}
+
+	if a := req.GetAmount(); a != nil {
+		if a.GetUnit() == pb.Activity_DrinkAmount_UNSPECIFIED {
+			if a.GetN() != 0 {
+				errs.Add("amount", "amount needs a unit")
+			}
+		} else if r, ok := amountRanges[a.GetUnit()]; !ok {
+			errs.Add("amount", "unknown amount unit")
+		} else if a.GetN() < r[0] || a.GetN() > r[1] {
+			errs.Add("amount", "amount must be between %d and %d %s", r[0], r[1], strings.ToLower(a.GetUnit().String()))
+		}
+	}
+
+	if p := req.GetPicture(); p != nil && len(p.GetData()) > 0 && !strings.HasPrefix(p.GetContentType(), "image/") {
+		errs.Add("picture", "uploaded file is not a picture")
+	}
+}
+
+func maxLength(errs Errors, field, v string, n int) {
+	if utf8.RuneCountInString(v) > n {
+		errs.Add(field, "%s must be at most %d characters", field, n)
+	}
+}
+
+func isOrigin(o string) bool {
+	for _, countries := range Origins {
+		if contains(countries, o) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(l []string, s string) bool {
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	pb "github.com/ahmetb/coffeelog/coffeelog"
+)
+
+func TestActivity(t *testing.T) {
+	shots := func(n int32) *pb.Activity_DrinkAmount {
+		return &pb.Activity_DrinkAmount{N: n, Unit: pb.Activity_DrinkAmount_SHOTS}
+	}
+	tests := []struct {
+		name string
+		req  pb.PostActivityRequest
+		want []string // fields with errors
+	}{
+		{name: "minimal",
+			req: pb.PostActivityRequest{Drink: "Latte"}},
+		{name: "complete",
+			req: pb.PostActivityRequest{Drink: "Latte", RoasterName: "Blue Bottle", Method: "Chemex",
+				Origin: "Kenya", Amount: shots(2), Visibility: pb.Visibility_FOLLOWERS,
+				Picture: &pb.PostActivityRequest_File{Data: []byte{1}, ContentType: "image/png"}}},
+		{name: "no drink",
+			req: pb.PostActivityRequest{Drink: "  "}, want: []string{"drink"}},
+		{name: "drink at the limit in characters",
+			req: pb.PostActivityRequest{Drink: strings.Repeat("é", MaxDrinkLength)}},
+		{name: "drink too long",
+			req: pb.PostActivityRequest{Drink: strings.Repeat("a", MaxDrinkLength+1)}, want: []string{"drink"}},
+		{name: "roaster too long",
+			req:  pb.PostActivityRequest{Drink: "Latte", RoasterName: strings.Repeat("a", MaxRoasterLength+1)},
+			want: []string{"roaster"}},
+		{name: "notes too long",
+			req:  pb.PostActivityRequest{Drink: "Latte", Notes: strings.Repeat("a", MaxNotesLength+1)},
+			want: []string{"notes"}},
+		{name: "unknown method",
+			req: pb.PostActivityRequest{Drink: "Latte", Method: "Percolator"}, want: []string{"method"}},
+		{name: "unknown origin",
+			req: pb.PostActivityRequest{Drink: "Latte", Origin: "Atlantis"}, want: []string{"origin"}},
+		{name: "unknown visibility",
+			req: pb.PostActivityRequest{Drink: "Latte", Visibility: pb.Visibility(42)}, want: []string{"visibility"}},
+		{name: "amount without a unit",
+			req:  pb.PostActivityRequest{Drink: "Latte", Amount: &pb.Activity_DrinkAmount{N: 2}},
+			want: []string{"amount"}},
+		{name: "no amount without a unit",
+			req: pb.PostActivityRequest{Drink: "Latte", Amount: &pb.Activity_DrinkAmount{}}},
+		{name: "too few shots",
+			req: pb.PostActivityRequest{Drink: "Latte", Amount: shots(0)}, want: []string{"amount"}},
+		{name: "too many shots",
+			req: pb.PostActivityRequest{Drink: "Latte", Amount: shots(9)}, want: []string{"amount"}},
+		{name: "unknown unit",
+			req:  pb.PostActivityRequest{Drink: "Latte", Amount: &pb.Activity_DrinkAmount{N: 1, Unit: 42}},
+			want: []string{"amount"}},
+		{name: "not a picture",
+			req: pb.PostActivityRequest{Drink: "Latte",
+				Picture: &pb.PostActivityRequest_File{Data: []byte{1}, ContentType: "text/html"}},
+			want: []string{"picture"}},
+		{name: "several problems",
+			req:  pb.PostActivityRequest{Method: "Percolator", Origin: "Atlantis"},
+			want: []string{"drink", "method", "origin"}},
+	}
+	for _, tt := range tests {
+		errs := make(Errors)
+		Activity(&tt.req, errs)
+		var got []string
+		for f := range errs {
+			got = append(got, f)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got errors for %v, expected %v (%v)", tt.name, got, tt.want, errs)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	errs := make(Errors)
+	if err := errs.Err(); err != nil {
+		t.Fatalf("got %v, expected no error", err)
+	}
+	errs.Add("origin", "unknown origin %q", "Atlantis")
+	errs.Add("drink", "drink is required")
+	errs.Add("drink", "drink must be at most %d characters", MaxDrinkLength)
+	want := `drink is required; unknown origin "Atlantis"`
+	if err := errs.Err(); err == nil || err.Error() != want {
+		t.Errorf("got %v, expected %q", err, want)
+	}
+}